@@ -0,0 +1,193 @@
+package goe
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v3"
+	"go.oease.dev/goe/contracts"
+	"go.oease.dev/goe/core"
+	"go.oease.dev/goe/modules/health"
+	"go.oease.dev/goe/modules/i18n"
+	"go.oease.dev/goe/modules/metrics"
+	"go.oease.dev/goe/modules/tracing"
+	"time"
+)
+
+// Container returns the module container of the app.
+func (app *App) Container() *core.Container {
+	return app.container
+}
+
+// Config returns the typed framework configuration of the app.
+func (app *App) Config() *core.GoeConfig {
+	return app.configs
+}
+
+// Health returns the health registry of the app, application modules can register their own checkers to it.
+func (app *App) Health() *health.Registry {
+	return app.container.GetHealth()
+}
+
+// Metrics returns the prometheus metrics of the app, application collectors can be registered to it.
+// It is nil unless METRICS_ENABLED is true.
+func (app *App) Metrics() *metrics.Metrics {
+	return app.container.GetMetrics()
+}
+
+// Tracing returns the OpenTelemetry tracing of the app, its tracer can start the spans of the application.
+// It is nil unless TRACING_ENABLED is true.
+func (app *App) Tracing() *tracing.Tracing {
+	return app.container.GetTracing()
+}
+
+// I18n returns the message catalogs of the app, see core.T to translate to the locale of a request.
+// It is nil if the i18n module is disabled.
+func (app *App) I18n() *i18n.Bundle {
+	return app.container.GetI18n()
+}
+
+func (app *App) DB() contracts.MongoDB {
+	return app.container.GetMongo()
+}
+
+func (app *App) Cron() contracts.CronJob {
+	return app.container.GetCron()
+}
+
+func (app *App) Log() contracts.Logger {
+	return app.container.GetLogger()
+}
+
+func (app *App) Cfg() contracts.Config {
+	return app.container.GetConfig()
+}
+
+func (app *App) MQ() contracts.Queue {
+	return app.container.GetQueue()
+}
+
+func (app *App) Cache() contracts.Cache {
+	return app.container.GetCache()
+}
+
+func (app *App) Search() contracts.Meilisearch {
+	return app.container.GetMeilisearch()
+}
+
+func (app *App) Mailer() contracts.Mailer {
+	return app.container.GetMailer()
+}
+
+func (app *App) Fiber() contracts.GoeFiber {
+	return app.container.GetFiber()
+}
+
+func (app *App) EMQX() contracts.EMQX {
+	return app.container.GetEMQX()
+}
+
+// RegisterModule registers an application module to the app container.
+// The module is initialized immediately, its dependencies must be registered before it, and it is started when the app starts running.
+func (app *App) RegisterModule(m core.Module) error {
+	return app.container.RegisterModule(m)
+}
+
+// Run runs the app in the run mode configured by GOE_RUN_MODE, default is all, see RunMode.
+func (app *App) Run() error {
+	mode, err := core.ParseRunMode(app.configs.App.RunMode)
+	if err != nil {
+		return err
+	}
+	return app.RunMode(mode)
+}
+
+// RunWorker runs the queue consumers of the app only, without opening an http port.
+func (app *App) RunWorker() error {
+	return app.RunMode(core.RunModeWorker)
+}
+
+// RunScheduler runs the cron scheduler of the app only, without opening an http port.
+func (app *App) RunScheduler() error {
+	return app.RunMode(core.RunModeScheduler)
+}
+
+// RunMode starts the modules active in the given run mode, and the http server if the mode includes web.
+// It blocks until the app is shut down by a signal, and returns the errors of the shutdown steps which failed or timed out.
+func (app *App) RunMode(mode core.RunMode) error {
+	if app.running {
+		return errors.New("app is already running")
+	}
+	serveHTTP := mode.Includes(core.RunModeWeb)
+	if serveHTTP && app.container.GetFiber() == nil {
+		return errors.New("fiber module is disabled, the app cannot serve http requests")
+	}
+	signals, err := shutdownSignals(app.configs.Shutdown.Signals)
+	if err != nil {
+		return err
+	}
+	if err := app.container.StartMode(mode); err != nil {
+		return err
+	}
+	app.container.GetLogger().Infof("App is running in %s mode", mode)
+	if serveHTTP {
+		go app.listen()
+	}
+	app.running = true
+	app.container.GetHealth().SetReady(true)
+	newShutdownHook(signals...).Close(func() {
+		err = app.shutdown()
+	})
+	return err
+}
+
+// listen serves the http server, it returns when the server shuts down.
+func (app *App) listen() {
+	err := app.container.GetFiber().App().Listen(":"+app.configs.Http.Port, fiber.ListenConfig{
+		DisableStartupMessage: true,
+		EnablePrefork:         false,
+		EnablePrintRoutes:     false,
+		OnShutdownError: func(err error) {
+			app.container.GetLogger().Error("Shutdown error: ", err)
+		},
+	})
+	if err != nil {
+		app.running = false
+		app.container.GetLogger().Panic("Server error: ", err)
+	}
+}
+
+// shutdown gracefully shuts down the app within the configured timeouts, see core.Container.Shutdown for the steps.
+func (app *App) shutdown() error {
+	app.running = false
+	logger := app.container.GetLogger()
+	logger.Info("Shutting down the app...")
+	// mark the app as not ready first, so load balancers stop routing traffic before the server shuts down
+	app.container.GetHealth().SetReady(false)
+	if drainDelay := app.configs.Health.DrainDelay; drainDelay > 0 && app.container.GetRunMode().Includes(core.RunModeWeb) {
+		logger.Infof("Draining traffic for %d seconds before shutdown...", drainDelay)
+		time.Sleep(time.Duration(drainDelay) * time.Second)
+	}
+	ctx := context.Background()
+	if app.configs.Shutdown.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(app.configs.Shutdown.Timeout)*time.Second)
+		defer cancel()
+	}
+	err := app.container.Shutdown(ctx, time.Duration(app.configs.Shutdown.ComponentTimeout)*time.Second)
+	if err != nil {
+		logger.Error("App shutdown with errors: ", err)
+		return err
+	}
+	logger.Info("App has shutdown successfully!")
+	return nil
+}
+
+// AddShutdownHook adds hooks which are called when the app shuts down in any run mode, it must be called before Run().
+// The hooks are called after the http server stops, and before the modules are drained and stopped.
+func (app *App) AddShutdownHook(hookHandlers ...func() error) error {
+	if app.running {
+		return errors.New("app is already running, shutdown hook must be added before calling Run()")
+	}
+	app.container.OnShutdown(hookHandlers...)
+	return nil
+}
@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go.oease.dev/goe"
+	"go.oease.dev/goe/contracts"
+	"go.oease.dev/goe/core"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Command is a command of the CLI, the name can contain spaces for sub commands, such as "queue stats".
+type Command struct {
+	// Name is the words to type to run the command, such as "serve" or "queue stats"
+	Name string
+	// Description is a one line description shown in the usage
+	Description string
+	// Flags defines the flags of the command, optional
+	Flags func(fs *flag.FlagSet)
+	// Run runs the command, the app is initialized before Run is called
+	Run func(ctx *Context) error
+	// ConfigOnly runs the command with the config loaded only, the app is not created and no backend is connected, Context.App is nil then.
+	// The setups are not called either.
+	ConfigOnly bool
+}
+
+// Context is passed to the command, it gives access to the initialized app and the command line.
+type Context struct {
+	// App is the initialized default app, goe.UseDB() and the other helpers can be used as well, it is nil for the ConfigOnly commands
+	App *goe.App
+	// Config is the loaded config, and AppConfig the built-in settings bound from it
+	Config    contracts.Config
+	AppConfig *core.GoeConfig
+	// Flags is the parsed flag set of the command
+	Flags *flag.FlagSet
+	// Args is the remaining arguments after the flags
+	Args []string
+	// Out is the output of the command, default is os.Stdout
+	Out io.Writer
+	// configs are the application config structs registered by CLI.Config
+	configs []appConfig
+}
+
+// appConfig is an application config struct printed by config print.
+type appConfig struct {
+	name string
+	ptr  any
+}
+
+// CLI turns an app binary into a multi-command tool, with built-in commands to serve, consume queues, run cron jobs and maintain the app.
+type CLI struct {
+	name     string
+	options  []goe.Option
+	setups   []func(app *goe.App) error
+	configs  []appConfig
+	commands map[string]*Command
+	out      io.Writer
+}
+
+// New creates a CLI with the built-in commands, the app is created with the given options when a command runs.
+func New(name string, opts ...goe.Option) *CLI {
+	c := &CLI{
+		name:     name,
+		options:  opts,
+		commands: make(map[string]*Command),
+		out:      os.Stdout,
+	}
+	for _, cmd := range builtinCommands() {
+		c.commands[cmd.Name] = cmd
+	}
+	return c
+}
+
+// Setup adds functions called after the app is initialized and before the command runs.
+// Use it to define routes, queues and cron jobs, so that every command sees the same app.
+func (c *CLI) Setup(fns ...func(app *goe.App) error) *CLI {
+	c.setups = append(c.setups, fns...)
+	return c
+}
+
+// Config registers an application config struct, ptr is a pointer to the struct bound by the app with Bind.
+// config print binds it from the config and prints it under name, next to the built-in settings printed under goe, with its secrets masked.
+func (c *CLI) Config(name string, ptr any) *CLI {
+	c.configs = append(c.configs, appConfig{name: name, ptr: ptr})
+	return c
+}
+
+// Register registers application commands, a command with the name of a registered command replaces it.
+func (c *CLI) Register(cmds ...*Command) error {
+	for _, cmd := range cmds {
+		if cmd == nil {
+			return errors.New("command is nil")
+		}
+		name := strings.Join(strings.Fields(cmd.Name), " ")
+		if name == "" {
+			return errors.New("command name is required")
+		}
+		if cmd.Run == nil {
+			return fmt.Errorf("command %s has no Run function", name)
+		}
+		cmd.Name = name
+		c.commands[name] = cmd
+	}
+	return nil
+}
+
+// SetOutput sets the output of the usage and the commands, default is os.Stdout.
+func (c *CLI) SetOutput(w io.Writer) {
+	c.out = w
+}
+
+// Execute runs the command given by the process arguments, and exits with status 1 if it fails.
+func (c *CLI) Execute() {
+	if err := c.Run(os.Args[1:]); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// Run runs the command matching the arguments, the arguments do not include the program name.
+// The command can be preceded by --set KEY=value flags, they override the config files and the environment variables.
+func (c *CLI) Run(args []string) error {
+	overrides, args, err := parseOverrides(args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.printUsage()
+		return nil
+	}
+	cmd, rest := c.match(args)
+	if cmd == nil {
+		c.printUsage()
+		return fmt.Errorf("unknown command: %s", strings.Join(args, " "))
+	}
+	fs := flag.NewFlagSet(c.name+" "+cmd.Name, flag.ContinueOnError)
+	fs.SetOutput(c.out)
+	if cmd.Flags != nil {
+		cmd.Flags(fs)
+	}
+	if err := fs.Parse(rest); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	opts := c.options
+	if len(overrides) > 0 {
+		opts = append(opts[:len(opts):len(opts)], goe.WithConfigOverrides(overrides))
+	}
+	ctx := &Context{
+		Flags:   fs,
+		Args:    fs.Args(),
+		Out:     c.out,
+		configs: c.configs,
+	}
+	if cmd.ConfigOnly {
+		if ctx.Config, ctx.AppConfig, err = goe.LoadConfig(opts...); err != nil {
+			return err
+		}
+		return cmd.Run(ctx)
+	}
+	if err := goe.NewApp(opts...); err != nil {
+		return err
+	}
+	app := goe.Default()
+	// commands which do not run the app still need their connections closed, closing twice is a no-op
+	defer func() {
+		_ = app.Container().Close()
+	}()
+	for _, setup := range c.setups {
+		if err := setup(app); err != nil {
+			return err
+		}
+	}
+	ctx.App, ctx.Config, ctx.AppConfig = app, app.Cfg(), app.Config()
+	return cmd.Run(ctx)
+}
+
+// parseOverrides reads the --set KEY=value flags preceding the command, and returns the remaining arguments.
+func parseOverrides(args []string) (map[string]string, []string, error) {
+	overrides := make(map[string]string)
+	for len(args) > 0 {
+		var pair string
+		switch arg := args[0]; {
+		case arg == "--set" || arg == "-set":
+			if len(args) < 2 {
+				return nil, nil, errors.New("flag --set needs a KEY=value argument")
+			}
+			pair, args = args[1], args[2:]
+		case strings.HasPrefix(arg, "--set=") || strings.HasPrefix(arg, "-set="):
+			pair, args = arg[strings.Index(arg, "=")+1:], args[1:]
+		default:
+			return overrides, args, nil
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, nil, fmt.Errorf("invalid --set %q, expected KEY=value", pair)
+		}
+		overrides[key] = value
+	}
+	return overrides, args, nil
+}
+
+// match returns the command with the most name words matching the beginning of the arguments, and the remaining arguments.
+func (c *CLI) match(args []string) (*Command, []string) {
+	var matched *Command
+	matchedWords := 0
+	for _, cmd := range c.commands {
+		words := strings.Fields(cmd.Name)
+		if len(words) > len(args) || len(words) <= matchedWords {
+			continue
+		}
+		ok := true
+		for i, word := range words {
+			if args[i] != word {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = cmd
+			matchedWords = len(words)
+		}
+	}
+	if matched == nil {
+		return nil, nil
+	}
+	return matched, args[matchedWords:]
+}
+
+func (c *CLI) printUsage() {
+	names := make([]string, 0, len(c.commands))
+	for name := range c.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	_, _ = fmt.Fprintf(c.out, "Usage: %s [--set KEY=value]... <command> [flags]\n\nCommands:\n", c.name)
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	for _, name := range names {
+		_, _ = fmt.Fprintf(w, "  %s\t%s\n", name, c.commands[name].Description)
+	}
+	_ = w.Flush()
+	_, _ = fmt.Fprintf(c.out, "\nRun '%s <command> -h' for the flags of a command.\n", c.name)
+}
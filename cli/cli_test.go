@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"bytes"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.oease.dev/goe"
+	"go.oease.dev/goe/modules/config"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOverrides(t *testing.T) {
+	overrides, args, err := parseOverrides([]string{"--set", "HTTP_PORT=8080", "-set=queue.concurrency=4", "--set", "A=b=c", "serve", "--set", "X=1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"HTTP_PORT": "8080", "queue.concurrency": "4", "A": "b=c"}, overrides)
+	// the flags after the command belong to the command
+	assert.Equal(t, []string{"serve", "--set", "X=1"}, args)
+
+	overrides, args, err = parseOverrides([]string{"--set", "EMPTY=", "config", "print"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"EMPTY": ""}, overrides)
+	assert.Equal(t, []string{"config", "print"}, args)
+
+	_, _, err = parseOverrides([]string{"--set"})
+	assert.EqualError(t, err, "flag --set needs a KEY=value argument")
+	_, _, err = parseOverrides([]string{"--set", "HTTP_PORT", "serve"})
+	assert.EqualError(t, err, `invalid --set "HTTP_PORT", expected KEY=value`)
+	_, _, err = parseOverrides([]string{"--set==1", "serve"})
+	assert.EqualError(t, err, `invalid --set "=1", expected KEY=value`)
+}
+
+func TestMatch(t *testing.T) {
+	c := New("app")
+	require.NoError(t, c.Register(
+		&Command{Name: "queue", Run: func(*Context) error { return nil }},
+		&Command{Name: " queue   purge ", Run: func(*Context) error { return nil }},
+	))
+
+	cmd, rest := c.match([]string{"queue", "stats", "-h"})
+	require.NotNil(t, cmd)
+	assert.Equal(t, "queue stats", cmd.Name)
+	assert.Equal(t, []string{"-h"}, rest)
+
+	// the command with the most matching words wins, the names are normalized when registered
+	cmd, rest = c.match([]string{"queue", "purge", "email"})
+	require.NotNil(t, cmd)
+	assert.Equal(t, "queue purge", cmd.Name)
+	assert.Equal(t, []string{"email"}, rest)
+
+	cmd, rest = c.match([]string{"queue", "drain"})
+	require.NotNil(t, cmd)
+	assert.Equal(t, "queue", cmd.Name)
+	assert.Equal(t, []string{"drain"}, rest)
+
+	cmd, _ = c.match([]string{"search"})
+	assert.Nil(t, cmd)
+	assert.EqualError(t, c.Register(&Command{Name: "  "}), "command name is required")
+	assert.EqualError(t, c.Register(&Command{Name: "users purge"}), "command users purge has no Run function")
+}
+
+func TestMaskedConfig(t *testing.T) {
+	type database struct {
+		Host     string `json:"host" env:"HOST"`
+		Password string `json:"password" env:"PASSWORD"`
+		DSN      string `json:"dsn" env:"DSN"`
+	}
+	type settings struct {
+		Name     string            `json:"name" env:"NAME"`
+		APIKey   string            `json:"api_key" env:"API_KEY"`
+		Cert     string            `json:"cert" env:"CERT" secret:"true"`
+		Empty    string            `json:"empty" env:"EMPTY" secret:"true"`
+		DB       *database         `json:"db" prefix:"DB_"`
+		Replicas []database        `json:"replicas"`
+		Labels   map[string]string `json:"labels"`
+		Ignored  string            `json:"-"`
+		Hook     func()            `json:"hook"`
+		private  string
+	}
+	v := &settings{
+		Name:     "shop",
+		APIKey:   "key-1",
+		Cert:     "-----BEGIN CERTIFICATE-----",
+		DB:       &database{Host: "db", Password: "pass", DSN: "postgres://u:p@db"},
+		Replicas: []database{{Host: "replica"}},
+		Labels:   map[string]string{"team": "core"},
+		Ignored:  "ignored",
+		Hook:     func() {},
+		private:  "private",
+	}
+	// DB_DSN is reported as a secret by the config, such as a value resolved from a secret provider
+	isSecret := func(key string) bool { return key == "DB_DSN" }
+	assert.Equal(t, map[string]any{
+		"name":    "shop",
+		"api_key": "******",
+		"cert":    "******",
+		"empty":   "",
+		"db": map[string]any{
+			"host":     "db",
+			"password": "******",
+			"dsn":      "******",
+		},
+		"replicas": []any{map[string]any{"host": "replica", "password": "", "dsn": ""}},
+		"labels":   map[string]string{"team": "core"},
+	}, maskedConfig(reflect.ValueOf(v), "", isSecret))
+	assert.Nil(t, maskedConfig(reflect.ValueOf((*settings)(nil)), "", isSecret))
+}
+
+// runCLI runs the command of a CLI whose app cannot connect to its backends, and fails the test if the app is created.
+func runCLI(t *testing.T, c *CLI, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	c.SetOutput(&out)
+	c.Setup(func(app *goe.App) error {
+		t.Fatal("the app is created")
+		return nil
+	})
+	err := c.Run(args)
+	return out.String(), err
+}
+
+func newConfigCLI(values map[string]string) *CLI {
+	env := map[string]string{
+		"APP_NAME":        "shop",
+		"MONGODB_ENABLED": "true",
+		"MONGODB_URI":     "mongodb://127.0.0.1:1",
+		"REDIS_HOST":      "127.0.0.1",
+		"REDIS_PORT":      "1",
+		"REDIS_PASSWORD":  "redis-pass",
+	}
+	for k, v := range values {
+		env[k] = v
+	}
+	return New("shop", goe.WithConfig(config.NewFromMap(env)))
+}
+
+func TestConfigPrint(t *testing.T) {
+	type payment struct {
+		Provider string `json:"provider" env:"PAYMENT_PROVIDER" default:"stripe"`
+		Secret   string `json:"webhook" env:"PAYMENT_WEBHOOK" secret:"true"`
+	}
+	c := newConfigCLI(map[string]string{"PAYMENT_WEBHOOK": "whsec_1", "SHOP_NAME": "Goe Shop"})
+	c.Config("payment", &payment{})
+	out, err := runCLI(t, c, "config", "print")
+	require.NoError(t, err)
+	var printed map[string]map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &printed))
+	assert.Equal(t, map[string]any{"provider": "stripe", "webhook": "******"}, printed["payment"])
+	assert.Equal(t, "shop", printed["goe"]["App"].(map[string]any)["name"])
+	assert.Equal(t, "******", printed["goe"]["Redis"].(map[string]any)["password"])
+
+	out, err = runCLI(t, c, "config", "print", "SHOP_NAME", "REDIS_PASSWORD", "MISSING")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"SHOP_NAME": "Goe Shop", "REDIS_PASSWORD": "******", "MISSING": ""}`, out)
+}
+
+func TestConfigOnlyCommands(t *testing.T) {
+	c := newConfigCLI(map[string]string{config.MasterKeyName: "0123456789abcdef"})
+	out, err := runCLI(t, c, "config", "encrypt", "s3cret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "enc:"), out)
+
+	_, err = runCLI(t, c, "log", "level")
+	assert.EqualError(t, err, "LOG_ADMIN_TOKEN is not set, the log level endpoint of the app is disabled")
+}
@@ -0,0 +1,351 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/goccy/go-json"
+	"go.oease.dev/goe/core"
+	"go.oease.dev/goe/modules/config"
+	"go.oease.dev/goe/modules/log"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func builtinCommands() []*Command {
+	return []*Command{
+		{
+			Name:        "serve",
+			Description: "Run the app, in the run mode of GOE_RUN_MODE by default",
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("mode", "", "run mode: web, worker, scheduler or all")
+			},
+			Run: runServe,
+		},
+		{
+			Name:        "worker",
+			Description: "Run the queue consumers only, without the http server",
+			Run: func(ctx *Context) error {
+				return ctx.App.RunWorker()
+			},
+		},
+		{
+			Name:        "cron",
+			Description: "Run the cron scheduler only, without the http server",
+			Run: func(ctx *Context) error {
+				return ctx.App.RunScheduler()
+			},
+		},
+		{
+			Name:        "queue stats",
+			Description: "Print the message counts of the declared queues",
+			Run:         runQueueStats,
+		},
+		{
+			Name:        "search reindex",
+			Description: "Rebuild all meilisearch indexes from mongodb",
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("index-config", "", "path of the index config json file, required if the app does not apply it in Setup")
+			},
+			Run: runSearchReindex,
+		},
+		{
+			Name:        "config print",
+			Description: "Print the resolved app configuration, or the values of the given keys, secrets are masked",
+			Run:         runConfigPrint,
+			ConfigOnly:  true,
+		},
+		{
+			Name:        "config encrypt",
+			Description: "Encrypt a value with CONFIG_MASTER_KEY for the enc: config values, read from stdin if not given",
+			Run:         runConfigEncrypt,
+			ConfigOnly:  true,
+		},
+		{
+			Name:        "log level",
+			Description: "Print the log levels of the running app, or change one: log level [logger] <level|inherit>",
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("url", "", "url of the log level endpoint, default is LOG_LEVEL_PATH on the local HTTP_PORT")
+				fs.Duration("for", 0, "restore the previous level after the duration, such as 10m")
+			},
+			Run:        runLogLevel,
+			ConfigOnly: true,
+		},
+		{
+			Name:        "routes list",
+			Description: "Print the registered http routes",
+			Run:         runRoutesList,
+		},
+	}
+}
+
+func runServe(ctx *Context) error {
+	mode := ctx.Flags.Lookup("mode").Value.String()
+	if mode == "" {
+		return ctx.App.Run()
+	}
+	runMode, err := core.ParseRunMode(mode)
+	if err != nil {
+		return err
+	}
+	return ctx.App.RunMode(runMode)
+}
+
+func runQueueStats(ctx *Context) error {
+	q := ctx.App.MQ()
+	if q == nil {
+		return errors.New("queue module is disabled")
+	}
+	stats, err := q.Stats()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(ctx.Out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "QUEUE\tPENDING\tREADY\tPROCESSING")
+	for _, s := range stats {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", s.Name, s.Pending, s.Ready, s.Processing)
+	}
+	return w.Flush()
+}
+
+func runSearchReindex(ctx *Context) error {
+	search := ctx.App.Search()
+	if search == nil {
+		return errors.New("meilisearch module is disabled")
+	}
+	if path := ctx.Flags.Lookup("index-config").Value.String(); path != "" {
+		configData, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := search.ApplyIndexConfigs(configData); err != nil {
+			return err
+		}
+	}
+	cfg := ctx.App.Config().MongoDB
+	if err := search.RebuildAllIndexes(cfg.URI, cfg.DB); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(ctx.Out, "All indexes are rebuilt")
+	return nil
+}
+
+func runConfigPrint(ctx *Context) error {
+	// the values read from KEY_FILE files or resolved by the secret providers are masked as well
+	isSecret := func(key string) bool { return false }
+	if cfg, ok := ctx.Config.(interface{ IsSecret(key string) bool }); ok {
+		isSecret = cfg.IsSecret
+	}
+	out := make(map[string]any)
+	if len(ctx.Args) > 0 {
+		for _, key := range ctx.Args {
+			value := ctx.Config.Get(key)
+			if value != "" && (isSecret(key) || isSecretKey(key)) {
+				value = "******"
+			}
+			out[key] = value
+		}
+	} else {
+		out["goe"] = maskedConfig(reflect.ValueOf(ctx.AppConfig), "", isSecret)
+		for _, cfg := range ctx.configs {
+			if _, ok := out[cfg.name]; ok {
+				return fmt.Errorf("config %s is printed already", cfg.name)
+			}
+			if err := ctx.Config.Bind(cfg.ptr); err != nil {
+				return err
+			}
+			out[cfg.name] = maskedConfig(reflect.ValueOf(cfg.ptr), "", isSecret)
+		}
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(ctx.Out, string(data))
+	return nil
+}
+
+func runConfigEncrypt(ctx *Context) error {
+	var value string
+	switch len(ctx.Args) {
+	case 0:
+		// the value is read from stdin, so that it is not kept in the shell history
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	case 1:
+		value = ctx.Args[0]
+	default:
+		return errors.New("usage: config encrypt [value], the value is read from stdin if it is not given")
+	}
+	encrypted, err := config.Encrypt(ctx.Config.Get(config.MasterKeyName), value)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(ctx.Out, encrypted)
+	return nil
+}
+
+func runLogLevel(ctx *Context) error {
+	cfg := ctx.AppConfig
+	if cfg.Log.AdminToken == "" {
+		return errors.New("LOG_ADMIN_TOKEN is not set, the log level endpoint of the app is disabled")
+	}
+	url := ctx.Flags.Lookup("url").Value.String()
+	if url == "" {
+		url = "http://127.0.0.1:" + cfg.Http.Port + cfg.Log.LevelPath
+	}
+	var req core.LogLevelRequest
+	switch len(ctx.Args) {
+	case 0:
+	case 1:
+		req.Level = ctx.Args[0]
+	case 2:
+		req.Name, req.Level = ctx.Args[0], ctx.Args[1]
+	default:
+		return errors.New("usage: log level [logger] <level|inherit>, the levels are printed without arguments")
+	}
+	method := http.MethodGet
+	var body io.Reader
+	if len(ctx.Args) > 0 {
+		if req.Level == "inherit" {
+			req.Level = ""
+		}
+		if revertAfter := ctx.Flags.Lookup("for").Value.(flag.Getter).Get().(time.Duration); revertAfter > 0 {
+			req.RevertAfter = revertAfter.String()
+		}
+		data, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		method, body = http.MethodPut, bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+cfg.Log.AdminToken)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("log level request failed with %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	var levels []log.LoggerLevel
+	if err := json.Unmarshal(data, &levels); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(ctx.Out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "LOGGER\tLEVEL\tREVERT AT")
+	for _, l := range levels {
+		name, level, revertAt := l.Name, l.Level, ""
+		if name == "" {
+			name = "(root)"
+		}
+		if l.Inherited {
+			level += " (inherited)"
+		}
+		if l.RevertAt != nil {
+			revertAt = l.RevertAt.Local().Format(time.DateTime)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", name, level, revertAt)
+	}
+	return w.Flush()
+}
+
+func runRoutesList(ctx *Context) error {
+	fb := ctx.App.Fiber()
+	if fb == nil {
+		return errors.New("fiber module is disabled")
+	}
+	routes := fb.App().GetRoutes(true)
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path == routes[j].Path {
+			return routes[i].Method < routes[j].Method
+		}
+		return routes[i].Path < routes[j].Path
+	})
+	w := tabwriter.NewWriter(ctx.Out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "METHOD\tPATH\tNAME")
+	for _, r := range routes {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", r.Method, r.Path, r.Name)
+	}
+	return w.Flush()
+}
+
+// maskedConfig converts the config to a json friendly value, the values of secret fields are masked.
+// A field is secret if it is tagged with `secret:"true"`, if its name looks like a secret, or if isSecret reports its config key, prefix is the config key prefix of v.
+// Fields tagged with `json:"-"` or `yaml:"-"` and function values are skipped.
+func maskedConfig(v reflect.Value, prefix string, isSecret func(key string) bool) any {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return maskedConfig(v.Elem(), prefix, isSecret)
+	case reflect.Struct:
+		out := make(map[string]any, v.NumField())
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() || field.Tag.Get("json") == "-" || field.Tag.Get("yaml") == "-" {
+				continue
+			}
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Func || fv.Kind() == reflect.Chan {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" {
+				name = field.Name
+			}
+			env, hasEnv := field.Tag.Lookup("env")
+			if field.Tag.Get("secret") == "true" || isSecretKey(name) || hasEnv && isSecret(prefix+env) {
+				if !fv.IsZero() {
+					out[name] = "******"
+				} else {
+					out[name] = ""
+				}
+				continue
+			}
+			out[name] = maskedConfig(fv, prefix+field.Tag.Get("prefix"), isSecret)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		out := make([]any, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out[i] = maskedConfig(v.Index(i), prefix, isSecret)
+		}
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+// isSecretKey reports whether the config key holds a secret, such as password, app_secret, api_key or token.
+func isSecretKey(name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasSuffix(name, "password") || strings.HasSuffix(name, "secret") || strings.HasSuffix(name, "token") {
+		return true
+	}
+	return name == "key" || strings.HasSuffix(name, "_key")
+}
@@ -12,4 +12,10 @@ type Config interface {
 	GetOrDefaultString(key string, defaultValue string) string
 	GetOrDefaultInt(key string, defaultValue int) int
 	GetOrDefaultBool(key string, defaultValue bool) bool
+
+	// Bind sets the fields of the struct ptr points to from their env, default, required and prefix tags.
+	Bind(ptr any) error
+	// OnChange subscribes fn to the changes of the key made by the config reloads, the values are read at startup otherwise.
+	// fn returns an error if it cannot apply the new value, the change is logged as needing a restart then.
+	OnChange(key string, fn func(oldValue, newValue string) error)
 }
@@ -6,7 +6,10 @@
 // -------------------------------------------
 package contracts
 
-import "github.com/eclipse/paho.mqtt.golang"
+import (
+	"context"
+	"github.com/eclipse/paho.mqtt.golang"
+)
 
 type EMQX interface {
 	// Publish will publish a message with the specified QoS and content to the specified topic.
@@ -21,4 +24,8 @@ type EMQX interface {
 	// Close will end the connection with the server,
 	// but not before waiting 250 milliseconds to wait for existing work to be completed.
 	Close()
+	// IsConnected returns whether the client is connected to the broker.
+	IsConnected() bool
+	// WithContext returns a client whose publications are traced as children of the span in ctx.
+	WithContext(ctx context.Context) EMQX
 }
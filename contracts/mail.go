@@ -1,8 +1,11 @@
 package contracts
 
 import (
+	"context"
+	htmltemplate "html/template"
 	"io"
 	"net/mail"
+	texttemplate "text/template"
 )
 
 // MailProvider represents the type of email provider
@@ -35,6 +38,14 @@ type EmailSender interface {
 	Text(text string) EmailSender
 	Headers(h map[string]string) EmailSender
 	Attachments(a map[string]string) EmailSender
+	// WithContext sets the context of the email, the send span is a child of its span, also when the email is queued
+	WithContext(ctx context.Context) EmailSender
+	// Locale sets the locale of the email, the subject and the t function of the templates are translated to it
+	Locale(locale string) EmailSender
+	// HTMLTemplate renders the HTML body with the template and data when the email is sent
+	HTMLTemplate(tmpl *htmltemplate.Template, data any) EmailSender
+	// TextTemplate renders the text body with the template and data when the email is sent
+	TextTemplate(tmpl *texttemplate.Template, data any) EmailSender
 	Send(useQueue ...bool) error
 }
 
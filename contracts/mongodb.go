@@ -1,6 +1,7 @@
 package contracts
 
 import (
+	"context"
 	"go.oease.dev/goe/modules/mongodb"
 	"go.oease.dev/omgo"
 )
@@ -20,4 +21,6 @@ type MongoDB interface {
 	IsExist(model mongodb.IDefaultModel, filter any) (bool, error)
 	Count(model mongodb.IDefaultModel, filter any) (int64, error)
 	Client() *mongodb.MongoDB
+	// WithContext returns a MongoDB whose operations run with ctx, their spans are children of the span in ctx
+	WithContext(ctx context.Context) MongoDB
 }
@@ -1,16 +1,36 @@
 package contracts
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type QueueName string
 type Queue interface {
 	NewQueue(name QueueName, handler func(string) bool, cfgs ...*NewQueueCfg) error
+	// NewQueueContext is NewQueue with a handler receiving the context of the message, which continues the trace of the pusher
+	NewQueueContext(name QueueName, handler func(ctx context.Context, payload string) bool, cfgs ...*NewQueueCfg) error
 	PushRaw(queueName QueueName, payload string) error
 	Push(queueName QueueName, payloadPtr any) error
 	PushDelayed(queueName QueueName, payloadPtr any, delayDuration time.Duration) error
 	PushDelayedRaw(queueName QueueName, payload string, delayDuration time.Duration) error
 	PushScheduledRaw(queueName QueueName, payload string, t time.Time) error
 	PushScheduled(queueName QueueName, payloadPtr any, t time.Time) error
+	// WithContext returns a Queue whose pushed messages carry the trace of the span and the request id in ctx
+	WithContext(ctx context.Context) Queue
+	// Stats returns the message counts of all declared queues, sorted by queue name.
+	Stats() ([]QueueStats, error)
+}
+
+// QueueStats is the message counts of a queue
+type QueueStats struct {
+	Name QueueName `json:"name"`
+	// Pending is the number of messages waiting for their delivery time
+	Pending int64 `json:"pending"`
+	// Ready is the number of messages ready to be consumed
+	Ready int64 `json:"ready"`
+	// Processing is the number of messages being consumed and not acknowledged yet
+	Processing int64 `json:"processing"`
 }
 
 // NewQueueCfg is the configuration for creating a new queue
@@ -4,127 +4,209 @@ import (
 	"go.oease.dev/goe/modules/broker"
 )
 
-var goeConfigInstance *GoeConfig
-
-func UseGoeConfig() *GoeConfig {
-	return goeConfigInstance
-}
-
 type GoeConfig struct {
-	App         *AppConfigs
-	Features    *GoeConfigFeatures
-	MongoDB     *GoeConfigMongodb
-	Redis       *GoeConfigRedis
-	Meilisearch *GoeConfigMeilisearch
-	Mailer      *GoeConfigMailer
-	Queue       *GoeConfigQueue
-	Http        *GoeConfigHttp
-	Session     *GoeConfigSession
-	S3          *GoeConfigS3
-	OIDC        *GoeOIDCConfig
-	EMQX        *broker.EMQXConfig
+	App         *AppConfigs           `prefix:""`
+	Features    *GoeConfigFeatures    `prefix:""`
+	MongoDB     *GoeConfigMongodb     `prefix:"MONGODB_"`
+	Redis       *GoeConfigRedis       `prefix:"REDIS_"`
+	Meilisearch *GoeConfigMeilisearch `prefix:"MEILISEARCH_"`
+	Mailer      *GoeConfigMailer      `prefix:""`
+	Queue       *GoeConfigQueue       `prefix:"QUEUE_"`
+	Cache       *GoeConfigCache       `prefix:"CACHE_"`
+	Http        *GoeConfigHttp        `prefix:"HTTP_"`
+	Session     *GoeConfigSession     `prefix:"SESSION_"`
+	S3          *GoeConfigS3          `prefix:"S3_"`
+	OIDC        *GoeOIDCConfig        `prefix:"OIDC_"`
+	EMQX        *broker.EMQXConfig    `prefix:"EMQX_"`
+	Health      *GoeConfigHealth      `prefix:"HEALTH_"`
+	Shutdown    *GoeConfigShutdown    `prefix:"SHUTDOWN_"`
+	Metrics     *GoeConfigMetrics     `prefix:"METRICS_"`
+	Tracing     *GoeConfigTracing     `prefix:"TRACING_"`
+	Log         *GoeConfigLog         `prefix:"LOG_"`
+	Config      *GoeConfigReload      `prefix:"CONFIG_"`
+	I18n        *GoeConfigI18n        `prefix:"I18N_"`
+	View        *GoeConfigView        `prefix:"VIEW_"`
 }
 
 type AppConfigs struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-	Env     string `json:"env"`
+	Name    string `json:"name" env:"APP_NAME" default:"GoeApp"`
+	Version string `json:"version" env:"APP_VERSION" default:"v1.0.0"`
+	Env     string `json:"env" env:"APP_ENV" default:"dev"`
+	RunMode string `json:"run_mode" env:"GOE_RUN_MODE" default:"all"`
 }
 
 type GoeConfigFeatures struct {
-	MongoDBEnabled      bool `json:"mongodb_enabled"`
-	MeilisearchEnabled  bool `json:"meilisearch_enabled"`
-	SearchDBSyncEnabled bool `json:"search_db_sync_enabled"`
-	MailerEnabled       bool `json:"mailer_enabled"`
-	EMQXBrokerEnabled   bool `json:"emqx_enabled"`
+	MongoDBEnabled      bool `json:"mongodb_enabled" env:"MONGODB_ENABLED"`
+	MeilisearchEnabled  bool `json:"meilisearch_enabled" env:"MEILISEARCH_ENABLED"`
+	SearchDBSyncEnabled bool `json:"search_db_sync_enabled" env:"MEILISEARCH_DB_SYNC"`
+	MailerEnabled       bool `json:"mailer_enabled" env:"MAILER_ENABLED"`
+	EMQXBrokerEnabled   bool `json:"emqx_enabled" env:"EMQX_BROKER_ENABLED"`
 }
 
 type GoeConfigMongodb struct {
-	URI string `json:"uri"`
-	DB  string `json:"db"`
+	URI string `json:"uri" env:"URI" secret:"true"`
+	DB  string `json:"db" env:"DB"`
 }
 
 type GoeConfigRedis struct {
-	Host     string `json:"host"`
-	Port     int    `json:"port"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Host     string `json:"host" env:"HOST"`
+	Port     int    `json:"port" env:"PORT"`
+	Username string `json:"username" env:"USERNAME"`
+	Password string `json:"password" env:"PASSWORD" secret:"true"`
 }
 
 type GoeConfigMeilisearch struct {
-	Endpoint string `json:"endpoint"`
-	ApiKey   string `json:"api_key"`
+	Endpoint string `json:"endpoint" env:"ENDPOINT"`
+	ApiKey   string `json:"api_key" env:"API_KEY" secret:"true"`
 }
 
 type GoeConfigMailer struct {
-	Provider  string           `json:"provider"` // "smtp", "resend", "ses"
-	FromEmail string           `json:"from_email"`
-	FromName  string           `json:"from_name"`
-	SMTP      *GoeConfigSMTP   `json:"smtp,omitempty"`
-	Resend    *GoeConfigResend `json:"resend,omitempty"`
-	SES       *GoeConfigSES    `json:"ses,omitempty"`
+	Provider  string           `json:"provider" env:"MAILER_PROVIDER" default:"smtp"` // "smtp", "resend", "ses"
+	FromEmail string           `json:"from_email" env:"MAILER_FROM_EMAIL"`
+	FromName  string           `json:"from_name" env:"MAILER_FROM_NAME"`
+	SMTP      *GoeConfigSMTP   `json:"smtp,omitempty" prefix:"SMTP_"`
+	Resend    *GoeConfigResend `json:"resend,omitempty" prefix:"RESEND_"`
+	SES       *GoeConfigSES    `json:"ses,omitempty" prefix:"SES_"`
 }
 
 type GoeConfigSMTP struct {
-	Host       string `json:"host"`
-	Port       int    `json:"port"`
-	Username   string `json:"username"`
-	Password   string `json:"password"`
-	Tls        bool   `json:"tls"`
-	LocalName  string `json:"local_name"`
-	AuthMethod string `json:"auth_method"`
+	Host       string `json:"host" env:"HOST"`
+	Port       int    `json:"port" env:"PORT"`
+	Username   string `json:"username" env:"USERNAME"`
+	Password   string `json:"password" env:"PASSWORD" secret:"true"`
+	Tls        bool   `json:"tls" env:"TLS"`
+	LocalName  string `json:"local_name" env:"LOCAL_NAME"`
+	AuthMethod string `json:"auth_method" env:"AUTH_METHOD" default:"PLAIN"`
 }
 
 type GoeConfigResend struct {
-	APIKey string `json:"api_key"`
+	APIKey string `json:"api_key" env:"API_KEY" secret:"true"`
 }
 
 type GoeConfigSES struct {
-	Region          string `json:"region"`
-	AccessKeyID     string `json:"access_key_id"`
-	SecretAccessKey string `json:"secret_access_key"`
-	Endpoint        string `json:"endpoint,omitempty"`
+	Region          string `json:"region" env:"REGION"`
+	AccessKeyID     string `json:"access_key_id" env:"ACCESS_KEY_ID"`
+	SecretAccessKey string `json:"secret_access_key" env:"SECRET_ACCESS_KEY" secret:"true"`
+	Endpoint        string `json:"endpoint,omitempty" env:"ENDPOINT"`
 }
 
 type GoeConfigQueue struct {
-	ConcurrentWorkers  int `json:"concurrent_workers"`
-	FetchInterval      int `json:"fetch_interval"`
-	FetchLimit         int `json:"fetch_limit"`
-	MaxConsumeDuration int `json:"max_consume_duration"`
-	DefaultRetries     int `json:"default_retries"`
+	Driver             string `json:"driver" env:"DRIVER" default:"redis"`
+	ConcurrentWorkers  int    `json:"concurrent_workers" env:"CONCURRENCY" default:"1"`
+	FetchInterval      int    `json:"fetch_interval" env:"FETCH_INTERVAL" default:"1"`
+	FetchLimit         int    `json:"fetch_limit" env:"FETCH_LIMIT" default:"0"`
+	MaxConsumeDuration int    `json:"max_consume_duration" env:"MAX_CONSUME_DURATION" default:"5"`
+	DefaultRetries     int    `json:"default_retries" env:"DEFAULT_RETRIES" default:"3"`
+}
+
+type GoeConfigCache struct {
+	Driver string `json:"driver" env:"DRIVER" default:"redis"`
 }
 
 type GoeConfigS3 struct {
-	Endpoint     string `json:"endpoint"`
-	AccessKey    string `json:"access_key"`
-	SecretKey    string `json:"secret_key"`
-	Bucket       string `json:"bucket"`
-	Region       string `json:"region"`
-	BucketLookup string `json:"bucket_lookup"`
-	UseSSL       bool   `json:"use_ssl"`
-	Token        string `json:"token"`
+	Endpoint     string `json:"endpoint" env:"ENDPOINT"`
+	AccessKey    string `json:"access_key" env:"ACCESS_KEY"`
+	SecretKey    string `json:"secret_key" env:"SECRET_KEY" secret:"true"`
+	Bucket       string `json:"bucket" env:"BUCKET_NAME"`
+	Region       string `json:"region" env:"REGION"`
+	BucketLookup string `json:"bucket_lookup" env:"BUCKET_LOOKUP" default:"path"`
+	UseSSL       bool   `json:"use_ssl" env:"USE_SSL"`
+	Token        string `json:"token" env:"TOKEN" secret:"true"`
 }
 
 type GoeOIDCConfig struct {
-	AppId     string   `json:"app_id"`
-	AppSecret string   `json:"app_secret"`
-	AppScopes []string `json:"app_scopes"`
-	Issuer    string   `json:"issuer"`
+	AppId     string   `json:"app_id" env:"APP_ID"`
+	AppSecret string   `json:"app_secret" env:"APP_SECRET" secret:"true"`
+	AppScopes []string `json:"app_scopes" env:"APP_SCOPES"`
+	Issuer    string   `json:"issuer" env:"ISSUER"`
 }
 
 type GoeConfigHttp struct {
-	Port            string   `json:"port"`
-	ServerHeader    string   `json:"server_header"`
-	BodyLimit       int      `json:"body_limit"`
-	Concurrency     int      `json:"concurrency"`
-	ProxyHeader     string   `json:"proxy_header"`
-	TrustProxyCheck bool     `json:"trust_proxy_check"`
-	TrustProxies    []string `json:"trust_proxies"`
-	ReduceMemory    bool     `json:"reduce_memory"`
-	IPValidation    bool     `json:"ip_validation"`
+	Port            string   `json:"port" env:"PORT" default:"3000"`
+	ServerHeader    string   `json:"server_header" env:"SERVER_HEADER" default:"GoeAppServer/v1"`
+	BodyLimit       int      `json:"body_limit" env:"BODY_LIMIT" default:"4194304"`
+	Concurrency     int      `json:"concurrency" env:"CONCURRENCY" default:"262144"`
+	ProxyHeader     string   `json:"proxy_header" env:"PROXY_HEADER"`
+	TrustProxyCheck bool     `json:"trust_proxy_check" env:"TRUSTED_PROXY_CHECK"`
+	TrustProxies    []string `json:"trust_proxies" env:"TRUSTED_PROXIES"`
+	ReduceMemory    bool     `json:"reduce_memory" env:"REDUCE_MEMORY"`
+	IPValidation    bool     `json:"ip_validation" env:"IP_VALIDATION"`
+	ErrorFormat     string   `json:"error_format" env:"ERROR_FORMAT" default:"envelope"` // envelope or problem, the json body of the error responses
+	ErrorTypeBase   string   `json:"error_type_base" env:"ERROR_TYPE_BASE"`              // base URI of the problem types, the type is about:blank without it
+	ErrorHomeLink   string   `json:"error_home_link" env:"ERROR_HOME_LINK" default:"/"`  // home link of the html error pages
+	ErrorBrand      string   `json:"error_brand" env:"ERROR_BRAND"`                      // brand shown on the html error pages
 }
 
 type GoeConfigSession struct {
-	Expiration int    `json:"expiration"`
-	KeyLookup  string `json:"key_lookup"`
+	Expiration int    `json:"expiration" env:"EXPIRATION" default:"86400"`
+	KeyLookup  string `json:"key_lookup" env:"LOOKUP" default:"cookie:goe_session_id"`
+}
+
+type GoeConfigHealth struct {
+	Enabled       bool   `json:"enabled" env:"ENABLED" default:"true"`
+	LivenessPath  string `json:"liveness_path" env:"LIVENESS_PATH" default:"/healthz"`
+	ReadinessPath string `json:"readiness_path" env:"READINESS_PATH" default:"/readyz"`
+	CheckTimeout  int    `json:"check_timeout" env:"CHECK_TIMEOUT" default:"5"` // seconds
+	DrainDelay    int    `json:"drain_delay" env:"DRAIN_DELAY" default:"0"`     // seconds to keep serving after readiness turns false on shutdown
+}
+
+type GoeConfigShutdown struct {
+	Timeout          int      `json:"timeout" env:"TIMEOUT" default:"30"`                     // seconds for the whole shutdown
+	ComponentTimeout int      `json:"component_timeout" env:"COMPONENT_TIMEOUT" default:"10"` // seconds for each shutdown step
+	Signals          []string `json:"signals" env:"SIGNALS"`
+}
+
+type GoeConfigMetrics struct {
+	Enabled   bool   `json:"enabled" env:"ENABLED"`
+	Path      string `json:"path" env:"PATH" default:"/metrics"`
+	Namespace string `json:"namespace" env:"NAMESPACE" default:"goe"`
+}
+
+type GoeConfigTracing struct {
+	Enabled      bool    `json:"enabled" env:"ENABLED"`
+	Exporter     string  `json:"exporter" env:"EXPORTER" default:"otlp"` // otlp or stdout
+	OTLPEndpoint string  `json:"otlp_endpoint" env:"OTLP_ENDPOINT"`      // host:port of the OTLP http receiver
+	OTLPInsecure bool    `json:"otlp_insecure" env:"OTLP_INSECURE"`
+	SampleRatio  float64 `json:"sample_ratio" env:"SAMPLE_RATIO" default:"1"`
+}
+
+type GoeConfigLog struct {
+	Level              string            `json:"level" env:"LEVEL"`                                     // debug, info, warn or error, default is debug in dev and info otherwise
+	Format             string            `json:"format" env:"FORMAT"`                                   // json or console, default is console in dev and json otherwise
+	Outputs            []string          `json:"outputs" env:"OUTPUTS" default:"stderr"`                // stdout, stderr or file
+	FilePath           string            `json:"file_path" env:"FILE_PATH" default:"logs/app.log"`      // path of the file output
+	FileMaxSize        int               `json:"file_max_size" env:"FILE_MAX_SIZE" default:"100"`       // megabytes from which the file is rotated
+	FileMaxAge         int               `json:"file_max_age" env:"FILE_MAX_AGE"`                       // days the rotated files are kept, 0 keeps them
+	FileMaxBackups     int               `json:"file_max_backups" env:"FILE_MAX_BACKUPS"`               // number of rotated files kept, 0 keeps them all
+	Sampling           *bool             `json:"sampling" env:"SAMPLING"`                               // sample the repeated logs, default is false in dev and true otherwise
+	SamplingInitial    int               `json:"sampling_initial" env:"SAMPLING_INITIAL" default:"100"` // logs with the same message written every second before sampling
+	SamplingThereafter int               `json:"sampling_thereafter" env:"SAMPLING_THEREAFTER" default:"100"`
+	Fields             map[string]string `json:"fields" env:"FIELDS"`                 // static fields added to the logs besides app, version and env, such as region:eu,zone:a
+	Redact             bool              `json:"redact" env:"REDACT" default:"true"`  // mask the passwords, tokens, emails and session users in the logs
+	RedactKeys         []string          `json:"redact_keys" env:"REDACT_KEYS"`       // field keys masked besides the defaults of log.DefaultRedactKeys
+	RedactPattern      string            `json:"redact_pattern" env:"REDACT_PATTERN"` // regular expression masked in the messages, patterns are joined with |
+	LevelPath          string            `json:"level_path" env:"LEVEL_PATH" default:"/admin/log/level"`
+	AdminToken         string            `json:"admin_token" env:"ADMIN_TOKEN" secret:"true"` // bearer token of the log level endpoint, which is disabled without it
+}
+
+type GoeConfigReload struct {
+	Watch         bool   `json:"watch" env:"WATCH"`
+	WatchInterval int    `json:"watch_interval" env:"WATCH_INTERVAL" default:"5"` // seconds
+	Source        string `json:"source" env:"SOURCE"`                             // redis or mongodb
+	SourceKey     string `json:"source_key" env:"SOURCE_KEY"`                     // redis hash or mongodb collection, default goe:config or configs
+}
+
+type GoeConfigI18n struct {
+	DefaultLocale string   `json:"default_locale" env:"DEFAULT_LOCALE" default:"en"`
+	Locales       []string `json:"locales" env:"LOCALES"`                          // locales matched for the requests, default is every locale with a catalog
+	Dir           string   `json:"dir" env:"DIR" default:"./locales"`              // json and yaml catalogs named after their locale, such as de.json, skipped if missing
+	QueryParam    string   `json:"query_param" env:"QUERY_PARAM" default:"lang"`   // query parameter choosing the locale of a request
+	SessionKey    string   `json:"session_key" env:"SESSION_KEY" default:"locale"` // session value holding the locale chosen by the user
+}
+
+type GoeConfigView struct {
+	Dir       string `json:"dir" env:"DIR" default:"./views"`           // views overwriting the embedded ones, skipped if missing
+	Extension string `json:"extension" env:"EXTENSION" default:".html"` // extension of the view files
+	Layout    string `json:"layout" env:"LAYOUT"`                       // default layout of the rendered views, such as layouts/main
+	Reload    bool   `json:"reload" env:"RELOAD"`                       // parse the views on each render, for development
 }
@@ -6,4 +6,5 @@ var (
 	RedisDBRateLimiter    = 2
 	RedisDBAuthSession    = 3
 	RedisDBAuthOAuthState = 4
+	RedisDBConfig         = 5
 )
@@ -1,12 +1,15 @@
 package core
 
 import (
-	"github.com/gofiber/fiber/v3"
+	"context"
 	"go.oease.dev/goe/contracts"
-	"go.oease.dev/goe/modules/broker"
-	"go.oease.dev/goe/modules/cache"
-	"go.oease.dev/goe/modules/cron"
-	"go.oease.dev/goe/modules/msearch"
+	"go.oease.dev/goe/modules/health"
+	"go.oease.dev/goe/modules/i18n"
+	"go.oease.dev/goe/modules/metrics"
+	"go.oease.dev/goe/modules/tracing"
+	"io"
+	"sync"
+	"time"
 )
 
 type Container struct {
@@ -21,157 +24,73 @@ type Container struct {
 	cron        contracts.CronJob
 	emqx        contracts.EMQX
 	appConfig   *GoeConfig
-}
-
-var goeContainerInstance *Container
-
-func UseGoeContainer() *Container {
-	return goeContainerInstance
-}
-
+	health      *health.Registry
+	metrics     *metrics.Metrics
+	tracing     *tracing.Tracing
+	i18n        *i18n.Bundle
+
+	modules     map[string]Module
+	registered  []string
+	initOrder   []Module
+	initialized bool
+	started     bool
+	closed      bool
+	runMode     RunMode
+	// shutdownHooks are called after the http server stops, before the modules are drained
+	shutdownHooks []func() error
+	// resources are the values created on demand for the app, such as the session store of the middlewares, see Resource
+	resourcesMu   sync.Mutex
+	resources     map[string]any
+	resourceNames []string
+}
+
+// NewContainer creates a new container, each App owns its own container so that multiple apps can run in one process.
 func NewContainer(config contracts.Config, logger contracts.Logger, appConfig *GoeConfig) *Container {
-	goeConfigInstance = appConfig
-	goeContainerInstance = &Container{
+	checkTimeout := 0
+	if appConfig != nil && appConfig.Health != nil {
+		checkTimeout = appConfig.Health.CheckTimeout
+	}
+	var m *metrics.Metrics
+	if appConfig != nil && appConfig.Metrics != nil && appConfig.Metrics.Enabled {
+		m = metrics.New(appConfig.Metrics.Namespace)
+	}
+	return &Container{
 		config:    config,
 		logger:    logger,
 		appConfig: appConfig,
-	}
-	return goeContainerInstance
-}
-
-func (c *Container) InitMongo() {
-	// Initialize MongoDB
-	mdb, err := NewGoeMongoDB(c.appConfig, c.logger)
-	if err != nil {
-		c.logger.Panic("Failed to initialize MongoDB: ", err)
-		return
-	} else {
-		c.mongo = mdb
+		health:    health.NewRegistry(time.Duration(checkTimeout) * time.Second),
+		metrics:   m,
+		modules:   make(map[string]Module),
 	}
 }
 
-func (c *Container) InitMeilisearch() {
-	if c.appConfig.Features.MeilisearchEnabled {
-		if c.appConfig.Meilisearch.ApiKey == "" {
-			c.logger.Panic("meilisearch api key is required")
-			return
-		}
-		if c.appConfig.Meilisearch.Endpoint == "" {
-			c.logger.Panic("meilisearch endpoint is required")
-			return
-		}
-		ms := msearch.NewMSearch(c.appConfig.Meilisearch.Endpoint, c.appConfig.Meilisearch.ApiKey, c.logger)
-		if ms == nil {
-			c.logger.Panic("Failed to initialize Meilisearch")
-			return
-		}
-		c.meilisearch = ms
-		if c.appConfig.Features.SearchDBSyncEnabled {
-			err := c.mongo.(*GoeMongoDB).SetMeilisearch(ms)
-			if err != nil {
-				c.logger.Panic("Failed to bind Meilisearch to MongoDB: ", err)
-				return
-			}
-		}
-	}
-}
-
-func (c *Container) InitCache() {
-	// Initialize Cache
-	if c.appConfig.Redis.Host != "" && c.appConfig.Redis.Port != 0 {
-		c.cache = cache.NewRedisCache(c.appConfig.Redis.Host, c.appConfig.Redis.Port, c.appConfig.Redis.Username, c.appConfig.Redis.Password, RedisDBCache, c.logger)
-		if c.cache == nil {
-			c.logger.Panic("Failed to initialize Redis Cache")
-		}
-	} else {
-		c.logger.Panic("Failed to initialize Redis Cache: missing required redis configuration")
-		return
-	}
+func (c *Container) GetConfig() contracts.Config {
+	return c.config
 }
 
-func (c *Container) InitQueue() {
-	// Initialize Queue
-	if c.appConfig.Redis.Host != "" && c.appConfig.Redis.Port != 0 {
-		q, err := NewGoeQueue(c.appConfig, c.logger)
-		if err != nil {
-			c.logger.Panic("Failed to initialize Redis MQ: ", err)
-			return
-		} else {
-			c.queue = q
-		}
-	} else {
-		c.logger.Panic("Failed to initialize Redis MQ: missing required redis configuration")
-		return
-	}
+// GetAppConfig returns the typed framework configuration of the app.
+func (c *Container) GetAppConfig() *GoeConfig {
+	return c.appConfig
 }
 
-func (c *Container) InitMailer() {
-	if c.appConfig.Features.MailerEnabled {
-		if c.queue == nil {
-			c.logger.Panic("Queue is required to initialize mailer")
-			return
-		}
-		// Initialize mailer
-		mailer := NewGoeMailer(c.appConfig, c.queue, c.logger)
-		if mailer == nil {
-			c.logger.Panic("Failed to initialize mailer")
-			return
-		}
-		c.mailer = mailer
-	}
+// GetHealth returns the health registry, modules register their checkers to it during Init.
+func (c *Container) GetHealth() *health.Registry {
+	return c.health
 }
 
-func (c *Container) InitFiber() {
-	fb := NewGoeFiber(c.appConfig, c.logger)
-	if fb == nil {
-		c.logger.Panic("Failed to initialize Fiber")
-		return
-	}
-	fb.App().Hooks().OnShutdown(func() error {
-		c.logger.Info("Shutting down the server...")
-		return c.Close()
-	})
-	fb.App().Hooks().OnListen(func(data fiber.ListenData) error {
-		err := c.queue.(*GoeQueue).Start()
-		if err != nil {
-			c.logger.Panic("Failed to start MQ: ", err)
-			return err
-		}
-		if c.cron != nil {
-			c.cron.(*cron.CronJobModule).Start()
-		}
-		c.logger.Infof("Server is running on http://%s:%s", data.Host, data.Port)
-		return err
-	})
-	c.fiber = fb
+// GetTracing returns the tracing of the app, it is nil if the tracing module is disabled.
+func (c *Container) GetTracing() *tracing.Tracing {
+	return c.tracing
 }
 
-func (c *Container) InitCron() {
-	mod, err := cron.NewCronJobService()
-	if err != nil {
-		c.logger.Panic("Failed to initialize cron job service: ", err)
-		return
-	}
-	c.cron = mod
-}
-
-func (c *Container) InitEMQX() {
-	if c.appConfig.Features.EMQXBrokerEnabled {
-		// init emqx config
-		c.appConfig.EMQX.Complete()
-		// init emqx broker
-		emqx, err := broker.NewEMQX(c.appConfig.EMQX)
-		if err != nil {
-			c.logger.Panic("Failed to initialize emqx broker service: ", err)
-			return
-		}
-		c.emqx = emqx
-	}
-
+// GetMetrics returns the prometheus metrics of the app, it is nil if the metrics are disabled.
+func (c *Container) GetMetrics() *metrics.Metrics {
+	return c.metrics
 }
 
-func (c *Container) GetConfig() contracts.Config {
-	return c.config
+// GetI18n returns the message catalogs of the app, it is nil if the i18n module is disabled.
+func (c *Container) GetI18n() *i18n.Bundle {
+	return c.i18n
 }
 
 func (c *Container) GetMongo() contracts.MongoDB {
@@ -210,22 +129,61 @@ func (c *Container) GetEMQX() contracts.EMQX {
 	return c.emqx
 }
 
-// Close closes the container and its dependencies. DON'T NEED TO CALL THIS METHOD MANUALLY, IT WILL BE CALLED AUTOMATICALLY WHEN THE APP SHUTS DOWN.
-func (c *Container) Close() error {
-	if c.mongo != nil {
-		c.mongo.(*GoeMongoDB).mongodbInstance.Close()
-	}
-	if c.queue != nil {
-		c.queue.(*GoeQueue).Close()
+// GetRunMode returns the run mode the container is started in, it is empty before the container starts.
+func (c *Container) GetRunMode() RunMode {
+	return c.runMode
+}
+
+// OnShutdown adds hooks which are called when the container shuts down, after the http server stops and before the modules are drained.
+func (c *Container) OnShutdown(hooks ...func() error) {
+	c.shutdownHooks = append(c.shutdownHooks, hooks...)
+}
+
+// Resource returns the value stored under name, create is called to create it on the first call.
+// The values are owned by the container so that apps running in one process do not share them,
+// the values implementing io.Closer are closed when the container shuts down, after the modules stop.
+func (c *Container) Resource(name string, create func() any) any {
+	c.resourcesMu.Lock()
+	defer c.resourcesMu.Unlock()
+	if v, ok := c.resources[name]; ok {
+		return v
 	}
-	if c.cache != nil {
-		c.cache.(*cache.RedisCache).Close()
+	if c.resources == nil {
+		c.resources = make(map[string]any)
 	}
-	if c.cron != nil {
-		c.cron.(*cron.CronJobModule).Close()
+	v := create()
+	c.resources[name] = v
+	c.resourceNames = append(c.resourceNames, name)
+	return v
+}
+
+// closeResources closes the resources in reverse creation order and removes them from the container.
+func (c *Container) closeResources(ctx context.Context, componentTimeout time.Duration) []error {
+	c.resourcesMu.Lock()
+	resources, names := c.resources, c.resourceNames
+	c.resources, c.resourceNames = nil, nil
+	c.resourcesMu.Unlock()
+	var errs []error
+	for i := len(names) - 1; i >= 0; i-- {
+		closer, ok := resources[names[i]].(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := c.shutdownStep(ctx, componentTimeout, "close "+names[i], func(ctx context.Context) error {
+			return closer.Close()
+		}); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	if c.emqx != nil {
-		c.emqx.Close()
+	return errs
+}
+
+// Close gracefully shuts down the container with the configured component timeout, see Shutdown.
+// DON'T NEED TO CALL THIS METHOD MANUALLY, IT WILL BE CALLED AUTOMATICALLY WHEN THE APP SHUTS DOWN.
+func (c *Container) Close() error {
+	componentTimeout := defaultShutdownComponentTimeout
+	if c.appConfig != nil && c.appConfig.Shutdown != nil && c.appConfig.Shutdown.ComponentTimeout > 0 {
+		componentTimeout = time.Duration(c.appConfig.Shutdown.ComponentTimeout) * time.Second
 	}
-	return nil
+	return c.Shutdown(context.Background(), componentTimeout)
 }
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.oease.dev/goe/modules/log"
+	"testing"
+)
+
+type fakeCloser struct {
+	name    string
+	err     error
+	journal *[]string
+}
+
+func (f *fakeCloser) Close() error {
+	*f.journal = append(*f.journal, "close "+f.name)
+	return f.err
+}
+
+func TestResourceClosedOnShutdown(t *testing.T) {
+	var journal []string
+	c := NewContainer(nil, log.NewNop(), nil)
+	require.NoError(t, c.RegisterModule(&fakeModule{name: "db", journal: &journal}))
+	require.NoError(t, c.InitModules())
+
+	calls := 0
+	create := func() any {
+		calls++
+		return &fakeCloser{name: "session", journal: &journal}
+	}
+	session := c.Resource("session", create)
+	assert.Same(t, session, c.Resource("session", create))
+	assert.Equal(t, 1, calls)
+	c.Resource("limiter", func() any { return &fakeCloser{name: "limiter", err: errors.New("broken pipe"), journal: &journal} })
+	c.Resource("counter", func() any { return 1 })
+
+	// another container does not share the resources
+	other := newTestContainer(t)
+	assert.NotSame(t, session, other.Resource("session", create))
+
+	err := c.Close()
+	assert.ErrorContains(t, err, "shutdown step close limiter: broken pipe")
+	assert.Equal(t, []string{"init db", "stop db", "close limiter", "close session"}, journal)
+	assert.Empty(t, c.resources)
+}
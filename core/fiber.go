@@ -3,28 +3,41 @@ package core
 import (
 	"encoding/xml"
 	"errors"
-	"fmt"
 	"github.com/goccy/go-json"
 	"github.com/gofiber/fiber/v3"
-	"github.com/gookit/goutil/strutil"
-	"github.com/gookit/validate"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.oease.dev/goe/contracts"
+	"go.oease.dev/goe/modules/problem"
+	"go.oease.dev/goe/modules/validation"
+	"go.oease.dev/goe/modules/view"
+	"net/http"
+	"strings"
+)
+
+const (
+	// ErrorFormatEnvelope renders the json errors as {"message": ..., "code": ...}
+	ErrorFormatEnvelope = "envelope"
+	// ErrorFormatProblem renders the json errors as RFC 7807 application/problem+json
+	ErrorFormatProblem = "problem"
 )
 
 type GoeFiber struct {
 	goeConfig *GoeConfig
 	fiberApp  *fiber.App
 	logger    contracts.Logger
+	validator *validation.FiberValidator
+	views     *view.Engine
 }
 
-// NewGoeFiber creates a new GoeFiber instance.
-func NewGoeFiber(goeConfig *GoeConfig, l contracts.Logger) *GoeFiber {
+// NewGoeFiber creates a new GoeFiber instance, the apps render the views of the given engine, see ctx.Render.
+func NewGoeFiber(goeConfig *GoeConfig, l contracts.Logger, views ...*view.Engine) *GoeFiber {
 	gf := &GoeFiber{
 		goeConfig: goeConfig,
 		logger:    l,
+		validator: validation.NewFiberValidator(),
+	}
+	if len(views) > 0 {
+		gf.views = views[0]
 	}
-	gf.initValidator()
 	fiberApp := fiber.New(fiber.Config{
 		ServerHeader:      goeConfig.Http.ServerHeader,
 		StrictRouting:     false,
@@ -50,7 +63,9 @@ func NewGoeFiber(goeConfig *GoeConfig, l contracts.Logger) *GoeFiber {
 		},
 		EnableIPValidation: goeConfig.Http.IPValidation,
 		ColorScheme:        fiber.DefaultColors,
-		StructValidator:    gf.newFiberBindValidator(),
+		StructValidator:    gf.validator,
+		Views:              gf.fiberViews(),
+		ViewsLayout:        gf.goeConfig.View.Layout,
 	})
 	gf.fiberApp = fiberApp
 	return gf
@@ -60,6 +75,19 @@ func (gf *GoeFiber) App() *fiber.App {
 	return gf.fiberApp
 }
 
+// Views returns the view engine of the apps, it is nil if the app has no views.
+func (gf *GoeFiber) Views() *view.Engine {
+	return gf.views
+}
+
+// fiberViews returns the views of the fiber config, a nil engine must not be a non-nil fiber.Views.
+func (gf *GoeFiber) fiberViews() fiber.Views {
+	if gf.views == nil {
+		return nil
+	}
+	return gf.views
+}
+
 func (gf *GoeFiber) CreateFiberApp(appName ...string) *fiber.App {
 	if len(appName) == 0 || appName[0] == "" {
 		appName[0] = gf.goeConfig.App.Name
@@ -91,94 +119,133 @@ func (gf *GoeFiber) CreateFiberApp(appName ...string) *fiber.App {
 		},
 		EnableIPValidation: gf.goeConfig.Http.IPValidation,
 		ColorScheme:        fiber.DefaultColors,
-		StructValidator:    gf.newFiberBindValidator(),
+		StructValidator:    gf.validator,
+		Views:              gf.fiberViews(),
+		ViewsLayout:        gf.goeConfig.View.Layout,
 	})
 }
 
-func (gf *GoeFiber) initValidator() {
-	validate.Config(func(opt *validate.GlobalOption) {
-		opt.ValidateTag = "v"
-		opt.MessageTag = "m"
-		opt.SkipOnEmpty = true
-	})
-	validate.AddValidator("id", func(val interface{}) bool {
-		if val == nil {
-			return false
-		}
-		if s, err := strutil.ToString(val); err != nil {
-			return false
-		} else {
-			if _, err := primitive.ObjectIDFromHex(s); err != nil {
-				return false
-			}
-		}
-		return true
-	})
-	validate.AddGlobalMessages(map[string]string{
-		"id": "{field} is not a valid ID",
-	})
+// ErrorStatus returns the status sent by the error handler of the apps for an error returned by a handler:
+// the status of an application error or of a fiber.Error, 400 for a validation error and 500 for the other errors.
+// The metrics and the traces of the requests record the same status.
+func ErrorStatus(err error) int {
+	var e *fiber.Error
+	var ve *validation.Error
+	if pe, ok := problem.As(err); ok {
+		return pe.Type.Status
+	} else if errors.As(err, &e) {
+		return e.Code
+	} else if errors.As(err, &ve) {
+		return fiber.StatusBadRequest
+	}
+	return fiber.StatusInternalServerError
 }
 
 func (gf *GoeFiber) GoeFiberErrorHandler(ctx fiber.Ctx, err error) error {
-	// Status code defaults to 500
-	respCode := fiber.StatusInternalServerError
-	// Set error message
-	message := err.Error()
-	// Check if it's a fiber.Error type
+	respCode := ErrorStatus(err)
+	// The other errors may hold the messages of the drivers, they are logged and the client gets the message of problem.Internal
+	message := T(ctx, problem.Internal.Message)
+	// The messages of all the failed rules, separated by lines in text and by semicolons in html
+	details := []string{message}
+	// The stable code of the error, see problem.Register
+	code := problem.Internal.Code
+	// The details of an application error, and the failed rules by field of a validation error
+	var extra map[string]any
+	var fieldErrors map[string][]string
+	// Check if it's an application error or a fiber.Error type
 	var e *fiber.Error
-	if errors.As(err, &e) {
-		respCode = e.Code
-		message = e.Message
+	var ve *validation.Error
+	if pe, ok := problem.As(err); ok {
+		code = pe.Type.Code
+		message = T(ctx, pe.Type.Message, pe.Args()...)
+		details = []string{message}
+		extra = pe.Details
+	} else if errors.As(err, &e) {
+		// the messages of the framework and the catalog keys are translated to the locale of the request
+		message = T(ctx, e.Message)
+		details = []string{message}
+		code = ""
+		if t := problem.ForStatus(e.Code); t != nil {
+			code = t.Code
+		}
+	} else if errors.As(err, &ve) {
+		code = problem.ValidationFailed.Code
+		if bundle := I18nFrom(ctx); bundle != nil {
+			locale := Locale(ctx)
+			ve = ve.Localize(bundle.Messages(locale, "validation."), bundle.Messages(locale, "fields."))
+		}
+		message = ve.Error()
+		details = make([]string, len(ve.Fields))
+		for i, f := range ve.Fields {
+			details[i] = f.Message
+		}
+		fieldErrors = ve.Messages()
+	} else {
+		logger := LoggerFrom(ctx)
+		if logger == nil {
+			logger = gf.logger
+		}
+		if logger != nil {
+			logger.Errorf("%s %s failed: %v", ctx.Method(), ctx.Path(), err)
+		}
 	}
 	ctx.Status(respCode)
 
+	// sendJSON sends the problem details if they are configured or accepted, else the envelope
+	sendJSON := func(asProblem bool) error {
+		if asProblem || gf.goeConfig.Http.ErrorFormat == ErrorFormatProblem {
+			return ctx.JSON(&problem.Problem{
+				Type:     problem.TypeURI(gf.goeConfig.Http.ErrorTypeBase, code),
+				Title:    http.StatusText(respCode),
+				Status:   respCode,
+				Detail:   message,
+				Instance: ctx.Path(),
+				Code:     code,
+				Details:  extra,
+				Errors:   fieldErrors,
+			}, problem.MIMEApplicationProblemJSON)
+		}
+		body := fiber.Map{"message": message}
+		if code != "" {
+			body["code"] = code
+		}
+		if len(extra) > 0 {
+			body["details"] = extra
+		}
+		if fieldErrors != nil {
+			body["errors"] = fieldErrors
+		}
+		return ctx.JSON(body)
+	}
+
 	// If the format is forced to json or text through query parameter, then return the response in that format
 	if ctx.Query("format") == "json" {
-		return ctx.JSON(fiber.Map{
-			"message": message,
-		})
+		return sendJSON(false)
 	}
 
 	// If the format is forced to text through query parameter, then return the response in that format
 	if ctx.Query("format") == "text" {
 		ctx.Response().Header.SetContentType(fiber.MIMETextPlain)
-		return ctx.SendString(message)
+		return ctx.SendString(strings.Join(details, "\n"))
 	}
 
 	if ctx.Accepts(fiber.MIMETextHTML) == fiber.MIMETextHTML {
 		// default response, html error page
 		ctx.Response().Header.SetContentType(fiber.MIMETextHTML)
-		return ctx.SendString(ErrorPage(fmt.Sprintf("ERROR %d", respCode), fmt.Sprintf("%d", respCode), message, "/"))
+		return ctx.SendString(gf.errorPage(ctx, respCode, code, strings.Join(details, "; "), details))
 	}
 
-	// If the format is not forced, then check the accept header
-	if ctx.Accepts(fiber.MIMEApplicationJSON) == fiber.MIMEApplicationJSON {
-		return ctx.JSON(fiber.Map{
-			"message": message,
-		})
+	// If the format is not forced, then check the accept header, the problem details are sent to the clients asking for them
+	if accepted := ctx.Accepts(fiber.MIMEApplicationJSON, problem.MIMEApplicationProblemJSON); accepted != "" {
+		return sendJSON(accepted == problem.MIMEApplicationProblemJSON)
 	}
 
 	if ctx.Accepts(fiber.MIMETextPlain) == fiber.MIMETextPlain {
 		ctx.Response().Header.SetContentType(fiber.MIMETextPlain)
-		return ctx.SendString(message)
+		return ctx.SendString(strings.Join(details, "\n"))
 	}
 
 	// default response, html error page
 	ctx.Response().Header.SetContentType(fiber.MIMETextHTML)
-	return ctx.SendString(ErrorPage(fmt.Sprintf("ERROR %d", respCode), fmt.Sprintf("%d", respCode), message, "/"))
-}
-
-type fiberBindValidator struct {
-}
-
-func (gf *GoeFiber) newFiberBindValidator() *fiberBindValidator {
-	return &fiberBindValidator{}
-}
-
-func (f *fiberBindValidator) Validate(out any) error {
-	v := validate.Struct(out)
-	if !v.Validate() {
-		return fiber.NewError(fiber.StatusBadRequest, v.Errors.One())
-	}
-	return nil
+	return ctx.SendString(gf.errorPage(ctx, respCode, code, strings.Join(details, "; "), details))
 }
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.oease.dev/goe/modules/config"
+	"go.oease.dev/goe/modules/log"
+	"go.oease.dev/goe/modules/problem"
+	"go.oease.dev/goe/modules/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type signupRequest struct {
+	Name string `json:"name" v:"required"`
+}
+
+// newObservedApp creates a fiber app recording its requests in the metrics and the spans of the container, the logs are written to the returned buffer.
+func newObservedApp(t *testing.T) (*fiber.App, *Container, *tracetest.InMemoryExporter, *bytes.Buffer) {
+	t.Helper()
+	appConfig := &GoeConfig{}
+	require.NoError(t, config.Bind(config.NewFromMap(map[string]string{"APP_NAME": "test", "METRICS_ENABLED": "true"}), appConfig))
+	var out bytes.Buffer
+	logger, err := log.NewWithConfig(log.Config{Format: log.FormatJSON, Writers: []io.Writer{&out}})
+	require.NoError(t, err)
+	c := NewContainer(config.NewFromMap(nil), logger, appConfig)
+	exporter := tracetest.NewInMemoryExporter()
+	c.tracing = tracing.NewWithExporter(tracing.Config{ServiceName: "test"}, exporter)
+
+	app := NewGoeFiber(appConfig, logger).CreateFiberApp("test")
+	registerTracingMiddleware(c, app)
+	registerMetricsRoutes(c, app)
+	app.Post("/signup", func(ctx fiber.Ctx) error {
+		req := &signupRequest{}
+		if err := ctx.Bind().JSON(req); err != nil {
+			return err
+		}
+		return ctx.App().Config().StructValidator.Validate(req)
+	})
+	app.Get("/items/:id", func(ctx fiber.Ctx) error {
+		return problem.NotFound.New()
+	})
+	app.Get("/orders", func(ctx fiber.Ctx) error {
+		return errors.New("mongo: connection refused by 10.0.0.7:27017")
+	})
+	return app, c, exporter, &out
+}
+
+func TestErrorStatus(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, ErrorStatus(problem.NotFound.New()))
+	assert.Equal(t, http.StatusNotFound, ErrorStatus(fmt.Errorf("find item: %w", problem.NotFound)))
+	assert.Equal(t, http.StatusTooManyRequests, ErrorStatus(fiber.ErrTooManyRequests))
+	assert.Equal(t, http.StatusInternalServerError, ErrorStatus(errors.New("connection refused")))
+}
+
+func TestErrorStatusOfMetricsAndTraces(t *testing.T) {
+	app, c, exporter, _ := newObservedApp(t)
+	for path, status := range map[string]int{"/signup": http.StatusBadRequest, "/items/1": http.StatusNotFound, "/orders": http.StatusInternalServerError} {
+		method := http.MethodGet
+		if path == "/signup" {
+			method = http.MethodPost
+		}
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(`{}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, status, resp.StatusCode, path)
+	}
+
+	rec := httptest.NewRecorder()
+	c.metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	// a failed Validate is recorded with the status sent by the error handler
+	assert.Contains(t, body, `goe_http_requests_total{method="POST",route="/signup",status="400"} 1`)
+	assert.Contains(t, body, `goe_http_requests_total{method="GET",route="/items/:id",status="404"} 1`)
+	assert.Contains(t, body, `goe_http_requests_total{method="GET",route="/orders",status="500"} 1`)
+
+	require.NoError(t, c.tracing.Provider().ForceFlush(context.Background()))
+	statuses := make(map[string]codes.Code)
+	for _, span := range exporter.GetSpans() {
+		statuses[span.Name] = span.Status.Code
+	}
+	// only the server errors mark the spans as failed
+	assert.Equal(t, map[string]codes.Code{
+		"POST /signup":   codes.Unset,
+		"GET /items/:id": codes.Unset,
+		"GET /orders":    codes.Error,
+	}, statuses)
+}
+
+func TestInternalErrorIsNotSent(t *testing.T) {
+	app, _, _, out := newObservedApp(t)
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(fiber.HeaderAccept, problem.MIMEApplicationProblemJSON)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "10.0.0.7")
+	assert.Contains(t, string(body), `"detail":"system busy"`)
+	assert.Contains(t, string(body), `"code":"internal_error"`)
+	// the error is logged instead
+	assert.Contains(t, out.String(), "GET /orders failed: mongo: connection refused by 10.0.0.7:27017")
+}
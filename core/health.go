@@ -0,0 +1,32 @@
+package core
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"go.oease.dev/goe/modules/health"
+)
+
+// registerHealthRoutes serves the liveness and readiness endpoints of the container health registry on the fiber app.
+func registerHealthRoutes(c *Container, app *fiber.App) {
+	cfg := c.appConfig.Health
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
+	if cfg.LivenessPath != "" {
+		app.Get(cfg.LivenessPath, func(ctx fiber.Ctx) error {
+			return sendHealthReport(ctx, c.health.Liveness())
+		})
+	}
+	if cfg.ReadinessPath != "" {
+		app.Get(cfg.ReadinessPath, func(ctx fiber.Ctx) error {
+			return sendHealthReport(ctx, c.health.Readiness(ctx.Context()))
+		})
+	}
+}
+
+func sendHealthReport(ctx fiber.Ctx, report *health.Report) error {
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	if report.Status != health.StatusUp {
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(report)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(report)
+}
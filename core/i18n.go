@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/session"
+	"go.oease.dev/goe/modules/i18n"
+	"io/fs"
+	"os"
+)
+
+// NewI18nModule creates the built-in i18n module, it loads the catalogs of the framework, the given catalogs, such as an embed.FS of the app, then the catalogs of I18N_DIR.
+// The catalogs are the json and yaml files at the root of each fs.FS, use fs.Sub for a directory of an embed.FS.
+func NewI18nModule(catalogs ...fs.FS) Module {
+	return &i18nModule{catalogs: catalogs}
+}
+
+type i18nModule struct {
+	catalogs []fs.FS
+}
+
+func (m *i18nModule) Name() string {
+	return ModuleI18n
+}
+
+func (m *i18nModule) DependsOn() []string {
+	return nil
+}
+
+func (m *i18nModule) Init(c *Container) error {
+	cfg := c.appConfig.I18n
+	bundle := i18n.New(i18n.Config{
+		DefaultLocale: cfg.DefaultLocale,
+		Locales:       cfg.Locales,
+	})
+	for _, fsys := range m.catalogs {
+		if err := bundle.LoadFS(fsys, "."); err != nil {
+			return err
+		}
+	}
+	// the catalogs of the directory can overwrite the embedded ones when the app is deployed, the directory is optional
+	if cfg.Dir != "" {
+		if _, err := os.Stat(cfg.Dir); err == nil {
+			if err := bundle.LoadDir(cfg.Dir); err != nil {
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	c.i18n = bundle
+	return nil
+}
+
+func (m *i18nModule) Start() error {
+	return nil
+}
+
+func (m *i18nModule) Stop() error {
+	return nil
+}
+
+type i18nContextKey struct{}
+
+// requestI18n is carried by the context of the requests, so that the handlers and the error handler translate with the catalogs of the app serving them.
+type requestI18n struct {
+	bundle     *i18n.Bundle
+	queryParam string
+	sessionKey string
+}
+
+// registerI18nMiddleware sets the catalogs of the app in the context of every request, see Locale and T.
+func registerI18nMiddleware(c *Container, app *fiber.App) {
+	if c.i18n == nil {
+		return
+	}
+	ri := &requestI18n{
+		bundle:     c.i18n,
+		queryParam: c.appConfig.I18n.QueryParam,
+		sessionKey: c.appConfig.I18n.SessionKey,
+	}
+	app.Use(func(ctx fiber.Ctx) error {
+		ctx.SetContext(context.WithValue(ctx.Context(), i18nContextKey{}, ri))
+		return ctx.Next()
+	})
+}
+
+func requestI18nFrom(ctx fiber.Ctx) *requestI18n {
+	ri, _ := ctx.Context().Value(i18nContextKey{}).(*requestI18n)
+	return ri
+}
+
+// I18nFrom returns the catalogs of the app serving the request, or nil if the i18n module is disabled.
+func I18nFrom(ctx fiber.Ctx) *i18n.Bundle {
+	if ri := requestI18nFrom(ctx); ri != nil {
+		return ri.bundle
+	}
+	return nil
+}
+
+// Locale returns the locale of the request, the first supported one of the I18N_QUERY_PARAM query parameter, of the I18N_SESSION_KEY value of the session,
+// and of the Accept-Language header, or the default locale. It is resolved on each call, so that a locale saved in the session applies at once.
+// It is empty if the i18n module is disabled.
+func Locale(ctx fiber.Ctx) string {
+	ri := requestI18nFrom(ctx)
+	if ri == nil {
+		return ""
+	}
+	preferred := make([]string, 0, 4)
+	if ri.queryParam != "" {
+		preferred = append(preferred, ctx.Query(ri.queryParam))
+	}
+	if ri.sessionKey != "" {
+		// the session is only read if the session middleware is registered
+		if s := session.FromContext(ctx); s != nil && s.Session != nil {
+			if locale, ok := s.Get(ri.sessionKey).(string); ok {
+				preferred = append(preferred, locale)
+			}
+		}
+	}
+	preferred = append(preferred, i18n.ParseAcceptLanguage(ctx.Get(fiber.HeaderAcceptLanguage))...)
+	return ri.bundle.Match(preferred...)
+}
+
+// T translates the key to the locale of the request, see i18n.Bundle.T. The key itself is the message if the i18n module is disabled.
+func T(ctx fiber.Ctx, key string, args ...any) string {
+	ri := requestI18nFrom(ctx)
+	if ri == nil {
+		return i18n.Format(key, args...)
+	}
+	return ri.bundle.T(Locale(ctx), key, args...)
+}
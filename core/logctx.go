@@ -0,0 +1,135 @@
+package core
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/utils/v2"
+	"github.com/rs/xid"
+	"go.oease.dev/goe/contracts"
+	"go.oease.dev/goe/modules/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// RequestIDHeader is the header of the request id, a valid id of the caller is kept, otherwise one is generated
+	RequestIDHeader = "X-Request-ID"
+	// requestIDCarrierKey is the key of the request id in the context carried by the queue payloads
+	requestIDCarrierKey = "x-request-id"
+	maxRequestIDLength  = 128
+)
+
+type loggerContextKey struct{}
+
+type requestIDContextKey struct{}
+
+// ContextWithLogger returns a context carrying the logger, it is returned by LoggerFrom.
+func ContextWithLogger(ctx context.Context, logger contracts.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// LoggerFrom returns the logger carried by a fiber.Ctx or a context.Context, or nil if there is none.
+func LoggerFrom(ctx any) contracts.Logger {
+	c := contextOf(ctx)
+	if c == nil {
+		return nil
+	}
+	logger, _ := c.Value(loggerContextKey{}).(contracts.Logger)
+	return logger
+}
+
+// ContextWithRequestID returns a context carrying the request id, it is pushed with the queue messages.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext returns the request id carried by a fiber.Ctx or a context.Context, or an empty string.
+func RequestIDFromContext(ctx any) string {
+	c := contextOf(ctx)
+	if c == nil {
+		return ""
+	}
+	id, _ := c.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+func contextOf(ctx any) context.Context {
+	switch c := ctx.(type) {
+	case fiber.Ctx:
+		return c.Context()
+	case context.Context:
+		return c
+	}
+	return nil
+}
+
+// WithLogFields returns a child of the logger adding the key-value pairs to its logs.
+// The logger is returned as is if it cannot create child loggers.
+func WithLogFields(logger contracts.Logger, keysAndValues ...any) contracts.Logger {
+	switch l := logger.(type) {
+	case *log.Log:
+		return l.With(keysAndValues...)
+	case interface {
+		With(keysAndValues ...any) contracts.Logger
+	}:
+		return l.With(keysAndValues...)
+	}
+	return logger
+}
+
+// NamedLogger returns the named logger of a module, whose level can be changed at runtime.
+// The logger is returned as is if it has no named loggers.
+func NamedLogger(logger contracts.Logger, name string) contracts.Logger {
+	switch l := logger.(type) {
+	case *log.Log:
+		return l.Named(name)
+	case interface {
+		Named(name string) contracts.Logger
+	}:
+		return l.Named(name)
+	}
+	return logger
+}
+
+// AddLogFields adds the key-value pairs to the logger of the request, such as the id of the user.
+func AddLogFields(ctx fiber.Ctx, keysAndValues ...any) {
+	logger := LoggerFrom(ctx)
+	if logger == nil {
+		return
+	}
+	ctx.SetContext(ContextWithLogger(ctx.Context(), WithLogFields(logger, keysAndValues...)))
+}
+
+// registerRequestContextMiddleware sets the request id of every request, and a logger adding the request id, method, path and trace id to its logs.
+// It is registered after the tracing middleware, so that the trace id of the request is known.
+func registerRequestContextMiddleware(c *Container, app *fiber.App) {
+	app.Use(func(ctx fiber.Ctx) error {
+		id := ctx.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = xid.New().String()
+		} else {
+			id = utils.CopyString(id)
+		}
+		ctx.Set(RequestIDHeader, id)
+
+		fields := []any{"request_id", id, "method", utils.CopyString(ctx.Method()), "path", utils.CopyString(ctx.Path())}
+		if sc := trace.SpanContextFromContext(ctx.Context()); sc.IsValid() {
+			fields = append(fields, "trace_id", sc.TraceID().String())
+		}
+		reqCtx := ContextWithRequestID(ctx.Context(), id)
+		ctx.SetContext(ContextWithLogger(reqCtx, WithLogFields(c.logger, fields...)))
+		return ctx.Next()
+	})
+}
+
+// validRequestID reports whether the request id of the caller can be logged as is, it is limited to 128 printable ascii characters.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
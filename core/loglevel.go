@@ -0,0 +1,74 @@
+package core
+
+import (
+	"crypto/subtle"
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v3"
+	"go.oease.dev/goe/modules/log"
+	"strings"
+	"time"
+)
+
+// levelController is implemented by the loggers whose levels can be read and changed per named logger at runtime, such as the built-in zap logger.
+type levelController interface {
+	Levels() []log.LoggerLevel
+	SetLevelFor(name, level string, revertAfter time.Duration) error
+}
+
+// LogLevelRequest changes the level of a logger, see NewLogLevelHandler.
+type LogLevelRequest struct {
+	// Name is the named logger, such as queue or mongodb, empty for the root logger
+	Name string `json:"name"`
+	// Level is debug, info, warn or error, empty makes a named logger follow the root level again
+	Level string `json:"level"`
+	// RevertAfter is the duration after which the previous level is restored, such as 10m, empty keeps the level
+	RevertAfter string `json:"revert_after,omitempty"`
+}
+
+// NewLogLevelHandler creates a handler returning the levels of the loggers on GET, and changing the level of a logger on PUT or POST with a LogLevelRequest json body.
+// It has no authorization of its own, mount it behind the admin authorization of the app. The handler served at LOG_LEVEL_PATH requires the bearer token of LOG_ADMIN_TOKEN.
+func NewLogLevelHandler(c *Container) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		controller, ok := c.logger.(levelController)
+		if !ok {
+			return fiber.NewError(fiber.StatusNotImplemented, "the logger does not support runtime levels")
+		}
+		if ctx.Method() == fiber.MethodGet {
+			return ctx.JSON(controller.Levels())
+		}
+		var req LogLevelRequest
+		if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid log level request")
+		}
+		var revertAfter time.Duration
+		if req.RevertAfter != "" {
+			d, err := time.ParseDuration(req.RevertAfter)
+			if err != nil || d < 0 {
+				return fiber.NewError(fiber.StatusBadRequest, "invalid revert_after duration")
+			}
+			revertAfter = d
+		}
+		if err := controller.SetLevelFor(req.Name, req.Level, revertAfter); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		c.logger.Infow("Log level changed", "name", req.Name, "level", req.Level, "revert_after", req.RevertAfter, "ip", ctx.IP())
+		return ctx.JSON(controller.Levels())
+	}
+}
+
+// registerLogLevelRoutes serves NewLogLevelHandler at LOG_LEVEL_PATH to the callers with the bearer token of LOG_ADMIN_TOKEN, it is disabled without a token.
+func registerLogLevelRoutes(c *Container, app *fiber.App) {
+	cfg := c.appConfig.Log
+	if cfg.AdminToken == "" || cfg.LevelPath == "" {
+		return
+	}
+	token := []byte(cfg.AdminToken)
+	authorize := func(ctx fiber.Ctx) error {
+		bearer, found := strings.CutPrefix(ctx.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(bearer), token) != 1 {
+			return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+		}
+		return ctx.Next()
+	}
+	app.Add([]string{fiber.MethodGet, fiber.MethodPut, fiber.MethodPost}, cfg.LevelPath, authorize, NewLogLevelHandler(c))
+}
@@ -140,7 +140,7 @@ func NewGoeMailer(appConfig *GoeConfig, queueInstance contracts.Queue, logger co
 		}
 
 		// Set up the queue consumer
-		queueInstance.NewQueue(mailModule.EmailDeliveryQueueName, manager.ProcessQueuedMessage)
+		queueInstance.NewQueueContext(mailModule.EmailDeliveryQueueName, manager.ProcessQueuedMessageContext)
 
 		return &GoeMailer{
 			manager: manager,
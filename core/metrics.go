@@ -0,0 +1,39 @@
+package core
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
+	"github.com/gofiber/utils/v2"
+	"time"
+)
+
+// registerMetricsRoutes records the requests of every route and serves the prometheus metrics of the container on the fiber app.
+func registerMetricsRoutes(c *Container, app *fiber.App) {
+	if c.metrics == nil {
+		return
+	}
+	m := c.metrics
+	app.Use(func(ctx fiber.Ctx) error {
+		start := time.Now()
+		// the route of the middleware itself, it is kept by the context when no route matches the request
+		own := ctx.Route()
+		err := ctx.Next()
+		route := ctx.Route()
+		routePath := route.Path
+		if route == own {
+			// unmatched paths are not used as labels, so that scanners cannot blow up the cardinality
+			routePath = "unmatched"
+		}
+		status := ctx.Response().StatusCode()
+		if err != nil {
+			// the error handler has not written the response yet, use the status it will send
+			status = ErrorStatus(err)
+		}
+		// the method is copied, the label outlives the request buffer it points to
+		m.ObserveHTTPRequest(utils.CopyString(ctx.Method()), routePath, status, time.Since(start))
+		return err
+	})
+	if c.appConfig.Metrics.Path != "" {
+		app.Get(c.appConfig.Metrics.Path, adaptor.HTTPHandler(m.Handler()))
+	}
+}
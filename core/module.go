@@ -0,0 +1,195 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Names of the built-in modules, can be used in DependsOn of application modules.
+const (
+	ModuleMongoDB     = "mongodb"
+	ModuleMeilisearch = "meilisearch"
+	ModuleQueue       = "queue"
+	ModuleCron        = "cron"
+	ModuleCache       = "cache"
+	ModuleMailer      = "mailer"
+	ModuleFiber       = "fiber"
+	ModuleEMQX        = "emqx"
+	ModuleTracing     = "tracing"
+	ModuleConfig      = "config"
+	ModuleI18n        = "i18n"
+)
+
+// Names of the named loggers of the built-in modules, their levels can be changed at runtime, see NewLogLevelHandler.
+const (
+	LoggerQueue   = "queue"
+	LoggerMongoDB = "mongodb"
+	LoggerMSearch = "msearch"
+	LoggerMail    = "mail"
+	LoggerEMQX    = "emqx"
+	LoggerCache   = "cache"
+)
+
+// Module is a subsystem whose lifecycle is managed by the Container.
+// Modules are initialized and started in dependency order, and stopped in reverse order.
+type Module interface {
+	// Name returns the unique name of the module.
+	Name() string
+	// DependsOn returns the names of the modules that must be initialized before this module.
+	DependsOn() []string
+	// Init initializes the module, modules it depends on can be accessed through the container.
+	Init(c *Container) error
+	// Start starts the background work of the module, it is called when the app starts running in a run mode the module is active in.
+	Start() error
+	// Stop stops the module and releases its resources, it is called when the app shuts down.
+	Stop() error
+}
+
+// RegisterModule registers a module to the container.
+// If the container modules are already initialized, the module is initialized immediately, and started if the container is running.
+// The module is only registered if it is initialized and started, so that a failed registration can be retried.
+func (c *Container) RegisterModule(m Module) error {
+	if m == nil {
+		return errors.New("module is nil")
+	}
+	name := m.Name()
+	if name == "" {
+		return errors.New("module name is required")
+	}
+	if _, ok := c.modules[name]; ok {
+		return fmt.Errorf("module %s is already registered", name)
+	}
+	if !c.initialized {
+		c.modules[name] = m
+		c.registered = append(c.registered, name)
+		return nil
+	}
+	for _, dep := range m.DependsOn() {
+		if _, ok := c.modules[dep]; !ok {
+			return fmt.Errorf("module %s depends on unregistered module %s", name, dep)
+		}
+	}
+	if err := m.Init(c); err != nil {
+		return fmt.Errorf("failed to initialize module %s: %w", name, err)
+	}
+	if c.started && isActiveIn(m, c.runMode) {
+		if err := m.Start(); err != nil {
+			// the module is stopped here and not registered, so that the shutdown does not stop it again
+			err = fmt.Errorf("failed to start module %s: %w", name, err)
+			return errors.Join(err, c.stopModules([]Module{m}))
+		}
+	}
+	c.modules[name] = m
+	c.registered = append(c.registered, name)
+	c.initOrder = append(c.initOrder, m)
+	return nil
+}
+
+// GetModule returns the registered module with the given name, or nil if not found.
+func (c *Container) GetModule(name string) Module {
+	return c.modules[name]
+}
+
+// InitModules initializes all registered modules in dependency order.
+// If a module fails, the modules already initialized are stopped in reverse order before the error is returned.
+func (c *Container) InitModules() error {
+	if c.initialized {
+		return errors.New("modules are already initialized")
+	}
+	order, err := c.resolveModuleOrder()
+	if err != nil {
+		return err
+	}
+	for _, m := range order {
+		if err := m.Init(c); err != nil {
+			// the modules already initialized hold clients, such as the MongoDB and Redis connections, they are released before failing
+			err = fmt.Errorf("failed to initialize module %s: %w", m.Name(), err)
+			err = errors.Join(err, c.stopModules(c.initOrder))
+			c.initOrder = nil
+			c.closed = true
+			return err
+		}
+		c.initOrder = append(c.initOrder, m)
+	}
+	c.initialized = true
+	return nil
+}
+
+// Start starts all initialized modules in dependency order.
+func (c *Container) Start() error {
+	return c.StartMode(RunModeAll)
+}
+
+// StartMode starts the initialized modules active in the given run mode in dependency order, see ModeAware.
+// If a module fails, all the initialized modules are stopped in reverse order before the error is returned, the container cannot be started again.
+func (c *Container) StartMode(mode RunMode) error {
+	if c.started {
+		return errors.New("modules are already started")
+	}
+	c.runMode = mode
+	for _, m := range c.initOrder {
+		if !isActiveIn(m, mode) {
+			continue
+		}
+		if err := m.Start(); err != nil {
+			// every initialized module is stopped, as in a shutdown, so that the process does not stay half started
+			err = fmt.Errorf("failed to start module %s: %w", m.Name(), err)
+			err = errors.Join(err, c.stopModules(c.initOrder))
+			c.closed = true
+			return err
+		}
+	}
+	c.started = true
+	return nil
+}
+
+// stopModules stops the modules in reverse order, it rolls back a failed initialization or start.
+func (c *Container) stopModules(modules []Module) error {
+	var errs []error
+	for i := len(modules) - 1; i >= 0; i-- {
+		if err := modules[i].Stop(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop module %s: %w", modules[i].Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveModuleOrder sorts the registered modules topologically, modules without dependency relations keep the registration order.
+func (c *Container) resolveModuleOrder() ([]Module, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(c.registered))
+	order := make([]Module, 0, len(c.registered))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("circular module dependency: %s", strings.Join(append(path, name), " -> "))
+		}
+		m := c.modules[name]
+		state[name] = visiting
+		for _, dep := range m.DependsOn() {
+			if _, ok := c.modules[dep]; !ok {
+				return fmt.Errorf("module %s depends on unregistered module %s", name, dep)
+			}
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		order = append(order, m)
+		return nil
+	}
+	for _, name := range c.registered {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.oease.dev/goe/modules/log"
+	"testing"
+)
+
+// fakeModule records its lifecycle calls in a shared journal.
+type fakeModule struct {
+	name     string
+	deps     []string
+	modes    []RunMode
+	initErr  error
+	startErr error
+	journal  *[]string
+}
+
+func (m *fakeModule) Name() string        { return m.name }
+func (m *fakeModule) DependsOn() []string { return m.deps }
+
+func (m *fakeModule) Init(c *Container) error {
+	*m.journal = append(*m.journal, "init "+m.name)
+	return m.initErr
+}
+
+func (m *fakeModule) Start() error {
+	*m.journal = append(*m.journal, "start "+m.name)
+	return m.startErr
+}
+
+func (m *fakeModule) Stop() error {
+	*m.journal = append(*m.journal, "stop "+m.name)
+	return nil
+}
+
+type modeModule struct {
+	*fakeModule
+}
+
+func (m *modeModule) ActiveIn(mode RunMode) bool {
+	for _, active := range m.modes {
+		if mode.Includes(active) {
+			return true
+		}
+	}
+	return false
+}
+
+func newTestContainer(t *testing.T, modules ...Module) *Container {
+	t.Helper()
+	c := NewContainer(nil, nil, nil)
+	for _, m := range modules {
+		require.NoError(t, c.RegisterModule(m))
+	}
+	return c
+}
+
+func TestResolveModuleOrder(t *testing.T) {
+	var journal []string
+	c := newTestContainer(t,
+		&fakeModule{name: "search", deps: []string{"db"}, journal: &journal},
+		&fakeModule{name: "cron", journal: &journal},
+		&fakeModule{name: "db", journal: &journal},
+		&fakeModule{name: "mailer", deps: []string{"queue"}, journal: &journal},
+		&fakeModule{name: "queue", journal: &journal},
+	)
+	order, err := c.resolveModuleOrder()
+	require.NoError(t, err)
+	names := make([]string, len(order))
+	for i, m := range order {
+		names[i] = m.Name()
+	}
+	// the dependencies first, the others keep the registration order
+	assert.Equal(t, []string{"db", "search", "cron", "queue", "mailer"}, names)
+}
+
+func TestResolveModuleOrderErrors(t *testing.T) {
+	var journal []string
+	c := newTestContainer(t, &fakeModule{name: "search", deps: []string{"db"}, journal: &journal})
+	_, err := c.resolveModuleOrder()
+	assert.EqualError(t, err, "module search depends on unregistered module db")
+
+	c = newTestContainer(t,
+		&fakeModule{name: "a", deps: []string{"b"}, journal: &journal},
+		&fakeModule{name: "b", deps: []string{"c"}, journal: &journal},
+		&fakeModule{name: "c", deps: []string{"a"}, journal: &journal},
+	)
+	_, err = c.resolveModuleOrder()
+	assert.EqualError(t, err, "circular module dependency: a -> b -> c -> a")
+	assert.Error(t, c.InitModules())
+	assert.Empty(t, journal)
+}
+
+func TestStartModeFiltersInactiveModules(t *testing.T) {
+	var journal []string
+	c := newTestContainer(t,
+		&fakeModule{name: "db", journal: &journal},
+		&modeModule{&fakeModule{name: "fiber", modes: []RunMode{RunModeWeb}, journal: &journal}},
+		&modeModule{&fakeModule{name: "queue", modes: []RunMode{RunModeWorker}, journal: &journal}},
+	)
+	require.NoError(t, c.InitModules())
+	require.NoError(t, c.StartMode(RunModeWorker))
+	// inactive modules are initialized, so that they can be used as clients, but not started
+	assert.Equal(t, []string{"init db", "init fiber", "init queue", "start db", "start queue"}, journal)
+	assert.Error(t, c.StartMode(RunModeWorker))
+}
+
+func TestInitModulesRollsBack(t *testing.T) {
+	var journal []string
+	c := newTestContainer(t,
+		&fakeModule{name: "db", journal: &journal},
+		&fakeModule{name: "cache", journal: &journal},
+		&fakeModule{name: "search", initErr: errors.New("connection refused"), journal: &journal},
+		&fakeModule{name: "cron", journal: &journal},
+	)
+	err := c.InitModules()
+	assert.EqualError(t, err, "failed to initialize module search: connection refused")
+	assert.Equal(t, []string{"init db", "init cache", "init search", "stop cache", "stop db"}, journal)
+}
+
+func TestStartModeRollsBack(t *testing.T) {
+	var journal []string
+	c := newTestContainer(t,
+		&fakeModule{name: "db", journal: &journal},
+		&modeModule{&fakeModule{name: "fiber", modes: []RunMode{RunModeWeb}, journal: &journal}},
+		&fakeModule{name: "queue", startErr: errors.New("redis is down"), journal: &journal},
+		&fakeModule{name: "cron", journal: &journal},
+	)
+	require.NoError(t, c.InitModules())
+	journal = nil
+	err := c.StartMode(RunModeWorker)
+	assert.EqualError(t, err, "failed to start module queue: redis is down")
+	assert.Equal(t, []string{"start db", "start queue", "stop cron", "stop queue", "stop fiber", "stop db"}, journal)
+	assert.False(t, c.started)
+}
+
+func TestRegisterModuleAfterInit(t *testing.T) {
+	var journal []string
+	c := NewContainer(nil, log.NewNop(), nil)
+	require.NoError(t, c.RegisterModule(&fakeModule{name: "db", journal: &journal}))
+	require.NoError(t, c.InitModules())
+
+	// a failed registration leaves no module behind, it can be retried
+	search := &fakeModule{name: "search", deps: []string{"cache"}, journal: &journal}
+	assert.EqualError(t, c.RegisterModule(search), "module search depends on unregistered module cache")
+	assert.Nil(t, c.GetModule("search"))
+	cache := &fakeModule{name: "cache", initErr: errors.New("connection refused"), journal: &journal}
+	assert.EqualError(t, c.RegisterModule(cache), "failed to initialize module cache: connection refused")
+	assert.Nil(t, c.GetModule("cache"))
+
+	cache.initErr = nil
+	require.NoError(t, c.RegisterModule(cache))
+	require.NoError(t, c.RegisterModule(search))
+	assert.Same(t, search, c.GetModule("search"))
+	assert.Equal(t, []string{"init db", "init cache", "init cache", "init search"}, journal)
+}
+
+func TestRegisterModuleAfterStart(t *testing.T) {
+	var journal []string
+	c := NewContainer(nil, log.NewNop(), nil)
+	require.NoError(t, c.RegisterModule(&fakeModule{name: "db", journal: &journal}))
+	require.NoError(t, c.InitModules())
+	require.NoError(t, c.Start())
+
+	queue := &fakeModule{name: "queue", startErr: errors.New("redis is down"), journal: &journal}
+	assert.EqualError(t, c.RegisterModule(queue), "failed to start module queue: redis is down")
+	assert.Nil(t, c.GetModule("queue"))
+
+	queue.startErr = nil
+	require.NoError(t, c.RegisterModule(queue))
+	// the module which failed to start is stopped once, the shutdown stops the registered modules only
+	require.NoError(t, c.Close())
+	assert.Equal(t, []string{
+		"init db", "start db",
+		"init queue", "start queue", "stop queue",
+		"init queue", "start queue",
+		"stop queue", "stop db",
+	}, journal)
+}
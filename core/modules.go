@@ -0,0 +1,451 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v3"
+	"go.oease.dev/goe/contracts"
+	"go.oease.dev/goe/modules/broker"
+	"go.oease.dev/goe/modules/cache"
+	"go.oease.dev/goe/modules/cron"
+	"go.oease.dev/goe/modules/msearch"
+	"io/fs"
+)
+
+// NewMongoDBModule creates the built-in MongoDB module.
+func NewMongoDBModule() Module {
+	return &mongoDBModule{}
+}
+
+type mongoDBModule struct {
+	mdb *GoeMongoDB
+}
+
+func (m *mongoDBModule) Name() string {
+	return ModuleMongoDB
+}
+
+func (m *mongoDBModule) DependsOn() []string {
+	return nil
+}
+
+func (m *mongoDBModule) Init(c *Container) error {
+	mdb, err := NewGoeMongoDB(c.appConfig, NamedLogger(c.logger, LoggerMongoDB))
+	if err != nil {
+		return err
+	}
+	if c.metrics != nil {
+		mdb.mongodbInstance.WithObserver(c.metrics)
+	}
+	if c.tracing != nil {
+		mdb.mongodbInstance.WithTracer(c.tracing.Tracer())
+	}
+	m.mdb = mdb
+	c.mongo = mdb
+	c.health.Register(ModuleMongoDB, func(ctx context.Context) error {
+		return mdb.mongodbInstance.Ping()
+	})
+	return nil
+}
+
+func (m *mongoDBModule) Start() error {
+	return nil
+}
+
+func (m *mongoDBModule) Stop() error {
+	if m.mdb == nil {
+		return nil
+	}
+	return m.mdb.mongodbInstance.Close()
+}
+
+// NewMeilisearchModule creates the built-in Meilisearch module, it depends on the MongoDB module for database sync.
+func NewMeilisearchModule() Module {
+	return &meilisearchModule{}
+}
+
+type meilisearchModule struct {
+}
+
+func (m *meilisearchModule) Name() string {
+	return ModuleMeilisearch
+}
+
+func (m *meilisearchModule) DependsOn() []string {
+	return []string{ModuleMongoDB}
+}
+
+func (m *meilisearchModule) Init(c *Container) error {
+	if c.appConfig.Meilisearch.ApiKey == "" {
+		return errors.New("meilisearch api key is required")
+	}
+	if c.appConfig.Meilisearch.Endpoint == "" {
+		return errors.New("meilisearch endpoint is required")
+	}
+	ms := msearch.NewMSearch(c.appConfig.Meilisearch.Endpoint, c.appConfig.Meilisearch.ApiKey, NamedLogger(c.logger, LoggerMSearch))
+	if ms == nil {
+		return errors.New("failed to initialize meilisearch")
+	}
+	if c.tracing != nil {
+		ms.WithTracer(c.tracing.Tracer())
+	}
+	c.meilisearch = ms
+	c.health.Register(ModuleMeilisearch, ms.Ping)
+	if c.appConfig.Features.SearchDBSyncEnabled {
+		mdb, ok := c.mongo.(*GoeMongoDB)
+		if !ok {
+			return errors.New("meilisearch db sync requires the built-in mongodb module")
+		}
+		if err := mdb.SetMeilisearch(ms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *meilisearchModule) Start() error {
+	return nil
+}
+
+func (m *meilisearchModule) Stop() error {
+	return nil
+}
+
+// NewQueueModule creates the built-in Redis message queue module.
+func NewQueueModule() Module {
+	return &queueModule{}
+}
+
+type queueModule struct {
+	queue *GoeQueue
+}
+
+func (m *queueModule) Name() string {
+	return ModuleQueue
+}
+
+func (m *queueModule) DependsOn() []string {
+	return nil
+}
+
+func (m *queueModule) Init(c *Container) error {
+	var q *GoeQueue
+	if c.appConfig.Queue.Driver == QueueDriverMemory {
+		q = NewGoeMemoryQueue(c.appConfig, NamedLogger(c.logger, LoggerQueue))
+	} else {
+		var err error
+		q, err = NewGoeQueue(c.appConfig, NamedLogger(c.logger, LoggerQueue))
+		if err != nil {
+			return err
+		}
+	}
+	if c.metrics != nil {
+		if err := q.WithMetrics(c.metrics); err != nil {
+			return err
+		}
+	}
+	if c.tracing != nil {
+		q.WithTracing(c.tracing)
+	}
+	m.queue = q
+	c.queue = q
+	c.health.Register(ModuleQueue, q.Ping)
+	c.config.OnChange("QUEUE_CONCURRENCY", func(oldValue, newValue string) error {
+		var section struct {
+			Queue GoeConfigQueue `prefix:"QUEUE_"`
+		}
+		if err := c.config.Bind(&section); err != nil {
+			return err
+		}
+		return q.SetConcurrency(section.Queue.ConcurrentWorkers)
+	})
+	return nil
+}
+
+// ActiveIn reports that the queue consumers are only started in the worker mode, messages can be published in every mode.
+func (m *queueModule) ActiveIn(mode RunMode) bool {
+	return mode.Includes(RunModeWorker)
+}
+
+func (m *queueModule) Start() error {
+	return m.queue.Start()
+}
+
+func (m *queueModule) Drain(ctx context.Context) error {
+	return m.queue.Drain(ctx)
+}
+
+func (m *queueModule) Stop() error {
+	if m.queue == nil {
+		return nil
+	}
+	return m.queue.Close()
+}
+
+// NewCronModule creates the built-in cron job module.
+func NewCronModule() Module {
+	return &cronModule{}
+}
+
+type cronModule struct {
+	cron *cron.CronJobModule
+}
+
+func (m *cronModule) Name() string {
+	return ModuleCron
+}
+
+func (m *cronModule) DependsOn() []string {
+	return nil
+}
+
+func (m *cronModule) Init(c *Container) error {
+	mod, err := cron.NewCronJobService()
+	if err != nil {
+		return err
+	}
+	if c.metrics != nil {
+		mod.WithObserver(c.metrics)
+	}
+	m.cron = mod
+	c.cron = mod
+	return nil
+}
+
+// ActiveIn reports that the cron scheduler is only started in the scheduler mode.
+func (m *cronModule) ActiveIn(mode RunMode) bool {
+	return mode.Includes(RunModeScheduler)
+}
+
+func (m *cronModule) Start() error {
+	m.cron.Start()
+	return nil
+}
+
+func (m *cronModule) Drain(ctx context.Context) error {
+	return m.cron.Shutdown(ctx)
+}
+
+func (m *cronModule) Stop() error {
+	if m.cron == nil || !m.cron.IsStarted() {
+		return nil
+	}
+	return m.cron.Close()
+}
+
+// NewCacheModule creates the built-in Redis cache module.
+func NewCacheModule() Module {
+	return &cacheModule{}
+}
+
+// Cache drivers, selected by CACHE_DRIVER
+const (
+	CacheDriverRedis  = "redis"
+	CacheDriverMemory = "memory"
+)
+
+type cacheModule struct {
+	cache interface {
+		contracts.Cache
+		Ping(ctx context.Context) error
+		Close() error
+	}
+}
+
+func (m *cacheModule) Name() string {
+	return ModuleCache
+}
+
+func (m *cacheModule) DependsOn() []string {
+	return nil
+}
+
+func (m *cacheModule) Init(c *Container) error {
+	if c.appConfig.Cache.Driver == CacheDriverMemory {
+		mc := cache.NewMemoryCache(0, NamedLogger(c.logger, LoggerCache))
+		if c.metrics != nil {
+			mc.WithObserver(c.metrics)
+		}
+		m.cache = mc
+		c.cache = mc
+		c.health.Register(ModuleCache, mc.Ping)
+		return nil
+	}
+	if c.appConfig.Redis.Host == "" || c.appConfig.Redis.Port == 0 {
+		return errors.New("missing required redis configuration")
+	}
+	rc := cache.NewRedisCache(c.appConfig.Redis.Host, c.appConfig.Redis.Port, c.appConfig.Redis.Username, c.appConfig.Redis.Password, RedisDBCache, NamedLogger(c.logger, LoggerCache))
+	if rc == nil {
+		return errors.New("failed to initialize redis cache")
+	}
+	if c.metrics != nil {
+		rc.WithObserver(c.metrics)
+	}
+	m.cache = rc
+	c.cache = rc
+	c.health.Register(ModuleCache, rc.Ping)
+	return nil
+}
+
+func (m *cacheModule) Start() error {
+	return nil
+}
+
+func (m *cacheModule) Stop() error {
+	if m.cache == nil {
+		return nil
+	}
+	return m.cache.Close()
+}
+
+// NewMailerModule creates the built-in mailer module, it depends on the queue module for queued delivery.
+func NewMailerModule() Module {
+	return &mailerModule{}
+}
+
+type mailerModule struct {
+}
+
+func (m *mailerModule) Name() string {
+	return ModuleMailer
+}
+
+func (m *mailerModule) DependsOn() []string {
+	return []string{ModuleQueue}
+}
+
+func (m *mailerModule) Init(c *Container) error {
+	if c.queue == nil {
+		return errors.New("queue is required to initialize mailer")
+	}
+	mailer := NewGoeMailer(c.appConfig, c.queue, NamedLogger(c.logger, LoggerMail))
+	if mailer == nil {
+		return errors.New("failed to initialize mailer")
+	}
+	if c.metrics != nil {
+		mailer.manager.WithObserver(c.metrics)
+	}
+	if c.tracing != nil {
+		mailer.manager.WithTracer(c.tracing.Tracer())
+	}
+	if c.i18n != nil {
+		mailer.manager.WithTranslator(c.i18n)
+	}
+	c.mailer = mailer
+	return nil
+}
+
+func (m *mailerModule) Start() error {
+	return nil
+}
+
+func (m *mailerModule) Stop() error {
+	return nil
+}
+
+// NewFiberModule creates the built-in Fiber http server module, the apps render the given views, such as an embed.FS of the app, and the views of VIEW_DIR.
+func NewFiberModule(views ...fs.FS) Module {
+	return &fiberModule{views: views}
+}
+
+type fiberModule struct {
+	views []fs.FS
+}
+
+func (m *fiberModule) Name() string {
+	return ModuleFiber
+}
+
+func (m *fiberModule) DependsOn() []string {
+	return nil
+}
+
+func (m *fiberModule) Init(c *Container) error {
+	views, err := newViewEngine(c.appConfig.View, m.views)
+	if err != nil {
+		return err
+	}
+	fb := NewGoeFiber(c.appConfig, c.logger, views)
+	if fb == nil {
+		return errors.New("failed to initialize fiber")
+	}
+	registerHealthRoutes(c, fb.App())
+	registerTracingMiddleware(c, fb.App())
+	registerRequestContextMiddleware(c, fb.App())
+	registerI18nMiddleware(c, fb.App())
+	registerMetricsRoutes(c, fb.App())
+	registerLogLevelRoutes(c, fb.App())
+	fb.App().Hooks().OnListen(func(data fiber.ListenData) error {
+		c.logger.Infof("Server is running on http://%s:%s", data.Host, data.Port)
+		return nil
+	})
+	c.fiber = fb
+	return nil
+}
+
+// ActiveIn reports that the http server is only served in the web mode.
+func (m *fiberModule) ActiveIn(mode RunMode) bool {
+	return mode.Includes(RunModeWeb)
+}
+
+func (m *fiberModule) Start() error {
+	return nil
+}
+
+func (m *fiberModule) Stop() error {
+	return nil
+}
+
+// NewEMQXModule creates the built-in EMQX broker module.
+func NewEMQXModule() Module {
+	return &emqxModule{}
+}
+
+type emqxModule struct {
+	emqx contracts.EMQX
+}
+
+func (m *emqxModule) Name() string {
+	return ModuleEMQX
+}
+
+func (m *emqxModule) DependsOn() []string {
+	return nil
+}
+
+func (m *emqxModule) Init(c *Container) error {
+	// init emqx config
+	c.appConfig.EMQX.Complete()
+	// init emqx broker
+	emqx, err := broker.NewEMQX(c.appConfig.EMQX)
+	if err != nil {
+		return err
+	}
+	if bk, ok := emqx.(*broker.EMQX); ok {
+		bk.WithLogger(NamedLogger(c.logger, LoggerEMQX).GetZapLogger())
+		if c.tracing != nil {
+			bk.WithTracer(c.tracing.Tracer())
+		}
+	}
+	m.emqx = emqx
+	c.emqx = emqx
+	c.health.Register(ModuleEMQX, func(ctx context.Context) error {
+		if !emqx.IsConnected() {
+			return errors.New("emqx broker is not connected")
+		}
+		return nil
+	})
+	return nil
+}
+
+func (m *emqxModule) Start() error {
+	return nil
+}
+
+func (m *emqxModule) Stop() error {
+	if m.emqx == nil {
+		return nil
+	}
+	m.emqx.Close()
+	return nil
+}
@@ -1,8 +1,10 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.oease.dev/goe/contracts"
 	"go.oease.dev/goe/modules/mongodb"
 	"go.oease.dev/goe/modules/msearch"
 	"go.oease.dev/omgo"
@@ -12,6 +14,7 @@ type GoeMongoDB struct {
 	goeConfig       *GoeConfig
 	msearchInstance *msearch.MSearch
 	mongodbInstance *mongodb.MongoDB
+	logger          mongodb.Logger
 }
 
 func NewGoeMongoDB(appConfig *GoeConfig, logger mongodb.Logger) (*GoeMongoDB, error) {
@@ -29,6 +32,7 @@ func NewGoeMongoDB(appConfig *GoeConfig, logger mongodb.Logger) (*GoeMongoDB, er
 		goeConfig:       appConfig,
 		mongodbInstance: mdb,
 		msearchInstance: nil,
+		logger:          logger,
 	}, nil
 }
 
@@ -43,6 +47,16 @@ func (g *GoeMongoDB) SetMeilisearch(meilisearch *msearch.MSearch) error {
 	return nil
 }
 
+// WithContext returns a MongoDB whose operations, including the search index sync, run with ctx and are traced as children of its span.
+func (g *GoeMongoDB) WithContext(ctx context.Context) contracts.MongoDB {
+	c := *g
+	c.mongodbInstance = g.mongodbInstance.WithContext(ctx)
+	if g.msearchInstance != nil {
+		c.msearchInstance = g.msearchInstance.WithContext(ctx)
+	}
+	return &c
+}
+
 func (g *GoeMongoDB) Find(model mongodb.IDefaultModel, filter any) omgo.QueryI {
 	return g.mongodbInstance.Find(model, filter)
 }
@@ -142,7 +156,7 @@ func (g *GoeMongoDB) DeleteMany(model mongodb.IDefaultModel, filter any) (*omgo.
 				delete(res, "_id")
 				err := g.msearchInstance.DelDoc(model.ColName(), res["id"].(string))
 				if err != nil {
-					UseGoeContainer().GetLogger().Error(err)
+					g.logger.Error(err)
 				}
 			}
 			if cur.Err() != nil {
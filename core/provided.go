@@ -0,0 +1,63 @@
+package core
+
+import (
+	"go.oease.dev/goe/contracts"
+)
+
+// providedModule takes the place of a built-in module with an implementation provided by the application,
+// such as a fake in tests, it does not connect to any infrastructure and its lifecycle is owned by the provider.
+type providedModule struct {
+	name string
+	set  func(c *Container)
+}
+
+func (m *providedModule) Name() string {
+	return m.name
+}
+
+func (m *providedModule) DependsOn() []string {
+	return nil
+}
+
+func (m *providedModule) Init(c *Container) error {
+	m.set(c)
+	return nil
+}
+
+func (m *providedModule) Start() error {
+	return nil
+}
+
+func (m *providedModule) Stop() error {
+	return nil
+}
+
+// ProvideMongoDB creates a module replacing the built-in MongoDB module with the given implementation.
+func ProvideMongoDB(db contracts.MongoDB) Module {
+	return &providedModule{name: ModuleMongoDB, set: func(c *Container) { c.mongo = db }}
+}
+
+// ProvideMeilisearch creates a module replacing the built-in Meilisearch module with the given implementation.
+func ProvideMeilisearch(search contracts.Meilisearch) Module {
+	return &providedModule{name: ModuleMeilisearch, set: func(c *Container) { c.meilisearch = search }}
+}
+
+// ProvideQueue creates a module replacing the built-in queue module with the given implementation.
+func ProvideQueue(queue contracts.Queue) Module {
+	return &providedModule{name: ModuleQueue, set: func(c *Container) { c.queue = queue }}
+}
+
+// ProvideCache creates a module replacing the built-in cache module with the given implementation.
+func ProvideCache(cache contracts.Cache) Module {
+	return &providedModule{name: ModuleCache, set: func(c *Container) { c.cache = cache }}
+}
+
+// ProvideMailer creates a module replacing the built-in mailer module with the given implementation.
+func ProvideMailer(mailer contracts.Mailer) Module {
+	return &providedModule{name: ModuleMailer, set: func(c *Container) { c.mailer = mailer }}
+}
+
+// ProvideEMQX creates a module replacing the built-in EMQX module with the given implementation.
+func ProvideEMQX(emqx contracts.EMQX) Module {
+	return &providedModule{name: ModuleEMQX, set: func(c *Container) { c.emqx = emqx }}
+}
@@ -1,21 +1,69 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/goccy/go-json"
 	"github.com/redis/go-redis/v9"
 	"go.oease.dev/goe/contracts"
+	"go.oease.dev/goe/modules/metrics"
 	"go.oease.dev/goe/modules/queue"
+	"go.oease.dev/goe/modules/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Queue drivers, selected by QUEUE_DRIVER
+const (
+	QueueDriverRedis  = "redis"
+	QueueDriverMemory = "memory"
+)
+
+// queueEngine is the queue implementation behind GoeQueue, a queue.DelayQueue over redis or an in-process queue.MemoryQueue
+type queueEngine interface {
+	SendScheduleMsg(payload string, t time.Time, opts ...interface{}) error
+	SendDelayMsg(payload string, duration time.Duration, opts ...interface{}) error
+	StartConsume() (done <-chan struct{})
+	StopConsume()
+	Shutdown(ctx context.Context) error
+	GetPendingCount() (int64, error)
+	GetReadyCount() (int64, error)
+	GetProcessingCount() (int64, error)
+	ListenEvent(listener queue.EventListener)
+	SetConcurrent(c uint)
+}
+
+// queueLogger logs the messages of the queues with the app logger, the Printf messages are logged at info level.
+type queueLogger struct {
+	contracts.Logger
+}
+
+func (l queueLogger) Printf(format string, v ...any) {
+	l.Infof(strings.TrimSuffix(format, "\n"), v...)
+}
+
 type GoeQueue struct {
 	queues    sync.Map
 	goeConfig *GoeConfig
 	logger    contracts.Logger
-	redisCli  *redis.Client
+	// redisCli is nil when the in-process memory driver is used
+	redisCli *redis.Client
+	started  bool
+	// metrics is nil when the metrics are disabled
+	metrics *metrics.Metrics
+	// tracing is nil when the tracing is disabled
+	tracing *tracing.Tracing
+	// concurrency overrides QUEUE_CONCURRENCY once changed by SetConcurrency
+	concurrency atomic.Int64
+	// defaultQueues holds the names of the queues declared without config, their workers follow SetConcurrency
+	defaultQueues sync.Map
 }
 
 func NewGoeQueue(appConfig *GoeConfig, logger contracts.Logger) (*GoeQueue, error) {
@@ -37,9 +85,22 @@ func NewGoeQueue(appConfig *GoeConfig, logger contracts.Logger) (*GoeQueue, erro
 	}
 }
 
+// NewGoeMemoryQueue creates a queue with in-process queues, messages are not shared between processes and are lost when the process exits.
+func NewGoeMemoryQueue(appConfig *GoeConfig, logger contracts.Logger) *GoeQueue {
+	return &GoeQueue{
+		goeConfig: appConfig,
+		logger:    logger,
+		queues:    sync.Map{},
+	}
+}
+
 func (g *GoeQueue) Start() error {
+	if g.started {
+		return nil
+	}
+	g.started = true
 	g.queues.Range(func(key, value any) bool {
-		rq, ok := value.(*queue.DelayQueue)
+		rq, ok := value.(queueEngine)
 		if !ok {
 			return false
 		}
@@ -49,21 +110,108 @@ func (g *GoeQueue) Start() error {
 	return nil
 }
 
-func (g *GoeQueue) Close() error {
+// Drain stops all consumers and waits until the in-flight messages are consumed or ctx is done.
+func (g *GoeQueue) Drain(ctx context.Context) error {
+	if !g.started {
+		return nil
+	}
+	g.started = false
+	var errs []error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
 	g.queues.Range(func(key, value any) bool {
-		rq, ok := value.(*queue.DelayQueue)
+		rq, ok := value.(queueEngine)
 		if !ok {
 			return false
 		}
-		rq.StopConsume()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := rq.Shutdown(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("queue %v: %w", key, err))
+				mu.Unlock()
+			}
+		}()
 		return true
 	})
-	return nil
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (g *GoeQueue) Close() error {
+	if g.started {
+		g.queues.Range(func(key, value any) bool {
+			rq, ok := value.(queueEngine)
+			if !ok {
+				return false
+			}
+			rq.StopConsume()
+			return true
+		})
+		g.started = false
+	}
+	if g.redisCli == nil {
+		return nil
+	}
+	return g.redisCli.Close()
+}
+
+// Ping checks the connection to the redis server of the queue, it always succeeds with the memory driver.
+func (g *GoeQueue) Ping(ctx context.Context) error {
+	if g.redisCli == nil {
+		return nil
+	}
+	return g.redisCli.Ping(ctx).Err()
+}
+
+func (g *GoeQueue) Stats() ([]contracts.QueueStats, error) {
+	stats := make([]contracts.QueueStats, 0)
+	var errs []error
+	g.queues.Range(func(key, value any) bool {
+		rq, ok := value.(queueEngine)
+		if !ok {
+			return true
+		}
+		s := contracts.QueueStats{Name: key.(contracts.QueueName)}
+		var err error
+		if s.Pending, err = rq.GetPendingCount(); err != nil {
+			errs = append(errs, err)
+		}
+		if s.Ready, err = rq.GetReadyCount(); err != nil {
+			errs = append(errs, err)
+		}
+		if s.Processing, err = rq.GetProcessingCount(); err != nil {
+			errs = append(errs, err)
+		}
+		stats = append(stats, s)
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats, errors.Join(errs...)
 }
 
 func (g *GoeQueue) NewQueue(name contracts.QueueName, handler func(string) bool, cfgs ...*contracts.NewQueueCfg) error {
-	rq := queue.NewQueue(string(name), g.redisCli)
-	rq.WithCallback(handler)
+	return g.NewQueueContext(name, func(ctx context.Context, payload string) bool {
+		return handler(payload)
+	}, cfgs...)
+}
+
+// NewQueueContext declares a queue whose handler receives the context of the message.
+// The context continues the trace of the context given to WithContext when the message was pushed.
+func (g *GoeQueue) NewQueueContext(name contracts.QueueName, handler func(ctx context.Context, payload string) bool, cfgs ...*contracts.NewQueueCfg) error {
+	callback := g.consumer(name, handler)
+	// if no config is provided, use the default config from the app config
+	concurrentWorkers := g.goeConfig.Queue.ConcurrentWorkers
+	if n := g.concurrency.Load(); n > 0 {
+		concurrentWorkers = int(n)
+	}
+	fetchInterval := g.goeConfig.Queue.FetchInterval
+	defaultRetries := g.goeConfig.Queue.DefaultRetries
+	maxConsumeDuration := g.goeConfig.Queue.MaxConsumeDuration
+	fetchLimit := g.goeConfig.Queue.FetchLimit
 	if len(cfgs) != 0 && cfgs[0] != nil {
 		// if config is provided, use the provided config, if values are 0, use the default values
 		if cfgs[0].ConcurrentWorkers == 0 {
@@ -82,29 +230,158 @@ func (g *GoeQueue) NewQueue(name contracts.QueueName, handler func(string) bool,
 			// default to 60 seconds
 			cfgs[0].MaxConsumeDuration = 60
 		}
-		rq.WithConcurrent(uint(cfgs[0].ConcurrentWorkers))
-		rq.WithFetchInterval(time.Duration(cfgs[0].FetchInterval) * time.Second)
-		rq.WithDefaultRetryCount(uint(cfgs[0].DefaultRetries))
-		rq.WithMaxConsumeDuration(time.Duration(cfgs[0].MaxConsumeDuration) * time.Second)
-		rq.WithFetchLimit(uint(cfgs[0].FetchLimit))
+		concurrentWorkers = cfgs[0].ConcurrentWorkers
+		fetchInterval = cfgs[0].FetchInterval
+		defaultRetries = cfgs[0].DefaultRetries
+		maxConsumeDuration = cfgs[0].MaxConsumeDuration
+		fetchLimit = cfgs[0].FetchLimit
 	} else {
-		// if no config is provided, use the default config from the app config
-		rq.WithConcurrent(uint(g.goeConfig.Queue.ConcurrentWorkers))
-		rq.WithFetchInterval(time.Duration(g.goeConfig.Queue.FetchInterval) * time.Second)
-		rq.WithDefaultRetryCount(uint(g.goeConfig.Queue.DefaultRetries))
-		rq.WithMaxConsumeDuration(time.Duration(g.goeConfig.Queue.MaxConsumeDuration) * time.Second)
-		rq.WithFetchLimit(uint(g.goeConfig.Queue.FetchLimit))
+		g.defaultQueues.Store(name, struct{}{})
+	}
+	if g.redisCli == nil {
+		// the memory queue delivers messages as soon as they are due, fetch interval and limit do not apply
+		rq := queue.NewMemoryQueue(string(name), callback)
+		rq.WithLogger(queueLogger{g.logger})
+		rq.WithConcurrent(uint(concurrentWorkers))
+		rq.WithDefaultRetryCount(uint(defaultRetries))
+		rq.WithMaxConsumeDuration(time.Duration(maxConsumeDuration) * time.Second)
+		g.listenEvents(name, rq)
+		g.queues.Store(name, rq)
+		return nil
 	}
+	rq := queue.NewQueue(string(name), g.redisCli)
+	rq.WithCallback(callback)
+	rq.WithLogger(queueLogger{g.logger})
+	rq.WithConcurrent(uint(concurrentWorkers))
+	rq.WithFetchInterval(time.Duration(fetchInterval) * time.Second)
+	rq.WithDefaultRetryCount(uint(defaultRetries))
+	rq.WithMaxConsumeDuration(time.Duration(maxConsumeDuration) * time.Second)
+	rq.WithFetchLimit(uint(fetchLimit))
+	g.listenEvents(name, rq)
 	g.queues.Store(name, rq)
 	return nil
 }
 
+// SetConcurrency changes the number of workers of the queues declared without config, the running consumers are resized.
+// The queues declared with a config keep their own number of workers.
+func (g *GoeQueue) SetConcurrency(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("invalid queue concurrency %d, it must be at least 1", n)
+	}
+	g.concurrency.Store(int64(n))
+	g.defaultQueues.Range(func(key, _ any) bool {
+		if rq, ok := g.queues.Load(key); ok {
+			rq.(queueEngine).SetConcurrent(uint(n))
+		}
+		return true
+	})
+	return nil
+}
+
+// consumer returns the callback of the queue, it strips the trace context from the payload and consumes the message in a span continuing the trace.
+func (g *GoeQueue) consumer(name contracts.QueueName, handler func(ctx context.Context, payload string) bool) func(string) bool {
+	return func(payload string) bool {
+		// the trace context is stripped even if the tracing is disabled, it may have been pushed by another process
+		carrier, payload := tracing.UnwrapPayload(payload)
+		ctx := context.Background()
+		fields := []any{"queue", string(name)}
+		if id := carrier[requestIDCarrierKey]; validRequestID(id) {
+			ctx = ContextWithRequestID(ctx, id)
+			fields = append(fields, "request_id", id)
+		}
+		if g.tracing == nil {
+			return handler(ContextWithLogger(ctx, WithLogFields(g.logger, fields...)), payload)
+		}
+		ctx = g.tracing.Extract(ctx, carrier)
+		ctx, span := g.tracing.Tracer().Start(ctx, "queue.consume "+string(name),
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				attribute.String("messaging.system", "goe"),
+				attribute.String("messaging.destination.name", string(name)),
+			),
+		)
+		defer span.End()
+		fields = append(fields, "trace_id", span.SpanContext().TraceID().String())
+		if !handler(ContextWithLogger(ctx, WithLogFields(g.logger, fields...)), payload) {
+			span.SetStatus(codes.Error, "message not acknowledged")
+			return false
+		}
+		return true
+	}
+}
+
+// WithTracing propagates the trace of the pushers to the consumers of the queues.
+func (g *GoeQueue) WithTracing(t *tracing.Tracing) *GoeQueue {
+	g.tracing = t
+	return g
+}
+
+// WithContext returns a queue whose pushed messages carry the trace context and the request id of ctx, they are consumed as part of the same trace and logged with the same request id.
+func (g *GoeQueue) WithContext(ctx context.Context) contracts.Queue {
+	return &contextQueue{GoeQueue: g, ctx: ctx}
+}
+
+// WithMetrics records the events of the queues in the metrics, and exposes their message counts as gauges.
+func (g *GoeQueue) WithMetrics(m *metrics.Metrics) error {
+	g.metrics = m
+	return m.WatchQueues(func() ([]metrics.QueueStats, error) {
+		stats, err := g.Stats()
+		queueStats := make([]metrics.QueueStats, 0, len(stats))
+		for _, s := range stats {
+			queueStats = append(queueStats, metrics.QueueStats{
+				Name:       string(s.Name),
+				Pending:    s.Pending,
+				Ready:      s.Ready,
+				Processing: s.Processing,
+			})
+		}
+		return queueStats, err
+	})
+}
+
+// listenEvents reports the events of the queue to the metrics, if they are enabled.
+func (g *GoeQueue) listenEvents(name contracts.QueueName, rq queueEngine) {
+	if g.metrics == nil {
+		return
+	}
+	rq.ListenEvent(&queueMetricsListener{name: string(name), metrics: g.metrics})
+}
+
+// queueMetricsListener counts the events of a queue, such as acked and retried messages.
+type queueMetricsListener struct {
+	name    string
+	metrics *metrics.Metrics
+}
+
+func (l *queueMetricsListener) OnEvent(event *queue.Event) {
+	var name string
+	switch event.Code {
+	case queue.NewMessageEvent:
+		name = "new_message"
+	case queue.ReadyEvent:
+		name = "ready"
+	case queue.DeliveredEvent:
+		name = "delivered"
+	case queue.AckEvent:
+		name = "ack"
+	case queue.NackEvent:
+		name = "nack"
+	case queue.RetryEvent:
+		name = "retry"
+	case queue.FinalFailedEvent:
+		name = "final_failed"
+	default:
+		return
+	}
+	l.metrics.ObserveQueueEvent(l.name, name, event.MsgCount)
+}
+
 func (g *GoeQueue) PushRaw(queueName contracts.QueueName, payload string) error {
 	rqm, ok := g.queues.Load(queueName)
 	if !ok {
 		return errors.New("queue not found")
 	}
-	rq, ok := rqm.(*queue.DelayQueue)
+	rq, ok := rqm.(queueEngine)
 	if !ok {
 		return errors.New("queue not found or invalid type")
 	}
@@ -116,7 +393,7 @@ func (g *GoeQueue) Push(queueName contracts.QueueName, payloadPtr any) error {
 	if !ok {
 		return errors.New("queue not found")
 	}
-	rq, ok := rqm.(*queue.DelayQueue)
+	rq, ok := rqm.(queueEngine)
 	if !ok {
 		return errors.New("queue not found or invalid type")
 	}
@@ -132,7 +409,7 @@ func (g *GoeQueue) PushDelayed(queueName contracts.QueueName, payloadPtr any, de
 	if !ok {
 		return errors.New("queue not found")
 	}
-	rq, ok := rqm.(*queue.DelayQueue)
+	rq, ok := rqm.(queueEngine)
 	if !ok {
 		return errors.New("queue not found or invalid type")
 	}
@@ -148,7 +425,7 @@ func (g *GoeQueue) PushDelayedRaw(queueName contracts.QueueName, payload string,
 	if !ok {
 		return errors.New("queue not found")
 	}
-	rq, ok := rqm.(*queue.DelayQueue)
+	rq, ok := rqm.(queueEngine)
 	if !ok {
 		return errors.New("queue not found or invalid type")
 	}
@@ -160,7 +437,7 @@ func (g *GoeQueue) PushScheduledRaw(queueName contracts.QueueName, payload strin
 	if !ok {
 		return errors.New("queue not found")
 	}
-	rq, ok := rqm.(*queue.DelayQueue)
+	rq, ok := rqm.(queueEngine)
 	if !ok {
 		return errors.New("queue not found or invalid type")
 	}
@@ -172,7 +449,7 @@ func (g *GoeQueue) PushScheduled(queueName contracts.QueueName, payloadPtr any,
 	if !ok {
 		return errors.New("queue not found")
 	}
-	rq, ok := rqm.(*queue.DelayQueue)
+	rq, ok := rqm.(queueEngine)
 	if !ok {
 		return errors.New("queue not found or invalid type")
 	}
@@ -182,3 +459,72 @@ func (g *GoeQueue) PushScheduled(queueName contracts.QueueName, payloadPtr any,
 	}
 	return rq.SendScheduleMsg(string(data), t)
 }
+
+// contextQueue is a GoeQueue bound to a context by WithContext, the trace context and the request id are prepended to the pushed payloads
+type contextQueue struct {
+	*GoeQueue
+	ctx context.Context
+}
+
+func (q *contextQueue) WithContext(ctx context.Context) contracts.Queue {
+	return q.GoeQueue.WithContext(ctx)
+}
+
+// wrap prepends the trace context and the request id of the queue context to the payload, the payload is unchanged if there are none
+func (q *contextQueue) wrap(queueName contracts.QueueName, payload string) string {
+	if q.ctx == nil {
+		return payload
+	}
+	carrier := map[string]string{}
+	if q.tracing != nil && trace.SpanContextFromContext(q.ctx).IsValid() {
+		_, span := q.tracing.Tracer().Start(q.ctx, "queue.publish "+string(queueName),
+			trace.WithSpanKind(trace.SpanKindProducer),
+			trace.WithAttributes(
+				attribute.String("messaging.system", "goe"),
+				attribute.String("messaging.destination.name", string(queueName)),
+			),
+		)
+		defer span.End()
+		carrier = q.tracing.Inject(trace.ContextWithSpan(q.ctx, span))
+	}
+	if id := RequestIDFromContext(q.ctx); id != "" {
+		carrier[requestIDCarrierKey] = id
+	}
+	return tracing.WrapPayload(carrier, payload)
+}
+
+func (q *contextQueue) PushRaw(queueName contracts.QueueName, payload string) error {
+	return q.GoeQueue.PushRaw(queueName, q.wrap(queueName, payload))
+}
+
+func (q *contextQueue) Push(queueName contracts.QueueName, payloadPtr any) error {
+	data, err := json.Marshal(payloadPtr)
+	if err != nil {
+		return err
+	}
+	return q.PushRaw(queueName, string(data))
+}
+
+func (q *contextQueue) PushDelayed(queueName contracts.QueueName, payloadPtr any, delayDuration time.Duration) error {
+	data, err := json.Marshal(payloadPtr)
+	if err != nil {
+		return err
+	}
+	return q.PushDelayedRaw(queueName, string(data), delayDuration)
+}
+
+func (q *contextQueue) PushDelayedRaw(queueName contracts.QueueName, payload string, delayDuration time.Duration) error {
+	return q.GoeQueue.PushDelayedRaw(queueName, q.wrap(queueName, payload), delayDuration)
+}
+
+func (q *contextQueue) PushScheduledRaw(queueName contracts.QueueName, payload string, t time.Time) error {
+	return q.GoeQueue.PushScheduledRaw(queueName, q.wrap(queueName, payload), t)
+}
+
+func (q *contextQueue) PushScheduled(queueName contracts.QueueName, payloadPtr any, t time.Time) error {
+	data, err := json.Marshal(payloadPtr)
+	if err != nil {
+		return err
+	}
+	return q.PushScheduledRaw(queueName, string(data), t)
+}
@@ -0,0 +1,220 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go.oease.dev/goe/contracts"
+	"go.oease.dev/goe/modules/config"
+	"go.oease.dev/goe/modules/mongodb"
+	"time"
+)
+
+// Config sources, selected by CONFIG_SOURCE
+const (
+	ConfigSourceRedis   = "redis"
+	ConfigSourceMongoDB = "mongodb"
+)
+
+const (
+	defaultConfigRedisKey      = "goe:config"
+	defaultConfigCollection    = "configs"
+	defaultConfigWatchInterval = 5 * time.Second
+)
+
+// reloadableConfig is implemented by the built-in config, a config given by goe.WithConfig may not support reloads.
+type reloadableConfig interface {
+	AddSource(src config.Source) error
+	OnReload(fn func(changes []config.Change))
+	Watch(ctx context.Context, interval time.Duration, onError func(err error))
+}
+
+// levelSetter is implemented by the loggers whose level can change at runtime, such as the built-in zap logger.
+type levelSetter interface {
+	SetLevel(level string) error
+}
+
+// NewConfigModule creates the built-in config reload module.
+// It reads the config values stored in the source, redis or mongodb, watches the config files and the source,
+// and reports the changed values which require a restart. The mongodb source depends on the MongoDB module.
+func NewConfigModule(source string) Module {
+	return &configModule{source: source}
+}
+
+type configModule struct {
+	source   string
+	cfg      reloadableConfig
+	redisCli *redis.Client
+	interval time.Duration
+	cancel   context.CancelFunc
+	logger   contracts.Logger
+}
+
+func (m *configModule) Name() string {
+	return ModuleConfig
+}
+
+func (m *configModule) DependsOn() []string {
+	if m.source == ConfigSourceMongoDB {
+		return []string{ModuleMongoDB}
+	}
+	return nil
+}
+
+func (m *configModule) Init(c *Container) error {
+	cfg, ok := c.config.(reloadableConfig)
+	if !ok {
+		return errors.New("config reload requires the built-in config, the config given by WithConfig does not support it")
+	}
+	m.cfg = cfg
+	m.logger = c.logger
+	settings := c.appConfig.Config
+	m.interval = time.Duration(settings.WatchInterval) * time.Second
+	if m.interval <= 0 {
+		m.interval = defaultConfigWatchInterval
+	}
+
+	var src config.Source
+	switch m.source {
+	case "":
+	case ConfigSourceRedis:
+		if c.appConfig.Redis.Host == "" || c.appConfig.Redis.Port == 0 {
+			return errors.New("redis config source requires the redis configuration")
+		}
+		key := settings.SourceKey
+		if key == "" {
+			key = defaultConfigRedisKey
+		}
+		m.redisCli = redis.NewClient(&redis.Options{
+			Addr:     fmt.Sprintf("%s:%d", c.appConfig.Redis.Host, c.appConfig.Redis.Port),
+			Username: c.appConfig.Redis.Username,
+			Password: c.appConfig.Redis.Password,
+			DB:       RedisDBConfig,
+		})
+		src = NewRedisConfigSource(m.redisCli, key)
+	case ConfigSourceMongoDB:
+		if c.mongo == nil {
+			return errors.New("mongodb config source requires the mongodb module")
+		}
+		collection := settings.SourceKey
+		if collection == "" {
+			collection = defaultConfigCollection
+		}
+		src = NewMongoConfigSource(c.mongo, collection)
+	default:
+		return fmt.Errorf("unsupported config source: %s", m.source)
+	}
+	if src != nil {
+		if err := cfg.AddSource(src); err != nil {
+			return err
+		}
+		// the app config was bound before the source was read, the modules initialized from now on see the values of the source
+		if err := c.config.Bind(c.appConfig); err != nil {
+			return err
+		}
+		if err := SetLogLevel(c.logger, c.appConfig); err != nil {
+			return err
+		}
+	}
+
+	c.config.OnChange("LOG_LEVEL", func(oldValue, newValue string) error {
+		var section struct {
+			Log GoeConfigLog `prefix:"LOG_"`
+		}
+		if err := c.config.Bind(&section); err != nil {
+			return err
+		}
+		return SetLogLevel(c.logger, &GoeConfig{App: c.appConfig.App, Log: &section.Log})
+	})
+	cfg.OnReload(func(changes []config.Change) {
+		for _, change := range changes {
+			switch {
+			case change.Live:
+				c.logger.Infof("Config %s changed and applied", change.Key)
+			case change.Err != nil:
+				c.logger.Errorf("Config %s changed but failed to apply, restart the app to apply it: %v", change.Key, change.Err)
+			default:
+				c.logger.Warnf("Config %s changed, restart the app to apply it", change.Key)
+			}
+		}
+	})
+	return nil
+}
+
+func (m *configModule) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.cfg.Watch(ctx, m.interval, func(err error) {
+		m.logger.Errorf("Failed to reload config, the current values are kept: %v", err)
+	})
+	return nil
+}
+
+func (m *configModule) Stop() error {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	if m.redisCli == nil {
+		return nil
+	}
+	return m.redisCli.Close()
+}
+
+// SetLogLevel applies LOG_LEVEL to the logger if it supports changing its level, an empty level means debug in dev and info otherwise.
+func SetLogLevel(logger contracts.Logger, appConfig *GoeConfig) error {
+	setter, ok := logger.(levelSetter)
+	if !ok {
+		return nil
+	}
+	level := appConfig.Log.Level
+	if level == "" {
+		level = "info"
+		if appConfig.App.Env == "dev" {
+			level = "debug"
+		}
+	}
+	return setter.SetLevel(level)
+}
+
+// NewRedisConfigSource reads the config values from the fields of a redis hash, such as HSET goe:config LOG_LEVEL debug.
+func NewRedisConfigSource(cli *redis.Client, key string) config.Source {
+	return config.SourceFunc("redis:"+key, func(ctx context.Context) (map[string]string, error) {
+		return cli.HGetAll(ctx, key).Result()
+	})
+}
+
+// ConfigEntry is a config value stored in the collection read by the mongodb config source.
+type ConfigEntry struct {
+	mongodb.DefaultModel `bson:",inline"`
+	Key                  string `bson:"key" json:"key"`
+	Value                string `bson:"value" json:"value"`
+	collection           string
+}
+
+// ColName returns the collection of the entries, configs unless another collection is set by CONFIG_SOURCE_KEY.
+func (e *ConfigEntry) ColName() string {
+	if e.collection != "" {
+		return e.collection
+	}
+	return defaultConfigCollection
+}
+
+// NewMongoConfigSource reads the config values from the key and value fields of the documents of the collection.
+func NewMongoConfigSource(db contracts.MongoDB, collection string) config.Source {
+	return config.SourceFunc("mongodb:"+collection, func(ctx context.Context) (map[string]string, error) {
+		query := db.WithContext(ctx).Find(&ConfigEntry{collection: collection}, nil)
+		if query == nil {
+			return nil, errors.New("mongodb is not initialized")
+		}
+		var entries []ConfigEntry
+		if err := query.All(&entries); err != nil {
+			return nil, err
+		}
+		values := make(map[string]string, len(entries))
+		for _, entry := range entries {
+			values[entry.Key] = entry.Value
+		}
+		return values, nil
+	})
+}
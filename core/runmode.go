@@ -0,0 +1,51 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RunMode selects the subsystems started by the app, so web and worker processes can be deployed separately.
+type RunMode string
+
+const (
+	// RunModeAll starts the http server, the queue consumers and the cron scheduler.
+	RunModeAll RunMode = "all"
+	// RunModeWeb starts the http server only.
+	RunModeWeb RunMode = "web"
+	// RunModeWorker starts the queue consumers only, without opening an http port.
+	RunModeWorker RunMode = "worker"
+	// RunModeScheduler starts the cron scheduler only, without opening an http port.
+	RunModeScheduler RunMode = "scheduler"
+)
+
+// ParseRunMode parses a run mode name, an empty name means RunModeAll.
+func ParseRunMode(name string) (RunMode, error) {
+	switch mode := RunMode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case "":
+		return RunModeAll, nil
+	case RunModeAll, RunModeWeb, RunModeWorker, RunModeScheduler:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown run mode: %s, must be one of web, worker, scheduler or all", name)
+	}
+}
+
+// Includes reports whether the subsystems of the given mode are started in this mode.
+func (m RunMode) Includes(mode RunMode) bool {
+	return m == RunModeAll || m == mode
+}
+
+// ModeAware is implemented by modules that are only started in some run modes.
+// Modules not implementing it are started in every run mode, inactive modules are still initialized, so they can be used as clients.
+type ModeAware interface {
+	ActiveIn(mode RunMode) bool
+}
+
+// isActiveIn reports whether the module is started in the given run mode.
+func isActiveIn(m Module, mode RunMode) bool {
+	if ma, ok := m.(ModeAware); ok {
+		return ma.ActiveIn(mode)
+	}
+	return true
+}
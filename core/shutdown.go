@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const defaultShutdownComponentTimeout = 10 * time.Second
+
+// Drainer is implemented by modules that have in-flight work to finish before they are stopped, such as queue consumers and cron jobs.
+// Drain is called after the http server stops accepting requests, and before any module is stopped.
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
+
+// Shutdown gracefully shuts down the container, the outcome of each step is logged:
+//  1. stop accepting http requests and wait for the active ones, if the http server runs in the current run mode
+//  2. call the shutdown hooks added by OnShutdown
+//  3. drain the modules implementing Drainer, in reverse initialization order
+//  4. flush the logs
+//  5. stop all modules in reverse initialization order
+//  6. close the resources created by Resource, in reverse creation order
+//
+// Each step is cancelled after componentTimeout, and all steps are cancelled when ctx is done.
+func (c *Container) Shutdown(ctx context.Context, componentTimeout time.Duration) error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.health.SetReady(false)
+	var errs []error
+	if c.fiber != nil && c.runMode.Includes(RunModeWeb) {
+		if err := c.shutdownStep(ctx, componentTimeout, "http", func(ctx context.Context) error {
+			return c.fiber.App().ShutdownWithContext(ctx)
+		}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, hook := range c.shutdownHooks {
+		if err := c.shutdownStep(ctx, componentTimeout, "hook", func(ctx context.Context) error {
+			return hook()
+		}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for i := len(c.initOrder) - 1; i >= 0; i-- {
+		d, ok := c.initOrder[i].(Drainer)
+		if !ok {
+			continue
+		}
+		if err := c.shutdownStep(ctx, componentTimeout, "drain "+c.initOrder[i].Name(), d.Drain); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	_ = c.shutdownStep(ctx, componentTimeout, "flush logs", func(ctx context.Context) error {
+		if zl := c.logger.GetZapLogger(); zl != nil {
+			// syncing stdout and stderr fails on some platforms, it is not a shutdown failure
+			_ = zl.Sync()
+		}
+		return nil
+	})
+	for i := len(c.initOrder) - 1; i >= 0; i-- {
+		m := c.initOrder[i]
+		if err := c.shutdownStep(ctx, componentTimeout, "stop "+m.Name(), func(ctx context.Context) error {
+			return m.Stop()
+		}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, c.closeResources(ctx, componentTimeout)...)
+	c.started = false
+	return errors.Join(errs...)
+}
+
+// shutdownStep runs a shutdown step and logs its outcome, the step is abandoned when the timeout is exceeded or ctx is done.
+func (c *Container) shutdownStep(ctx context.Context, timeout time.Duration, step string, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		timeout = defaultShutdownComponentTimeout
+	}
+	stepCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() {
+		result <- fn(stepCtx)
+	}()
+	var err error
+	select {
+	case err = <-result:
+	case <-stepCtx.Done():
+		err = stepCtx.Err()
+	}
+	if err != nil {
+		c.logger.Errorf("Shutdown step '%s' failed after %s: %v", step, time.Since(start), err)
+		return fmt.Errorf("shutdown step %s: %w", step, err)
+	}
+	c.logger.Infof("Shutdown step '%s' completed in %s", step, time.Since(start))
+	return nil
+}
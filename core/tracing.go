@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/utils/v2"
+	"go.oease.dev/goe/modules/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewTracingModule creates the built-in OpenTelemetry tracing module.
+// It is registered first, so that the other built-in modules are traced, and stopped last, so that their spans are exported.
+func NewTracingModule() Module {
+	return &tracingModule{}
+}
+
+type tracingModule struct {
+	tracing *tracing.Tracing
+}
+
+func (m *tracingModule) Name() string {
+	return ModuleTracing
+}
+
+func (m *tracingModule) DependsOn() []string {
+	return nil
+}
+
+func (m *tracingModule) Init(c *Container) error {
+	cfg := c.appConfig.Tracing
+	t, err := tracing.New(tracing.Config{
+		ServiceName:    c.appConfig.App.Name,
+		ServiceVersion: c.appConfig.App.Version,
+		Environment:    c.appConfig.App.Env,
+		Exporter:       cfg.Exporter,
+		Endpoint:       cfg.OTLPEndpoint,
+		Insecure:       cfg.OTLPInsecure,
+		SampleRatio:    cfg.SampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+	m.tracing = t
+	c.tracing = t
+	return nil
+}
+
+func (m *tracingModule) Start() error {
+	return nil
+}
+
+func (m *tracingModule) Stop() error {
+	if m.tracing == nil {
+		return nil
+	}
+	return m.tracing.Shutdown(context.Background())
+}
+
+// registerTracingMiddleware starts a span for every request, continuing the trace of the W3C traceparent header of the caller.
+// The span is set in the request context, handlers pass ctx.Context() to the WithContext methods of the modules to trace their calls.
+func registerTracingMiddleware(c *Container, app *fiber.App) {
+	if c.tracing == nil {
+		return
+	}
+	t := c.tracing
+	app.Use(func(ctx fiber.Ctx) error {
+		headers := propagation.HeaderCarrier{}
+		ctx.Request().Header.VisitAll(func(key, value []byte) {
+			headers.Set(string(key), string(value))
+		})
+		parent := t.Propagator().Extract(ctx.Context(), headers)
+		// the span outlives the request buffers, so the method and path are copied
+		method := utils.CopyString(ctx.Method())
+		// the span is named after the route once it is matched, the path is not used so that span names stay few
+		spanCtx, span := t.Tracer().Start(parent, "HTTP "+method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", method),
+				attribute.String("url.path", utils.CopyString(ctx.Path())),
+			),
+		)
+		defer span.End()
+		ctx.SetContext(spanCtx)
+		own := ctx.Route()
+		err := ctx.Next()
+		if route := ctx.Route(); route != own {
+			span.SetName(method + " " + route.Path)
+			span.SetAttributes(attribute.String("http.route", route.Path))
+		}
+		status := ctx.Response().StatusCode()
+		if err != nil {
+			status = ErrorStatus(err)
+			span.RecordError(err)
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, utils.StatusMessage(status))
+		}
+		return err
+	})
+}
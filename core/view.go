@@ -0,0 +1,95 @@
+package core
+
+import (
+	"bytes"
+	"github.com/gofiber/fiber/v3"
+	"go.oease.dev/goe/modules/view"
+	"io/fs"
+	"os"
+	"strconv"
+)
+
+// ErrorPageData is the data of the html error pages, the errors/<status> and errors/error views of the app are rendered with it.
+type ErrorPageData struct {
+	// Lang is the locale of the request
+	Lang  string
+	Title string
+	// Status is the http status, Code is its text for the built-in page
+	Status int
+	Code   string
+	// ErrorCode is the code of the error, see problem.Register
+	ErrorCode string
+	Message   string
+	// Details are the messages of all the failed rules of a validation error, or the message
+	Details []string
+	// HomeLink and Brand are read from HTTP_ERROR_HOME_LINK and HTTP_ERROR_BRAND
+	HomeLink string
+	Brand    string
+	BackText string
+	HomeText string
+}
+
+// newViewEngine creates the view engine of the fiber apps from the given views and the views of VIEW_DIR, which overwrite them.
+// It is nil if there are no views.
+func newViewEngine(cfg *GoeConfigView, views []fs.FS) (*view.Engine, error) {
+	fsyss := append([]fs.FS{}, views...)
+	if cfg.Dir != "" {
+		if _, err := os.Stat(cfg.Dir); err == nil {
+			fsyss = append(fsyss, os.DirFS(cfg.Dir))
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if len(fsyss) == 0 {
+		return nil, nil
+	}
+	engine := view.New(view.Config{
+		Extension: cfg.Extension,
+		Reload:    cfg.Reload,
+	}, fsyss...)
+	// the views are loaded by fiber too, but it only logs their errors
+	if err := engine.Load(); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// errorPage renders the html error page in the locale of the request, the errors/<status> or errors/error view of the app if any, else the built-in page.
+func (gf *GoeFiber) errorPage(ctx fiber.Ctx, status int, errorCode, message string, details []string) string {
+	lang := Locale(ctx)
+	if lang == "" {
+		lang = "en"
+	}
+	data := &ErrorPageData{
+		Lang:      lang,
+		Title:     T(ctx, "ERROR {code}", "code", status),
+		Status:    status,
+		Code:      strconv.Itoa(status),
+		ErrorCode: errorCode,
+		Message:   message,
+		Details:   details,
+		HomeLink:  gf.goeConfig.Http.ErrorHomeLink,
+		Brand:     gf.goeConfig.Http.ErrorBrand,
+		BackText:  T(ctx, "Go Back"),
+		HomeText:  T(ctx, "Go to Home Page"),
+	}
+	if gf.views != nil {
+		for _, name := range []string{"errors/" + data.Code, "errors/error"} {
+			if !gf.views.Has(name) {
+				continue
+			}
+			var buf bytes.Buffer
+			var layouts []string
+			if gf.goeConfig.View.Layout != "" {
+				layouts = append(layouts, gf.goeConfig.View.Layout)
+			}
+			if err := gf.views.Render(&buf, name, data, layouts...); err != nil {
+				// the built-in page is sent instead of an error page failing to render
+				gf.logger.Errorf("failed to render the error page %s: %v", name, err)
+				break
+			}
+			return buf.String()
+		}
+	}
+	return DefaultErrorPage(data)
+}
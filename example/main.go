@@ -26,12 +26,12 @@ func main() {
 		goe.UseLog().Error("test caller skip")
 		err := errors.New("errrrrrrr")
 		if err != nil {
-			return webresult.SystemBusy(err)
+			return webresult.SystemBusyContext(ctx, err)
 		}
 		return webresult.SendSucceed(ctx, "Hello, World!")
 	})
 
-	fileUploader := middlewares.NewFileMiddlewares()
+	fileUploader := middlewares.NewFileMiddlewares(goe.UseContainer())
 	goe.UseFiber().App().Post("/file/upload", fileUploader.HandleUpload())
 	goe.UseFiber().App().Get("/file/view/:id", fileUploader.HandleView())
 	goe.UseFiber().App().Delete("/file/delete/:id", fileUploader.HandleDelete())
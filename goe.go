@@ -1,310 +1,344 @@
 package goe
 
 import (
-	"context"
 	"errors"
-	"github.com/gofiber/fiber/v3"
+	"fmt"
 	"github.com/google/uuid"
 	"go.oease.dev/goe/contracts"
 	"go.oease.dev/goe/core"
-	"go.oease.dev/goe/modules/broker"
 	"go.oease.dev/goe/modules/config"
+	"go.oease.dev/goe/modules/i18n"
 	"go.oease.dev/goe/modules/log"
+	"go.oease.dev/goe/modules/metrics"
+	"go.oease.dev/goe/modules/tracing"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
-	"time"
 )
 
+// App is a goe application, it owns its configuration and module container.
 type App struct {
-	configs     *core.GoeConfig
-	container   *core.Container
-	running     bool
-	gracefulCtx context.Context
+	configs   *core.GoeConfig
+	container *core.Container
+	running   bool
 }
 
+// appInstance is the default App used by the package level helpers such as UseDB() and Run()
 var appInstance *App
 
-func NewApp() error {
-	configModule := config.New("./configs")
-	appEnv := configModule.GetOrDefaultString("APP_ENV", "dev")
-	var logModule *log.Log
-	if appEnv == "dev" {
-		logModule = log.New(log.LevelDev)
-	} else {
-		logModule = log.New(log.LevelProd)
-	}
-	app := &App{}
-	err := app.applyEnvConfig(configModule)
+// NewApp creates the default App and initializes its modules, it can be customized with options.
+// The default App is used by the package level helpers such as UseDB() and Run().
+func NewApp(opts ...Option) error {
+	app, err := New(opts...)
 	if err != nil {
 		return err
 	}
-	app.container = core.NewContainer(configModule, logModule, app.configs)
 	appInstance = app
+	return nil
+}
 
-	// Initialize MongoDB
-	if appInstance.configs.Features.MongoDBEnabled {
-		appInstance.container.InitMongo()
+// New creates an isolated App and initializes its modules, it can be customized with options.
+// Modules that are not explicitly enabled and miss their required infrastructure, such as Redis for cache and queue, are skipped.
+// Apps created by New do not share state, so multiple apps can run in one process.
+func New(opts ...Option) (*App, error) {
+	o := newAppOptions(opts...)
+	configModule, err := loadConfig(o)
+	if err != nil {
+		return nil, err
 	}
-
-	// Initialize Meilisearch
-	if appInstance.configs.Features.MeilisearchEnabled && appInstance.configs.Features.MongoDBEnabled {
-		appInstance.container.InitMeilisearch()
+	app := &App{}
+	if err = app.applyEnvConfig(configModule); err != nil {
+		return nil, err
 	}
+	logModule := o.logger
+	if logModule == nil {
+		if logModule, err = app.newLogger(o); err != nil {
+			return nil, err
+		}
+	} else if app.configs.Log.Level != "" {
+		// a logger given by WithLogger keeps its level unless LOG_LEVEL is set
+		if err := core.SetLogLevel(logModule, app.configs); err != nil {
+			return nil, err
+		}
+	}
+	app.container = core.NewContainer(configModule, logModule, app.configs)
 
-	// Init Queue
-	appInstance.container.InitQueue()
-
-	// Init Cron
-	appInstance.container.InitCron()
-
-	// Init Cache
-	appInstance.container.InitCache()
-
-	// Init Mailer
-	if appInstance.configs.Features.MailerEnabled {
-		appInstance.container.InitMailer()
+	for _, m := range app.builtinModules(o) {
+		if err := app.container.RegisterModule(m); err != nil {
+			return nil, err
+		}
 	}
+	for _, m := range o.customModules {
+		if err := app.container.RegisterModule(m); err != nil {
+			return nil, err
+		}
+	}
+	if err := app.container.InitModules(); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
 
-	// Init Fiber
-	appInstance.container.InitFiber()
+// LoadConfig loads the config the way New does with the same options and binds the built-in settings,
+// without creating the logger and the modules, so tools only reading the config do not connect to any backend.
+func LoadConfig(opts ...Option) (contracts.Config, *core.GoeConfig, error) {
+	configModule, err := loadConfig(newAppOptions(opts...))
+	if err != nil {
+		return nil, nil, err
+	}
+	app := &App{}
+	if err := app.applyEnvConfig(configModule); err != nil {
+		return nil, nil, err
+	}
+	return configModule, app.configs, nil
+}
 
-	// Init EMQX
-	appInstance.container.InitEMQX()
+// loadConfig returns the config given by WithConfig, or loads it from the config dir, the overrides, the secret providers and the secret files of the options.
+func loadConfig(o *appOptions) (contracts.Config, error) {
+	if o.config != nil {
+		return o.config, nil
+	}
+	// only the secret fields are read from the KEY_FILE files, the settings such as EMQX_TLS_KEY_FILE are paths themselves
+	loadOpts := []config.Option{
+		config.WithOverrides(o.configOverrides),
+		config.WithSecretFiles(config.SecretKeys(&core.GoeConfig{})...),
+		config.WithSecretFiles(o.secretFiles...),
+	}
+	for _, p := range o.secretProviders {
+		loadOpts = append(loadOpts, config.WithSecretProvider(p))
+	}
+	cfg, err := config.Load(o.configDir, loadOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
 
-	return nil
+// builtinModules returns the built-in modules enabled by the options and the feature configuration.
+// Modules provided by options such as WithDB replace the built-in ones and are always enabled.
+func (app *App) builtinModules(o *appOptions) []core.Module {
+	features := app.configs.Features
+	redisConfigured := app.configs.Redis.Host != "" && app.configs.Redis.Port != 0
+	modules := make([]core.Module, 0, 10)
+	add := func(name string, defaultValue bool, newModule func() core.Module) bool {
+		if m, ok := o.provided[name]; ok {
+			modules = append(modules, m)
+			return true
+		}
+		if !o.isEnabled(name, defaultValue) {
+			return false
+		}
+		modules = append(modules, newModule())
+		return true
+	}
+	// tracing is registered first, so that the modules initialized after it are traced
+	add(core.ModuleTracing, app.configs.Tracing.Enabled, core.NewTracingModule)
+	// the config module reads the config source before the modules using the values of the source are initialized
+	reload := app.configs.Config
+	add(core.ModuleConfig, reload.Watch || reload.Source != "", func() core.Module {
+		return core.NewConfigModule(reload.Source)
+	})
+	// the catalogs are loaded before the mailer and the fiber modules translating with them
+	add(core.ModuleI18n, true, func() core.Module {
+		return core.NewI18nModule(o.i18nCatalogs...)
+	})
+	mongoEnabled := add(core.ModuleMongoDB, features.MongoDBEnabled, core.NewMongoDBModule)
+	if _, provided := o.provided[core.ModuleMeilisearch]; provided || mongoEnabled {
+		add(core.ModuleMeilisearch, features.MeilisearchEnabled, core.NewMeilisearchModule)
+	}
+	if !add(core.ModuleQueue, redisConfigured || app.configs.Queue.Driver == core.QueueDriverMemory, core.NewQueueModule) {
+		if _, explicit := o.modules[core.ModuleQueue]; !explicit {
+			app.container.GetLogger().Warn("Redis is not configured, queue module is disabled")
+		}
+	}
+	add(core.ModuleCron, true, core.NewCronModule)
+	if !add(core.ModuleCache, redisConfigured || app.configs.Cache.Driver == core.CacheDriverMemory, core.NewCacheModule) {
+		if _, explicit := o.modules[core.ModuleCache]; !explicit {
+			app.container.GetLogger().Warn("Redis is not configured, cache module is disabled")
+		}
+	}
+	add(core.ModuleMailer, features.MailerEnabled, core.NewMailerModule)
+	add(core.ModuleFiber, true, func() core.Module {
+		return core.NewFiberModule(o.views...)
+	})
+	add(core.ModuleEMQX, features.EMQXBrokerEnabled, core.NewEMQXModule)
+	return modules
 }
 
 // applyEnvConfig applies environment configuration to the App instance.
-// It populates the configs field with values from the configModule parameter.
-// It returns an error if there is an issue applying the configuration.
-func (app *App) applyEnvConfig(configModule *config.Config) error {
-	app.configs = &core.GoeConfig{
-		App: &core.AppConfigs{
-			Name:    configModule.GetOrDefaultString("APP_NAME", "GoeApp"),
-			Version: configModule.GetOrDefaultString("APP_VERSION", "v1.0.0"),
-			Env:     configModule.GetOrDefaultString("APP_ENV", "dev"),
-		},
-		Features: &core.GoeConfigFeatures{
-			MongoDBEnabled:      configModule.GetOrDefaultBool("MONGODB_ENABLED", false),
-			MeilisearchEnabled:  configModule.GetOrDefaultBool("MEILISEARCH_ENABLED", false),
-			SearchDBSyncEnabled: configModule.GetOrDefaultBool("MEILISEARCH_DB_SYNC", false),
-			MailerEnabled:       configModule.GetOrDefaultBool("MAILER_ENABLED", false),
-			EMQXBrokerEnabled:   configModule.GetOrDefaultBool("EMQX_BROKER_ENABLED", false),
-		},
-		MongoDB: &core.GoeConfigMongodb{
-			URI: configModule.GetOrDefaultString("MONGODB_URI", ""),
-			DB:  configModule.GetOrDefaultString("MONGODB_DB", ""),
-		},
-		Redis: &core.GoeConfigRedis{
-			Host:     configModule.GetOrDefaultString("REDIS_HOST", ""),
-			Port:     configModule.GetOrDefaultInt("REDIS_PORT", 0),
-			Username: configModule.GetOrDefaultString("REDIS_USERNAME", ""),
-			Password: configModule.GetOrDefaultString("REDIS_PASSWORD", ""),
-		},
-		Meilisearch: &core.GoeConfigMeilisearch{
-			Endpoint: configModule.GetOrDefaultString("MEILISEARCH_ENDPOINT", ""),
-			ApiKey:   configModule.GetOrDefaultString("MEILISEARCH_API_KEY", ""),
-		},
-		Mailer: &core.GoeConfigMailer{
-			Provider:  configModule.GetOrDefaultString("MAILER_PROVIDER", "smtp"),
-			FromEmail: configModule.GetOrDefaultString("MAILER_FROM_EMAIL", ""),
-			FromName:  configModule.GetOrDefaultString("MAILER_FROM_NAME", ""),
-			SMTP: &core.GoeConfigSMTP{
-				Host:       configModule.GetOrDefaultString("SMTP_HOST", ""),
-				Port:       configModule.GetOrDefaultInt("SMTP_PORT", 0),
-				Username:   configModule.GetOrDefaultString("SMTP_USERNAME", ""),
-				Password:   configModule.GetOrDefaultString("SMTP_PASSWORD", ""),
-				Tls:        configModule.GetOrDefaultBool("SMTP_TLS", false),
-				LocalName:  configModule.GetOrDefaultString("SMTP_LOCAL_NAME", ""),
-				AuthMethod: configModule.GetOrDefaultString("SMTP_AUTH_METHOD", "PLAIN"),
-			},
-			Resend: &core.GoeConfigResend{
-				APIKey: configModule.GetOrDefaultString("RESEND_API_KEY", ""),
-			},
-			SES: &core.GoeConfigSES{
-				Region:          configModule.GetOrDefaultString("SES_REGION", ""),
-				AccessKeyID:     configModule.GetOrDefaultString("SES_ACCESS_KEY_ID", ""),
-				SecretAccessKey: configModule.GetOrDefaultString("SES_SECRET_ACCESS_KEY", ""),
-				Endpoint:        configModule.GetOrDefaultString("SES_ENDPOINT", ""),
-			},
-		},
-		Queue: &core.GoeConfigQueue{
-			ConcurrentWorkers:  configModule.GetOrDefaultInt("QUEUE_CONCURRENCY", 1),
-			FetchInterval:      configModule.GetOrDefaultInt("QUEUE_FETCH_INTERVAL", 1),
-			FetchLimit:         configModule.GetOrDefaultInt("QUEUE_FETCH_LIMIT", 0),
-			MaxConsumeDuration: configModule.GetOrDefaultInt("QUEUE_MAX_CONSUME_DURATION", 5),
-			DefaultRetries:     configModule.GetOrDefaultInt("QUEUE_DEFAULT_RETRIES", 3),
-		},
-		Http: &core.GoeConfigHttp{
-			Port:            configModule.GetOrDefaultString("HTTP_PORT", "3000"),
-			ServerHeader:    configModule.GetOrDefaultString("HTTP_SERVER_HEADER", "GoeAppServer/v1"),
-			BodyLimit:       configModule.GetOrDefaultInt("HTTP_BODY_LIMIT", fiber.DefaultBodyLimit),
-			Concurrency:     configModule.GetOrDefaultInt("HTTP_CONCURRENCY", fiber.DefaultConcurrency),
-			ProxyHeader:     configModule.GetOrDefaultString("HTTP_PROXY_HEADER", ""),
-			TrustProxyCheck: configModule.GetOrDefaultBool("HTTP_TRUSTED_PROXY_CHECK", false),
-			TrustProxies:    configModule.GetStringSlice("HTTP_TRUSTED_PROXIES"),
-			IPValidation:    configModule.GetOrDefaultBool("HTTP_IP_VALIDATION", false),
-			ReduceMemory:    configModule.GetOrDefaultBool("HTTP_REDUCE_MEMORY", false),
-		},
-		Session: &core.GoeConfigSession{
-			KeyLookup:  configModule.GetOrDefaultString("SESSION_LOOKUP", "cookie:goe_session_id"),
-			Expiration: configModule.GetOrDefaultInt("SESSION_EXPIRATION", 86400),
-		},
-		S3: &core.GoeConfigS3{
-			Endpoint:     configModule.GetOrDefaultString("S3_ENDPOINT", ""),
-			AccessKey:    configModule.GetOrDefaultString("S3_ACCESS_KEY", ""),
-			SecretKey:    configModule.GetOrDefaultString("S3_SECRET_KEY", ""),
-			Bucket:       configModule.GetOrDefaultString("S3_BUCKET_NAME", ""),
-			Region:       configModule.GetOrDefaultString("S3_REGION", ""),
-			BucketLookup: configModule.GetOrDefaultString("S3_BUCKET_LOOKUP", "path"),
-			UseSSL:       configModule.GetOrDefaultBool("S3_USE_SSL", false),
-			Token:        configModule.GetOrDefaultString("S3_TOKEN", ""),
-		},
-		OIDC: &core.GoeOIDCConfig{
-			AppId:     configModule.Get("OIDC_APP_ID"),
-			AppSecret: configModule.Get("OIDC_APP_SECRET"),
-			AppScopes: configModule.GetStringSlice("OIDC_APP_SCOPES"),
-			Issuer:    configModule.Get("OIDC_ISSUER"),
-		},
-		EMQX: &broker.EMQXConfig{
-			ID:       configModule.GetOrDefaultString("EMQX_HOST", uuid.NewString()),
-			Addr:     configModule.GetOrDefaultString("EMQX_ADDR", "tcp://localhost:1883"),
-			Username: configModule.GetOrDefaultString("EMQX_USERNAME", "admin"),
-			Password: configModule.GetOrDefaultString("EMQX_PASSWORD", "public"),
-			TLSConfig: &broker.TLSConfig{
-				Enable:   configModule.GetOrDefaultBool("EMQX_TLS_ENABLED", false),
-				CA:       configModule.GetOrDefaultString("EMQX_TLS_CA", "ca.pem"),
-				CertFile: configModule.GetOrDefaultString("EMQX_TLS_CERT_FILE", "client-crt.pem"),
-				KeyFile:  configModule.GetOrDefaultString("EMQX_TLS_KEY_FILE", "client-key.pem"),
-			},
-		},
+// It binds the configs field from configModule according to the tags of core.GoeConfig.
+// It returns an error listing all the missing and invalid values.
+func (app *App) applyEnvConfig(configModule contracts.Config) error {
+	app.configs = &core.GoeConfig{}
+	if err := configModule.Bind(app.configs); err != nil {
+		return err
+	}
+	if app.configs.EMQX.ID == "" {
+		app.configs.EMQX.ID = uuid.NewString()
 	}
 	return nil
 }
 
-func UseDB() contracts.MongoDB {
-	if appInstance == nil {
-		panic("must initialize App first, by calling NewApp() method")
-		return nil
+// newLogger creates the zap logger configured by the LOG_ settings, its logs carry the name, version and env of the app.
+func (app *App) newLogger(o *appOptions) (*log.Log, error) {
+	cfg := app.configs.Log
+	fields := map[string]any{
+		"app":     app.configs.App.Name,
+		"version": app.configs.App.Version,
+		"env":     app.configs.App.Env,
+	}
+	for k, v := range cfg.Fields {
+		fields[k] = v
+	}
+	var redaction *log.RedactConfig
+	if cfg.Redact {
+		redaction = &log.RedactConfig{Keys: cfg.RedactKeys}
+		if cfg.RedactPattern != "" {
+			redaction.Patterns = []string{cfg.RedactPattern}
+		}
 	}
-	return appInstance.container.GetMongo()
+	return log.NewWithConfig(log.Config{
+		Development: app.configs.App.Env == "dev",
+		Level:       cfg.Level,
+		Format:      cfg.Format,
+		Outputs:     cfg.Outputs,
+		File: log.FileConfig{
+			Path:       cfg.FilePath,
+			MaxSize:    cfg.FileMaxSize,
+			MaxAge:     cfg.FileMaxAge,
+			MaxBackups: cfg.FileMaxBackups,
+		},
+		Writers:            o.logWriters,
+		Sampling:           cfg.Sampling,
+		SamplingInitial:    cfg.SamplingInitial,
+		SamplingThereafter: cfg.SamplingThereafter,
+		Fields:             fields,
+		Redaction:          redaction,
+	})
 }
 
-func UseCron() contracts.CronJob {
+// Default returns the default App created by NewApp, or nil if NewApp has not been called.
+func Default() *App {
+	return appInstance
+}
+
+// mustDefault returns the default App, it panics if NewApp has not been called.
+func mustDefault() *App {
 	if appInstance == nil {
 		panic("must initialize App first, by calling NewApp() method")
-		return nil
 	}
-	return appInstance.container.GetCron()
+	return appInstance
+}
+
+func UseDB() contracts.MongoDB {
+	return mustDefault().DB()
+}
+
+func UseCron() contracts.CronJob {
+	return mustDefault().Cron()
 }
 
 func UseLog() contracts.Logger {
-	if appInstance == nil {
-		panic("must initialize App first, by calling NewApp() method")
-		return nil
+	return mustDefault().Log()
+}
+
+// LogFrom returns the logger of a request or of a queue message, adding its request id, method, path, user id and trace id to the logs.
+// ctx is a fiber.Ctx or a context.Context, such as ctx.Context() or the context of a NewQueueContext handler, the app logger is returned if it carries none.
+func LogFrom(ctx any) contracts.Logger {
+	if logger := core.LoggerFrom(ctx); logger != nil {
+		return logger
 	}
-	return appInstance.container.GetLogger()
+	return mustDefault().Log()
 }
 
 func UseCfg() contracts.Config {
-	if appInstance == nil {
-		panic("must initialize App first, by calling NewApp() method")
-		return nil
-	}
-	return appInstance.container.GetConfig()
+	return mustDefault().Cfg()
 }
 
 func UseMQ() contracts.Queue {
-	if appInstance == nil {
-		panic("must initialize App first, by calling NewApp() method")
-		return nil
-	}
-	return appInstance.container.GetQueue()
+	return mustDefault().MQ()
 }
 
 func UseCache() contracts.Cache {
-	if appInstance == nil {
-		panic("must initialize App first, by calling NewApp() method")
-		return nil
-	}
-	return appInstance.container.GetCache()
+	return mustDefault().Cache()
 }
 
 func UseSearch() contracts.Meilisearch {
-	if appInstance == nil {
-		panic("must initialize App first, by calling NewApp() method")
-		return nil
-	}
-	return appInstance.container.GetMeilisearch()
+	return mustDefault().Search()
 }
 
 func UseMailer() contracts.Mailer {
+	return mustDefault().Mailer()
+}
+
+func UseFiber() contracts.GoeFiber {
+	return mustDefault().Fiber()
+}
+
+func UseEMQX() contracts.EMQX {
+	return mustDefault().EMQX()
+}
+
+// UseMetrics returns the prometheus metrics of the default App, it is nil unless METRICS_ENABLED is true.
+func UseMetrics() *metrics.Metrics {
+	return mustDefault().Metrics()
+}
+
+// UseTracing returns the tracing of the default App, it is nil unless TRACING_ENABLED is true.
+func UseTracing() *tracing.Tracing {
+	return mustDefault().Tracing()
+}
+
+// UseI18n returns the message catalogs of the default App, it is nil if the i18n module is disabled.
+func UseI18n() *i18n.Bundle {
+	return mustDefault().I18n()
+}
+
+// UseContainer returns the container of the default App, it can be passed to middleware constructors.
+func UseContainer() *core.Container {
+	return mustDefault().Container()
+}
+
+// RegisterModule registers an application module to the default App container.
+// The module is initialized immediately, its dependencies must be registered before it, and it is started when the app starts running.
+func RegisterModule(m core.Module) error {
 	if appInstance == nil {
-		panic("must initialize App first, by calling NewApp() method")
-		return nil
+		return errors.New("must initialize App first, by calling NewApp() method")
 	}
-	return appInstance.container.GetMailer()
+	return appInstance.RegisterModule(m)
 }
 
-func UseFiber() contracts.GoeFiber {
+// Run runs the default App in the run mode configured by GOE_RUN_MODE, it blocks until the app is shut down by a signal.
+func Run() error {
 	if appInstance == nil {
-		panic("must initialize App first, by calling NewApp() method")
-		return nil
+		return errors.New("must initialize App first, by calling NewApp() method")
 	}
-	return appInstance.container.GetFiber()
+	return appInstance.Run()
 }
 
-func UseEMQX() contracts.EMQX {
+// RunWorker runs the queue consumers of the default App only, without opening an http port.
+func RunWorker() error {
 	if appInstance == nil {
-		panic("must initialize App first, by calling NewApp() method")
-		return nil
+		return errors.New("must initialize App first, by calling NewApp() method")
 	}
-	return appInstance.container.GetEMQX()
+	return appInstance.RunWorker()
 }
 
-func Run() error {
+// RunScheduler runs the cron scheduler of the default App only, without opening an http port.
+func RunScheduler() error {
 	if appInstance == nil {
 		return errors.New("must initialize App first, by calling NewApp() method")
 	}
-	if appInstance.running {
-		return errors.New("app is already running")
-	}
-	go func() {
-		err := appInstance.container.GetFiber().App().Listen(":"+appInstance.configs.Http.Port, fiber.ListenConfig{
-			DisableStartupMessage: true,
-			EnablePrefork:         false,
-			EnablePrintRoutes:     false,
-			OnShutdownError: func(err error) {
-				appInstance.container.GetLogger().Error("Shutdown error: ", err)
-			},
-		})
-		if err != nil {
-			appInstance.running = false
-			appInstance.container.GetLogger().Panic("Server error: ", err)
-		}
-	}()
-	appInstance.running = true
-	newShutdownHook().Close(func() {
-		appInstance.running = false
-		_ = appInstance.container.GetFiber().App().ShutdownWithTimeout(5 * time.Second)
-		//if err != nil {
-		//	appInstance.container.GetLogger().Error("Server shutdown error: ", err)
-		//}
-		appInstance.container.GetLogger().Info("Server has shutdown successfully!")
-	})
-	return nil
+	return appInstance.RunScheduler()
 }
 
+// AddShutdownHook adds shutdown hooks to the default App.
 func AddShutdownHook(hookHandlers ...func() error) error {
 	if appInstance == nil {
 		return errors.New("must initialize App first, by calling NewApp() method")
 	}
-	if appInstance.running {
-		return errors.New("app is already running, shutdown hook must be added before calling Run()")
-	}
-	appInstance.container.GetFiber().App().Hooks().OnShutdown(hookHandlers...)
-	return nil
+	return appInstance.AddShutdownHook(hookHandlers...)
 }
 
 // thanks to https://github.com/xinliangnote/go-gin-api for the shutdown hook implementation
@@ -322,13 +356,37 @@ type sdhook struct {
 	ctx chan os.Signal
 }
 
-// NewHook create a Hook instance
-func newShutdownHook() hook {
+// NewHook create a Hook instance, default with signals of SIGINT and SIGTERM
+func newShutdownHook(signals ...syscall.Signal) hook {
 	hook := &sdhook{
 		ctx: make(chan os.Signal, 1),
 	}
+	if len(signals) == 0 {
+		signals = []syscall.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	return hook.WithSignals(signals...)
+}
 
-	return hook.WithSignals(syscall.SIGINT, syscall.SIGTERM)
+// shutdownSignals parses the signal names of SHUTDOWN_SIGNALS, such as "SIGINT,SIGTERM" or "INT,TERM,HUP"
+func shutdownSignals(names []string) ([]syscall.Signal, error) {
+	signals := make([]syscall.Signal, 0, len(names))
+	for _, name := range names {
+		switch strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "SIG") {
+		case "":
+			continue
+		case "INT":
+			signals = append(signals, syscall.SIGINT)
+		case "TERM":
+			signals = append(signals, syscall.SIGTERM)
+		case "QUIT":
+			signals = append(signals, syscall.SIGQUIT)
+		case "HUP":
+			signals = append(signals, syscall.SIGHUP)
+		default:
+			return nil, fmt.Errorf("unsupported shutdown signal: %s", name)
+		}
+	}
+	return signals, nil
 }
 func (h *sdhook) WithSignals(signals ...syscall.Signal) hook {
 	for _, s := range signals {
@@ -0,0 +1,114 @@
+package goetest
+
+import (
+	"errors"
+	"github.com/goccy/go-json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FakeCache is an in-memory contracts.Cache, it records the expiration each key was set with, so tests can assert on it.
+type FakeCache struct {
+	mu      sync.RWMutex
+	entries map[string]*fakeCacheEntry
+}
+
+type fakeCacheEntry struct {
+	value     []byte
+	expire    time.Duration
+	expiresAt time.Time // zero means never expires
+}
+
+// NewFakeCache creates an empty in-memory cache.
+func NewFakeCache() *FakeCache {
+	return &FakeCache{entries: make(map[string]*fakeCacheEntry)}
+}
+
+func (f *FakeCache) Get(key string) []byte {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	entry, ok := f.entries[key]
+	if !ok || (!entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt)) {
+		return nil
+	}
+	return append([]byte(nil), entry.value...)
+}
+
+func (f *FakeCache) GetBind(key string, bindPtr any) error {
+	res := f.Get(key)
+	if res == nil {
+		return nil
+	}
+	return json.Unmarshal(res, bindPtr)
+}
+
+func (f *FakeCache) Set(key string, value []byte, expire time.Duration) error {
+	if key == "" || len(value) == 0 {
+		return nil
+	}
+	entry := &fakeCacheEntry{value: append([]byte(nil), value...), expire: expire}
+	if expire > 0 {
+		entry.expiresAt = time.Now().Add(expire)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries[key] = entry
+	return nil
+}
+
+func (f *FakeCache) SetBind(key string, bindPtr any, expire time.Duration) error {
+	if bindPtr == nil {
+		return errors.New("bindPtr is nil")
+	}
+	b, err := json.Marshal(bindPtr)
+	if err != nil {
+		return err
+	}
+	return f.Set(key, b, expire)
+}
+
+func (f *FakeCache) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.entries, key)
+	return nil
+}
+
+// Has reports whether the key is cached and not expired.
+func (f *FakeCache) Has(key string) bool {
+	return f.Get(key) != nil
+}
+
+// Keys returns the cached keys which are not expired, sorted.
+func (f *FakeCache) Keys() []string {
+	now := time.Now()
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	keys := make([]string, 0, len(f.entries))
+	for key, entry := range f.entries {
+		if entry.expiresAt.IsZero() || now.Before(entry.expiresAt) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Expiration returns the expiration the key was set with, and whether the key exists.
+func (f *FakeCache) Expiration(key string) (time.Duration, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	entry, ok := f.entries[key]
+	if !ok {
+		return 0, false
+	}
+	return entry.expire, true
+}
+
+// Reset removes all cached keys.
+func (f *FakeCache) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries = make(map[string]*fakeCacheEntry)
+}
@@ -0,0 +1,267 @@
+package goetest
+
+import (
+	"bytes"
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/session"
+	"github.com/valyala/fasthttp"
+	"go.oease.dev/goe/middlewares"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client sends requests to the fiber app of a Harness without opening a port, it keeps the cookies set by the responses like a browser.
+type Client struct {
+	harness *Harness
+	headers http.Header
+	cookies map[string]*http.Cookie
+	timeout time.Duration
+	// sessionID is the id of the session created by WithSession, sent the way the session KeyLookup config expects
+	sessionID string
+}
+
+// Response is the response of a request sent by Client, the body is read already.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Cookies    []*http.Cookie
+	Body       []byte
+}
+
+// String returns the body as a string.
+func (r *Response) String() string {
+	return string(r.Body)
+}
+
+// JSON unmarshals the json body into v.
+func (r *Response) JSON(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+func newClient(h *Harness) *Client {
+	return &Client{
+		harness: h,
+		headers: make(http.Header),
+		cookies: make(map[string]*http.Cookie),
+		timeout: 10 * time.Second,
+	}
+}
+
+// WithHeader sets a header sent with every request of the client.
+func (c *Client) WithHeader(key, value string) *Client {
+	c.headers.Set(key, value)
+	return c
+}
+
+// WithTimeout sets the max duration of a request, default is 10 seconds, 0 disables the timeout.
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	c.timeout = timeout
+	return c
+}
+
+// WithSession stores the values in the session of the client, the session is created if the client has none.
+// The handlers read the values through middlewares.UseSession, the session middleware must be registered to the app.
+func (c *Client) WithSession(values map[string]any) *Client {
+	c.harness.t.Helper()
+	c.updateSession(func(sess *session.Session) {
+		for k, v := range values {
+			sess.Set(k, v)
+		}
+	})
+	return c
+}
+
+// LoginAs simulates a user logged in by the OIDC middleware, the user is stored in the session as json,
+// so middlewares.IsLoggedIn reports true and the login info middleware returns the user.
+func (c *Client) LoginAs(user any) *Client {
+	c.harness.t.Helper()
+	userInfo, err := json.Marshal(user)
+	if err != nil {
+		c.harness.t.Fatalf("goetest: failed to marshal user: %v", err)
+	}
+	c.updateSession(func(sess *session.Session) {
+		sess.Set("sid", sess.ID())
+		sess.Set("user", userInfo)
+	})
+	return c
+}
+
+// Logout destroys the session of the client.
+func (c *Client) Logout() *Client {
+	c.harness.t.Helper()
+	if c.sessionID == "" {
+		return c
+	}
+	store := middlewares.GetSessionStore(c.harness.App.Container())
+	if err := store.Delete(c.sessionID); err != nil {
+		c.harness.t.Fatalf("goetest: failed to delete session: %v", err)
+	}
+	if source, name := c.sessionLookup(); source == "cookie" {
+		delete(c.cookies, name)
+	}
+	c.sessionID = ""
+	return c
+}
+
+func (c *Client) Get(path string) *Response {
+	return c.Request(http.MethodGet, path, nil)
+}
+
+func (c *Client) Delete(path string) *Response {
+	return c.Request(http.MethodDelete, path, nil)
+}
+
+func (c *Client) Post(path string, body any) *Response {
+	return c.Request(http.MethodPost, path, body)
+}
+
+func (c *Client) Put(path string, body any) *Response {
+	return c.Request(http.MethodPut, path, body)
+}
+
+func (c *Client) Patch(path string, body any) *Response {
+	return c.Request(http.MethodPatch, path, body)
+}
+
+// Request sends a request, a body of []byte, string or io.Reader is sent as is, other bodies are sent as json.
+func (c *Client) Request(method, path string, body any) *Response {
+	c.harness.t.Helper()
+	var reader io.Reader
+	isJSON := false
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(b)
+	case string:
+		reader = strings.NewReader(b)
+	case io.Reader:
+		reader = b
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.harness.t.Fatalf("goetest: failed to marshal request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+		isJSON = true
+	}
+	req, err := http.NewRequest(method, path, reader)
+	if err != nil {
+		c.harness.t.Fatalf("goetest: failed to create request: %v", err)
+	}
+	if isJSON {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	return c.Do(req)
+}
+
+// Do sends the request with the headers, cookies and session of the client.
+func (c *Client) Do(req *http.Request) *Response {
+	c.harness.t.Helper()
+	fb := c.harness.App.Fiber()
+	if fb == nil {
+		c.harness.t.Fatal("goetest: fiber module is disabled")
+	}
+	for k, values := range c.headers {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	for _, cookie := range c.cookies {
+		req.AddCookie(cookie)
+	}
+	if c.sessionID != "" {
+		switch source, name := c.sessionLookup(); source {
+		case "header":
+			req.Header.Set(name, c.sessionID)
+		case "query":
+			q := req.URL.Query()
+			q.Set(name, c.sessionID)
+			req.URL.RawQuery = q.Encode()
+		}
+	}
+	resp, err := fb.App().Test(req, fiber.TestConfig{Timeout: c.timeout, FailOnTimeout: true})
+	if err != nil {
+		c.harness.t.Fatalf("goetest: request %s %s failed: %v", req.Method, req.URL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.harness.t.Fatalf("goetest: failed to read response body: %v", err)
+	}
+	c.keepCookies(resp.Cookies())
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Cookies:    resp.Cookies(),
+		Body:       body,
+	}
+}
+
+// keepCookies stores the cookies set by a response, and removes the expired ones.
+func (c *Client) keepCookies(cookies []*http.Cookie) {
+	source, name := c.sessionLookup()
+	for _, cookie := range cookies {
+		expired := cookie.MaxAge < 0 || (!cookie.Expires.IsZero() && cookie.Expires.Before(time.Now())) || cookie.Value == ""
+		if expired {
+			delete(c.cookies, cookie.Name)
+		} else {
+			c.cookies[cookie.Name] = &http.Cookie{Name: cookie.Name, Value: cookie.Value}
+		}
+		if source == "cookie" && cookie.Name == name {
+			if expired {
+				c.sessionID = ""
+			} else {
+				c.sessionID = cookie.Value
+			}
+		}
+	}
+}
+
+// updateSession loads the session of the client, or creates one, and saves it after fn changes it.
+func (c *Client) updateSession(fn func(sess *session.Session)) {
+	c.harness.t.Helper()
+	container := c.harness.App.Container()
+	store := middlewares.GetSessionStore(container)
+	var sess *session.Session
+	var err error
+	if c.sessionID != "" {
+		// the session may be expired or destroyed by a handler, a new one is created then
+		if sess, err = store.GetByID(c.sessionID); err != nil {
+			sess = nil
+		}
+	}
+	if sess == nil {
+		fb := container.GetFiber()
+		if fb == nil {
+			c.harness.t.Fatal("goetest: fiber module is disabled")
+		}
+		// a new session is created from an empty request, as the session store only creates sessions for requests
+		ctx := fb.App().AcquireCtx(&fasthttp.RequestCtx{})
+		defer fb.App().ReleaseCtx(ctx)
+		sess, err = store.Get(ctx)
+	}
+	if err != nil {
+		c.harness.t.Fatalf("goetest: failed to get session: %v", err)
+	}
+	defer sess.Release()
+	fn(sess)
+	if err := sess.Save(); err != nil {
+		c.harness.t.Fatalf("goetest: failed to save session: %v", err)
+	}
+	c.sessionID = sess.ID()
+	if source, name := c.sessionLookup(); source == "cookie" {
+		c.cookies[name] = &http.Cookie{Name: name, Value: c.sessionID}
+	}
+}
+
+// sessionLookup returns the source and the name of the session id, parsed from the session KeyLookup config, e.g. cookie:goe_session_id.
+func (c *Client) sessionLookup() (source string, name string) {
+	source, name, found := strings.Cut(c.harness.App.Config().Session.KeyLookup, ":")
+	if !found {
+		return "cookie", "session_id"
+	}
+	return source, name
+}
@@ -0,0 +1,156 @@
+package goetest
+
+import (
+	"context"
+	"errors"
+	"github.com/eclipse/paho.mqtt.golang"
+	"go.oease.dev/goe/contracts"
+	"strings"
+	"sync"
+)
+
+// PublishedMessage is a message published through a FakeEMQX.
+type PublishedMessage struct {
+	Topic    string
+	QoS      byte
+	Retained bool
+	Payload  any
+}
+
+// FakeEMQX is a contracts.EMQX which records the published messages, and delivers messages to the subscriptions with Deliver.
+type FakeEMQX struct {
+	mu            sync.Mutex
+	published     []*PublishedMessage
+	subscriptions map[string]func(mqtt.Client, mqtt.Message)
+	closed        bool
+}
+
+// NewFakeEMQX creates a connected fake broker.
+func NewFakeEMQX() *FakeEMQX {
+	return &FakeEMQX{subscriptions: make(map[string]func(mqtt.Client, mqtt.Message))}
+}
+
+func (f *FakeEMQX) Publish(topic string, qos byte, retained bool, payload any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return errors.New("not connected")
+	}
+	f.published = append(f.published, &PublishedMessage{Topic: topic, QoS: qos, Retained: retained, Payload: payload})
+	return nil
+}
+
+func (f *FakeEMQX) Subscribe(module string, qos byte, callback func(mqtt.Client, mqtt.Message)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subscriptions[module] = callback
+	return nil
+}
+
+func (f *FakeEMQX) Unsubscribe(module string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subscriptions, module)
+	return nil
+}
+
+func (f *FakeEMQX) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+}
+
+func (f *FakeEMQX) IsConnected() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return !f.closed
+}
+
+// WithContext returns the fake itself, the context is ignored.
+func (f *FakeEMQX) WithContext(ctx context.Context) contracts.EMQX {
+	return f
+}
+
+// Published returns the messages published to the topic, in publish order, an empty topic returns all messages.
+func (f *FakeEMQX) Published(topic string) []PublishedMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	messages := make([]PublishedMessage, 0)
+	for _, msg := range f.published {
+		if topic == "" || msg.Topic == topic {
+			messages = append(messages, *msg)
+		}
+	}
+	return messages
+}
+
+// Deliver calls the callbacks of the subscriptions matching the topic with the payload, wildcards + and # are supported.
+// It returns the number of called callbacks, the mqtt.Client passed to the callbacks is nil.
+func (f *FakeEMQX) Deliver(topic string, payload []byte) int {
+	f.mu.Lock()
+	var callbacks []func(mqtt.Client, mqtt.Message)
+	for filter, cb := range f.subscriptions {
+		if topicMatches(filter, topic) && cb != nil {
+			callbacks = append(callbacks, cb)
+		}
+	}
+	f.mu.Unlock()
+	for _, cb := range callbacks {
+		cb(nil, &fakeMessage{topic: topic, payload: payload})
+	}
+	return len(callbacks)
+}
+
+// Reset removes all recorded messages, the subscriptions are kept.
+func (f *FakeEMQX) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.published = nil
+}
+
+// topicMatches reports whether the topic matches the subscription filter.
+func topicMatches(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) || (level != "+" && level != topicLevels[i]) {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+// fakeMessage implements mqtt.Message for the messages delivered by FakeEMQX
+type fakeMessage struct {
+	topic   string
+	payload []byte
+}
+
+func (m *fakeMessage) Duplicate() bool {
+	return false
+}
+
+func (m *fakeMessage) Qos() byte {
+	return 0
+}
+
+func (m *fakeMessage) Retained() bool {
+	return false
+}
+
+func (m *fakeMessage) Topic() string {
+	return m.topic
+}
+
+func (m *fakeMessage) MessageID() uint16 {
+	return 0
+}
+
+func (m *fakeMessage) Payload() []byte {
+	return m.payload
+}
+
+func (m *fakeMessage) Ack() {}
@@ -0,0 +1,107 @@
+// Package goetest boots goe apps in tests, with in-memory fakes in place of MongoDB, Cache, Queue, Mailer, Meilisearch and EMQX,
+// so tests run without any infrastructure and can assert on what the app wrote.
+package goetest
+
+import (
+	"go.oease.dev/goe"
+	"go.oease.dev/goe/modules/config"
+	"go.oease.dev/goe/modules/log"
+	"testing"
+)
+
+// Harness is a goe App running on fakes, the fakes are exposed to assert on what was written.
+type Harness struct {
+	App    *goe.App
+	DB     *FakeMongoDB
+	Cache  *FakeCache
+	Queue  *FakeQueue
+	Mailer *FakeMailer
+	Search *FakeSearch
+	EMQX   *FakeEMQX
+
+	t testing.TB
+}
+
+// Option configures the Harness created by New.
+type Option func(o *options)
+
+type options struct {
+	env        map[string]string
+	appOptions []goe.Option
+}
+
+// WithEnv sets a config value of the app, the app does not read env files or environment variables.
+func WithEnv(key, value string) Option {
+	return func(o *options) {
+		o.env[key] = value
+	}
+}
+
+// WithAppOptions passes options to goe.New, they are applied after the fakes, so they can replace them, e.g. goe.WithLogger.
+func WithAppOptions(opts ...goe.Option) Option {
+	return func(o *options) {
+		o.appOptions = append(o.appOptions, opts...)
+	}
+}
+
+// New creates an isolated App running on fakes, logs are discarded unless a logger is given by WithAppOptions.
+// The app is not started, its http handlers are called through Client, and it is closed when the test finishes.
+func New(t testing.TB, opts ...Option) *Harness {
+	t.Helper()
+	o := &options{
+		env: map[string]string{
+			"APP_NAME":     "GoeTestApp",
+			"APP_ENV":      "test",
+			"CACHE_DRIVER": "memory",
+			"QUEUE_DRIVER": "memory",
+		},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	h := &Harness{
+		DB:     NewFakeMongoDB(),
+		Cache:  NewFakeCache(),
+		Queue:  NewFakeQueue(),
+		Mailer: NewFakeMailer("GoeTestApp", "noreply@example.com"),
+		Search: NewFakeSearch(),
+		EMQX:   NewFakeEMQX(),
+		t:      t,
+	}
+	appOpts := []goe.Option{
+		goe.WithConfig(config.NewFromMap(o.env)),
+		goe.WithLogger(log.NewNop()),
+		goe.WithDB(h.DB),
+		goe.WithSearch(h.Search),
+		goe.WithQueue(h.Queue),
+		goe.WithCache(h.Cache),
+		goe.WithMailer(h.Mailer),
+		goe.WithEMQX(h.EMQX),
+	}
+	app, err := goe.New(append(appOpts, o.appOptions...)...)
+	if err != nil {
+		t.Fatalf("goetest: failed to create app: %v", err)
+	}
+	h.App = app
+	t.Cleanup(func() {
+		_ = app.Container().Close()
+	})
+	return h
+}
+
+// Client creates an http client calling the fiber app of the harness, each client has its own cookies and session.
+func (h *Harness) Client() *Client {
+	return newClient(h)
+}
+
+// Reset removes all data recorded by the fakes, so the harness can be reused by sub tests.
+func (h *Harness) Reset() {
+	h.DB.Reset()
+	h.Cache.Reset()
+	h.Queue.Reset()
+	h.Mailer.Reset()
+	h.Search.Reset()
+	h.EMQX.Reset()
+}
@@ -0,0 +1,127 @@
+package goetest
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.oease.dev/goe/middlewares"
+	"net/http"
+	"net/mail"
+	"testing"
+	"time"
+)
+
+const queueWelcome = "welcome"
+
+func newSignupHarness(t *testing.T) *Harness {
+	h := New(t, WithEnv("SIGNUP_ROLE", "member"))
+	app := h.App
+	require.NoError(t, app.MQ().NewQueue(queueWelcome, func(payload string) bool {
+		return app.Mailer().DefaultSender().
+			To(&[]*mail.Address{{Address: payload}}).
+			Subject("Welcome").
+			Send() == nil
+	}))
+	app.Fiber().App().Post("/signup", func(ctx fiber.Ctx) error {
+		u := &user{Role: app.Cfg().Get("SIGNUP_ROLE")}
+		if err := ctx.Bind().JSON(u); err != nil {
+			return err
+		}
+		if _, err := app.DB().Insert(u); err != nil {
+			return err
+		}
+		if err := app.Cache().Set("user:"+u.Name, []byte(u.GetId()), time.Hour); err != nil {
+			return err
+		}
+		if err := app.MQ().PushRaw(queueWelcome, u.Name+"@example.com"); err != nil {
+			return err
+		}
+		if err := app.EMQX().Publish("users/"+u.Name+"/created", 1, false, u.GetId()); err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"id": u.GetId()})
+	})
+	return h
+}
+
+func TestHarness(t *testing.T) {
+	h := newSignupHarness(t)
+	resp := h.Client().Post("/signup", map[string]any{"name": "ann", "age": 31})
+	require.Equal(t, http.StatusCreated, resp.StatusCode, resp.String())
+	var created struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, resp.JSON(&created))
+
+	var stored user
+	ok, err := h.DB.FindOne(&user{}, bson.M{"name": "ann"}, &stored)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, created.ID, stored.GetId())
+	assert.Equal(t, "member", stored.Role)
+
+	assert.Equal(t, []byte(created.ID), h.Cache.Get("user:ann"))
+	expire, ok := h.Cache.Expiration("user:ann")
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour, expire)
+	published := h.EMQX.Published("users/ann/created")
+	require.Len(t, published, 1)
+	assert.Equal(t, created.ID, published[0].Payload)
+
+	// the messages are delivered by the test, the handler sends the email through the fake mailer
+	assert.Equal(t, []string{"ann@example.com"}, h.Queue.Payloads(queueWelcome))
+	assert.Empty(t, h.Mailer.Sent())
+	acked, err := h.Queue.Deliver(queueWelcome)
+	require.NoError(t, err)
+	assert.Equal(t, 1, acked)
+	sent := h.Mailer.SentTo("ann@example.com")
+	require.Len(t, sent, 1)
+	assert.Equal(t, "Welcome", sent[0].Subject)
+	assert.True(t, sent[0].Queued)
+
+	h.Reset()
+	assert.Empty(t, h.DB.Documents("users"))
+	assert.Empty(t, h.Cache.Keys())
+	assert.Empty(t, h.Queue.Messages(queueWelcome))
+	assert.Empty(t, h.Mailer.Sent())
+	assert.Empty(t, h.EMQX.Published(""))
+	// the declared queues are kept
+	require.NoError(t, h.App.MQ().PushRaw(queueWelcome, "bob@example.com"))
+}
+
+func TestHarnessesAreIsolated(t *testing.T) {
+	a, b := newSignupHarness(t), newSignupHarness(t)
+	require.Equal(t, http.StatusCreated, a.Client().Post("/signup", map[string]any{"name": "ann"}).StatusCode)
+	assert.Len(t, a.DB.Documents("users"), 1)
+	assert.Empty(t, b.DB.Documents("users"))
+	assert.Empty(t, b.Queue.Payloads(queueWelcome))
+}
+
+func TestClientSession(t *testing.T) {
+	h := New(t)
+	app := h.App.Fiber().App()
+	app.Use(middlewares.NewSessionMiddleware(h.App.Container()))
+	app.Get("/me", func(ctx fiber.Ctx) error {
+		if !middlewares.IsLoggedIn(ctx) {
+			return fiber.ErrUnauthorized
+		}
+		return ctx.SendString(middlewares.SessionUserID(ctx))
+	})
+	app.Get("/cart", func(ctx fiber.Ctx) error {
+		items, _ := middlewares.UseSession(ctx).Get("items").(string)
+		return ctx.SendString(items)
+	})
+
+	client := h.Client().LoginAs(map[string]any{"id": "42", "name": "ann"})
+	resp := client.Get("/me")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "42", resp.String())
+	assert.Equal(t, "book", client.WithSession(map[string]any{"items": "book"}).Get("/cart").String())
+	// the session survives the requests, the user is still logged in
+	assert.Equal(t, "42", client.Get("/me").String())
+
+	// each client has its own session
+	assert.Equal(t, http.StatusUnauthorized, h.Client().Get("/me").StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, client.Logout().Get("/me").StatusCode)
+}
@@ -0,0 +1,202 @@
+package goetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go.oease.dev/goe/contracts"
+	"go.oease.dev/goe/modules/i18n"
+	htmltemplate "html/template"
+	"net/mail"
+	"sync"
+	texttemplate "text/template"
+)
+
+// SentEmail is an email sent through a FakeMailer.
+type SentEmail struct {
+	Provider contracts.MailProvider
+	From     *mail.Address
+	To       []*mail.Address
+	Cc       []*mail.Address
+	Bcc      []*mail.Address
+	Subject  string
+	HTML     string
+	Text     string
+	Headers  map[string]string
+	// Attachments maps the attachment names to their file paths, the files are not read
+	Attachments map[string]string
+	// Locale is the locale set on the sender, the fake mailer does not translate
+	Locale string
+	// Queued reports whether the email was sent through the queue, which is the default of EmailSender.Send
+	Queued bool
+}
+
+// FakeMailer is a contracts.Mailer which records the sent emails instead of delivering them.
+type FakeMailer struct {
+	mu              sync.Mutex
+	from            *mail.Address
+	defaultProvider contracts.MailProvider
+	providers       map[contracts.MailProvider]contracts.EmailProviderFactory
+	sent            []*SentEmail
+}
+
+// NewFakeMailer creates a fake mailer sending from the given address, with SMTP as the default provider.
+func NewFakeMailer(fromName, fromEmail string) *FakeMailer {
+	return &FakeMailer{
+		from:            &mail.Address{Name: fromName, Address: fromEmail},
+		defaultProvider: contracts.ProviderSMTP,
+		providers:       make(map[contracts.MailProvider]contracts.EmailProviderFactory),
+	}
+}
+
+func (f *FakeMailer) GetSender(provider contracts.MailProvider) contracts.EmailSender {
+	return &fakeEmailSender{
+		mailer: f,
+		email: &SentEmail{
+			Provider: provider,
+			From:     &mail.Address{Name: f.from.Name, Address: f.from.Address},
+		},
+	}
+}
+
+func (f *FakeMailer) DefaultSender() contracts.EmailSender {
+	return f.GetSender(f.defaultProvider)
+}
+
+// RegisterProvider records the provider factory, the factory is never called.
+func (f *FakeMailer) RegisterProvider(provider contracts.MailProvider, factory contracts.EmailProviderFactory) error {
+	if factory == nil {
+		return errors.New("provider factory is nil")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.providers[provider] = factory
+	return nil
+}
+
+// Sent returns the sent emails, in send order.
+func (f *FakeMailer) Sent() []*SentEmail {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*SentEmail(nil), f.sent...)
+}
+
+// SentTo returns the sent emails having the address as a To, Cc or Bcc recipient.
+func (f *FakeMailer) SentTo(address string) []*SentEmail {
+	var res []*SentEmail
+	for _, email := range f.Sent() {
+		if hasAddress(email.To, address) || hasAddress(email.Cc, address) || hasAddress(email.Bcc, address) {
+			res = append(res, email)
+		}
+	}
+	return res
+}
+
+// Reset removes all recorded emails.
+func (f *FakeMailer) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = nil
+}
+
+func hasAddress(addresses []*mail.Address, address string) bool {
+	for _, a := range addresses {
+		if a != nil && a.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// fakeEmailSender builds the SentEmail with the same fluent api as the built-in sender
+type fakeEmailSender struct {
+	mailer *FakeMailer
+	email  *SentEmail
+	// err is the error of rendering a template, it is returned by Send
+	err error
+}
+
+func (s *fakeEmailSender) To(t *[]*mail.Address) contracts.EmailSender {
+	s.email.To = *t
+	return s
+}
+
+func (s *fakeEmailSender) Bcc(b *[]*mail.Address) contracts.EmailSender {
+	s.email.Bcc = *b
+	return s
+}
+
+func (s *fakeEmailSender) Cc(c *[]*mail.Address) contracts.EmailSender {
+	s.email.Cc = *c
+	return s
+}
+
+func (s *fakeEmailSender) Subject(sub string) contracts.EmailSender {
+	s.email.Subject = sub
+	return s
+}
+
+func (s *fakeEmailSender) HTML(html string) contracts.EmailSender {
+	s.email.HTML = html
+	return s
+}
+
+func (s *fakeEmailSender) Text(text string) contracts.EmailSender {
+	s.email.Text = text
+	return s
+}
+
+func (s *fakeEmailSender) Headers(h map[string]string) contracts.EmailSender {
+	s.email.Headers = h
+	return s
+}
+
+func (s *fakeEmailSender) Attachments(a map[string]string) contracts.EmailSender {
+	s.email.Attachments = a
+	return s
+}
+
+func (s *fakeEmailSender) WithContext(ctx context.Context) contracts.EmailSender {
+	return s
+}
+
+func (s *fakeEmailSender) Locale(locale string) contracts.EmailSender {
+	s.email.Locale = locale
+	return s
+}
+
+// HTMLTemplate renders the template at once, its t function formats the keys without translating them
+func (s *fakeEmailSender) HTMLTemplate(tmpl *htmltemplate.Template, data any) contracts.EmailSender {
+	var buf bytes.Buffer
+	if t, err := tmpl.Clone(); err != nil {
+		s.err = err
+	} else if err := t.Funcs(htmltemplate.FuncMap{"t": i18n.Format}).Execute(&buf, data); err != nil {
+		s.err = err
+	}
+	s.email.HTML = buf.String()
+	return s
+}
+
+// TextTemplate renders the template at once, its t function formats the keys without translating them
+func (s *fakeEmailSender) TextTemplate(tmpl *texttemplate.Template, data any) contracts.EmailSender {
+	var buf bytes.Buffer
+	if t, err := tmpl.Clone(); err != nil {
+		s.err = err
+	} else if err := t.Funcs(texttemplate.FuncMap{"t": i18n.Format}).Execute(&buf, data); err != nil {
+		s.err = err
+	}
+	s.email.Text = buf.String()
+	return s
+}
+
+func (s *fakeEmailSender) Send(useQueue ...bool) error {
+	if s.err != nil {
+		return s.err
+	}
+	email := *s.email
+	email.Queued = len(useQueue) == 0 || useQueue[0]
+	s.mailer.mu.Lock()
+	defer s.mailer.mu.Unlock()
+	s.mailer.sent = append(s.mailer.sent, &email)
+	return nil
+}
@@ -0,0 +1,697 @@
+package goetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.oease.dev/goe/contracts"
+	"go.oease.dev/goe/modules/mongodb"
+	"go.oease.dev/omgo"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeMongoDB is an in-memory contracts.MongoDB, documents are kept per collection in insertion order.
+// Filters match fields by equality only, dotted paths such as "address.city" are supported, query operators are not.
+// Find and FindWithCursor run over the stored documents, see fakeQuery for the supported query methods, Aggregate returns an error.
+type FakeMongoDB struct {
+	mu          sync.RWMutex
+	collections map[string][]bson.M
+}
+
+// beforeInsertHook and beforeUpdateHook are the hooks of mongodb.DefaultModel, called by the fake like the mongodb driver does
+type beforeInsertHook interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+type beforeUpdateHook interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// NewFakeMongoDB creates an empty in-memory MongoDB.
+func NewFakeMongoDB() *FakeMongoDB {
+	return &FakeMongoDB{collections: make(map[string][]bson.M)}
+}
+
+// Documents returns copies of the documents stored in the collection, in insertion order.
+func (f *FakeMongoDB) Documents(colName string) []bson.M {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	docs := make([]bson.M, 0, len(f.collections[colName]))
+	for _, doc := range f.collections[colName] {
+		docs = append(docs, copyDoc(doc))
+	}
+	return docs
+}
+
+// Reset removes all documents of all collections.
+func (f *FakeMongoDB) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.collections = make(map[string][]bson.M)
+}
+
+func (f *FakeMongoDB) Find(model mongodb.IDefaultModel, filter any) omgo.QueryI {
+	return &fakeQuery{db: f, colName: model.ColName(), filter: filter}
+}
+
+func (f *FakeMongoDB) FindPage(model mongodb.IDefaultModel, filter any, res any, pageSize int64, currentPage int64, option ...*mongodb.FindPageOption) (totalDoc int64, totalPage int64) {
+	matched, err := f.match(model.ColName(), filter)
+	if err != nil || pageSize <= 0 {
+		return 0, 0
+	}
+	totalDoc = int64(len(matched))
+	totalPage = totalDoc / pageSize
+	if totalDoc%pageSize != 0 {
+		totalPage++
+	}
+	offset := (currentPage - 1) * pageSize
+	if offset < 0 || offset >= totalDoc {
+		return totalDoc, totalPage
+	}
+	end := offset + pageSize
+	if end > totalDoc {
+		end = totalDoc
+	}
+	if err := decodeDocs(matched[offset:end], res); err != nil {
+		return 0, 0
+	}
+	return totalDoc, totalPage
+}
+
+func (f *FakeMongoDB) FindOne(model mongodb.IDefaultModel, filter any, res any) (bool, error) {
+	matched, err := f.match(model.ColName(), filter)
+	if err != nil {
+		return false, err
+	}
+	if len(matched) == 0 {
+		return false, nil
+	}
+	return true, decodeDoc(matched[0], res)
+}
+
+func (f *FakeMongoDB) FindById(model mongodb.IDefaultModel, id string, res any) (bool, error) {
+	return f.FindOne(model, bson.M{"_id": mongodb.MustHexToObjectId(id)}, res)
+}
+
+func (f *FakeMongoDB) FindWithCursor(model mongodb.IDefaultModel, filter any) omgo.CursorI {
+	return f.Find(model, filter).Cursor()
+}
+
+func (f *FakeMongoDB) Insert(model mongodb.IDefaultModel) (*omgo.InsertOneResult, error) {
+	id, err := f.insert(model.ColName(), model)
+	if err != nil {
+		return nil, err
+	}
+	return &omgo.InsertOneResult{InsertedID: id}, nil
+}
+
+func (f *FakeMongoDB) InsertMany(model mongodb.IDefaultModel, docs []any) (*omgo.InsertManyResult, error) {
+	ids := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		id, err := f.insert(model.ColName(), doc)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return &omgo.InsertManyResult{InsertedIDs: ids}, nil
+}
+
+func (f *FakeMongoDB) Update(model mongodb.IDefaultModel) error {
+	if model.GetObjectID().IsZero() {
+		return errors.New("model does not have an ID, please provide an ID or find the document first")
+	}
+	if hook, ok := model.(beforeUpdateHook); ok {
+		if err := hook.BeforeUpdate(context.Background()); err != nil {
+			return err
+		}
+	}
+	doc, err := toDoc(model)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, stored := range f.collections[model.ColName()] {
+		if valueEqual(stored["_id"], model.GetObjectID()) {
+			// same as $set, the fields of the model overwrite the stored ones
+			for k, v := range doc {
+				stored[k] = v
+			}
+			return nil
+		}
+	}
+	return omgo.ErrNoSuchDocuments
+}
+
+func (f *FakeMongoDB) Delete(model mongodb.IDefaultModel) error {
+	if model.GetObjectID().IsZero() {
+		return errors.New("model does not have an ID, please provide an ID or find the document first")
+	}
+	res, err := f.DeleteMany(model, bson.M{"_id": model.GetObjectID()})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return omgo.ErrNoSuchDocuments
+	}
+	return nil
+}
+
+func (f *FakeMongoDB) DeleteMany(model mongodb.IDefaultModel, filter any) (*omgo.DeleteResult, error) {
+	if filter == nil {
+		return nil, errors.New("filter cannot be nil, please provide a filter")
+	}
+	cond, err := toDoc(filter)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	kept := make([]bson.M, 0, len(f.collections[model.ColName()]))
+	var deleted int64
+	for _, doc := range f.collections[model.ColName()] {
+		ok, err := matchDoc(doc, cond)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			deleted++
+			continue
+		}
+		kept = append(kept, doc)
+	}
+	f.collections[model.ColName()] = kept
+	return &omgo.DeleteResult{DeletedCount: deleted}, nil
+}
+
+func (f *FakeMongoDB) Aggregate(model mongodb.IDefaultModel, pipeline any, res any) error {
+	return errors.New("goetest: Aggregate is not supported by FakeMongoDB")
+}
+
+func (f *FakeMongoDB) IsExist(model mongodb.IDefaultModel, filter any) (bool, error) {
+	matched, err := f.match(model.ColName(), filter)
+	if err != nil {
+		return false, err
+	}
+	return len(matched) > 0, nil
+}
+
+func (f *FakeMongoDB) Count(model mongodb.IDefaultModel, filter any) (int64, error) {
+	matched, err := f.match(model.ColName(), filter)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(matched)), nil
+}
+
+// Client returns nil, there is no mongodb client behind the fake.
+func (f *FakeMongoDB) Client() *mongodb.MongoDB {
+	return nil
+}
+
+// WithContext returns the fake itself, the context is ignored.
+func (f *FakeMongoDB) WithContext(ctx context.Context) contracts.MongoDB {
+	return f
+}
+
+// insert stores the document, it runs the BeforeInsert hook and generates the _id like the mongodb driver does.
+func (f *FakeMongoDB) insert(colName string, document any) (any, error) {
+	if hook, ok := document.(beforeInsertHook); ok {
+		if err := hook.BeforeInsert(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	doc, err := toDoc(document)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := doc["_id"]
+	if !ok || id == nil || id == primitive.NilObjectID {
+		id = primitive.NewObjectID()
+		doc["_id"] = id
+		if model, ok := document.(mongodb.IDefaultModel); ok {
+			model.PutId(id.(primitive.ObjectID).Hex())
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, stored := range f.collections[colName] {
+		if valueEqual(stored["_id"], id) {
+			return nil, fmt.Errorf("duplicate key error, collection %s, _id %v", colName, id)
+		}
+	}
+	f.collections[colName] = append(f.collections[colName], doc)
+	return id, nil
+}
+
+// match returns copies of the documents of the collection matching the filter.
+func (f *FakeMongoDB) match(colName string, filter any) ([]bson.M, error) {
+	cond := bson.M{}
+	if filter != nil {
+		var err error
+		if cond, err = toDoc(filter); err != nil {
+			return nil, err
+		}
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	matched := make([]bson.M, 0)
+	for _, doc := range f.collections[colName] {
+		ok, err := matchDoc(doc, cond)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, copyDoc(doc))
+		}
+	}
+	return matched, nil
+}
+
+func matchDoc(doc bson.M, cond bson.M) (bool, error) {
+	for key, want := range cond {
+		if strings.HasPrefix(key, "$") {
+			return false, fmt.Errorf("goetest: query operator %s is not supported by FakeMongoDB", key)
+		}
+		if sub, ok := want.(bson.M); ok {
+			for op := range sub {
+				if strings.HasPrefix(op, "$") {
+					return false, fmt.Errorf("goetest: query operator %s is not supported by FakeMongoDB", op)
+				}
+			}
+		}
+		got, ok := lookup(doc, key)
+		if !ok || !valueEqual(got, want) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// lookup returns the value of the field, the key can be a dotted path of embedded documents.
+func lookup(doc bson.M, key string) (any, bool) {
+	var cur any = doc
+	for _, part := range strings.Split(key, ".") {
+		var m bson.M
+		switch v := cur.(type) {
+		case bson.M:
+			m = v
+		case bson.D:
+			m = v.Map()
+		default:
+			return nil, false
+		}
+		var ok bool
+		if cur, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// valueEqual compares values by their bson encoding, so that values of the stored documents and of the filters are comparable.
+func valueEqual(a, b any) bool {
+	ta, da, errA := bson.MarshalValue(a)
+	tb, db, errB := bson.MarshalValue(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return ta == tb && string(da) == string(db)
+}
+
+// toDoc converts a model, a filter or a map to a bson document.
+func toDoc(v any) (bson.M, error) {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.M{}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func copyDoc(doc bson.M) bson.M {
+	c, err := toDoc(doc)
+	if err != nil {
+		return bson.M{}
+	}
+	return c
+}
+
+func decodeDoc(doc bson.M, res any) error {
+	if res == nil {
+		return nil
+	}
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, res)
+}
+
+// decodeDocs decodes the documents into res, which must be a pointer to a slice.
+func decodeDocs(docs []bson.M, res any) error {
+	rv := reflect.ValueOf(res)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("result must be a pointer to a slice")
+	}
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	out := reflect.MakeSlice(slice.Type(), 0, len(docs))
+	for _, doc := range docs {
+		isPtr := elemType.Kind() == reflect.Pointer
+		target := reflect.New(elemType)
+		if isPtr {
+			target = reflect.New(elemType.Elem())
+		}
+		if err := decodeDoc(doc, target.Interface()); err != nil {
+			return err
+		}
+		if isPtr {
+			out = reflect.Append(out, target)
+		} else {
+			out = reflect.Append(out, target.Elem())
+		}
+	}
+	slice.Set(out)
+	return nil
+}
+
+// fakeQuery is the query returned by FakeMongoDB.Find, the documents are matched when the results are read.
+// Select supports the inclusion or the exclusion of fields, Sort the field names prefixed by - for the descending order.
+// BatchSize, NoCursorTimeout and Hint are ignored, the other methods of omgo.QueryI are not supported and panic.
+type fakeQuery struct {
+	omgo.QueryI
+	db       *FakeMongoDB
+	colName  string
+	filter   any
+	selector any
+	sort     []string
+	skip     int64
+	limit    int64
+}
+
+func (q *fakeQuery) Select(selector interface{}) omgo.QueryI {
+	q.selector = selector
+	return q
+}
+
+func (q *fakeQuery) Sort(fields ...string) omgo.QueryI {
+	q.sort = fields
+	return q
+}
+
+func (q *fakeQuery) Skip(n int64) omgo.QueryI {
+	q.skip = n
+	return q
+}
+
+func (q *fakeQuery) Limit(n int64) omgo.QueryI {
+	q.limit = n
+	return q
+}
+
+func (q *fakeQuery) BatchSize(n int64) omgo.QueryI {
+	return q
+}
+
+func (q *fakeQuery) NoCursorTimeout(n bool) omgo.QueryI {
+	return q
+}
+
+func (q *fakeQuery) Hint(hint interface{}) omgo.QueryI {
+	return q
+}
+
+func (q *fakeQuery) One(result interface{}) error {
+	docs, err := q.docs()
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return omgo.ErrNoSuchDocuments
+	}
+	return decodeDoc(docs[0], result)
+}
+
+func (q *fakeQuery) All(result interface{}) error {
+	docs, err := q.docs()
+	if err != nil {
+		return err
+	}
+	return decodeDocs(docs, result)
+}
+
+// Count returns the number of the matching documents, skip and limit apply like with the mongodb driver.
+func (q *fakeQuery) Count() (int64, error) {
+	docs, err := q.docs()
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(docs)), nil
+}
+
+func (q *fakeQuery) EstimatedCount() (int64, error) {
+	q.db.mu.RLock()
+	defer q.db.mu.RUnlock()
+	return int64(len(q.db.collections[q.colName])), nil
+}
+
+// Distinct decodes the distinct values of the field into result, which must be a pointer to a slice.
+func (q *fakeQuery) Distinct(key string, result interface{}) error {
+	docs, err := q.db.match(q.colName, q.filter)
+	if err != nil {
+		return err
+	}
+	values := make([]any, 0, len(docs))
+	for _, doc := range docs {
+		v, ok := lookup(doc, key)
+		if !ok {
+			continue
+		}
+		duplicate := false
+		for _, seen := range values {
+			if valueEqual(seen, v) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			values = append(values, v)
+		}
+	}
+	data, err := bson.Marshal(bson.M{"values": values})
+	if err != nil {
+		return err
+	}
+	return bson.Raw(data).Lookup("values").Unmarshal(result)
+}
+
+func (q *fakeQuery) Cursor() omgo.CursorI {
+	docs, err := q.docs()
+	return &fakeCursor{docs: docs, err: err}
+}
+
+// docs returns the matching documents, sorted, skipped, limited and projected.
+func (q *fakeQuery) docs() ([]bson.M, error) {
+	docs, err := q.db.match(q.colName, q.filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(q.sort) > 0 {
+		sort.SliceStable(docs, func(i, j int) bool {
+			for _, field := range q.sort {
+				desc := strings.HasPrefix(field, "-")
+				field = strings.TrimPrefix(strings.TrimPrefix(field, "-"), "+")
+				a, _ := lookup(docs[i], field)
+				b, _ := lookup(docs[j], field)
+				if c := compareValues(a, b); c != 0 {
+					return c < 0 != desc
+				}
+			}
+			return false
+		})
+	}
+	if q.skip > 0 {
+		if q.skip >= int64(len(docs)) {
+			docs = docs[:0]
+		} else {
+			docs = docs[q.skip:]
+		}
+	}
+	if q.limit > 0 && q.limit < int64(len(docs)) {
+		docs = docs[:q.limit]
+	}
+	if q.selector != nil {
+		selector, err := toDoc(q.selector)
+		if err != nil {
+			return nil, err
+		}
+		for i, doc := range docs {
+			if docs[i], err = project(doc, selector); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return docs, nil
+}
+
+// project keeps the fields included by the selector, or removes the excluded ones, _id is kept unless it is excluded.
+func project(doc bson.M, selector bson.M) (bson.M, error) {
+	include := false
+	for field, v := range selector {
+		if field != "_id" && truthy(v) {
+			include = true
+		}
+	}
+	out := bson.M{}
+	if include {
+		if id, ok := doc["_id"]; ok {
+			out["_id"] = id
+		}
+	} else {
+		out = doc
+	}
+	for field, v := range selector {
+		if strings.Contains(field, ".") {
+			return nil, fmt.Errorf("goetest: projection of the embedded field %s is not supported by FakeMongoDB", field)
+		}
+		switch {
+		case !truthy(v):
+			delete(out, field)
+		case include:
+			if value, ok := doc[field]; ok {
+				out[field] = value
+			}
+		}
+	}
+	return out, nil
+}
+
+func truthy(v any) bool {
+	switch n := v.(type) {
+	case bool:
+		return n
+	case int32:
+		return n != 0
+	case int64:
+		return n != 0
+	case float64:
+		return n != 0
+	}
+	return true
+}
+
+// compareValues orders the values of a field like mongodb does for the common types, missing values first.
+func compareValues(a, b any) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y)
+		}
+	case bool:
+		if y, ok := b.(bool); ok && x != y {
+			if y {
+				return -1
+			}
+			return 1
+		}
+		return 0
+	case primitive.DateTime:
+		if y, ok := b.(primitive.DateTime); ok {
+			return x.Time().Compare(y.Time())
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	case primitive.ObjectID:
+		if y, ok := b.(primitive.ObjectID); ok {
+			return strings.Compare(x.Hex(), y.Hex())
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// fakeCursor iterates over the documents of a fakeQuery.
+type fakeCursor struct {
+	docs []bson.M
+	pos  int
+	err  error
+}
+
+func (c *fakeCursor) Next(result interface{}) bool {
+	if c.err != nil || c.pos >= len(c.docs) {
+		return false
+	}
+	doc := c.docs[c.pos]
+	c.pos++
+	if err := decodeDoc(doc, result); err != nil {
+		c.err = err
+		return false
+	}
+	return true
+}
+
+// All decodes the remaining documents into results, which must be a pointer to a slice.
+func (c *fakeCursor) All(results interface{}) error {
+	if c.err != nil {
+		return c.err
+	}
+	err := decodeDocs(c.docs[c.pos:], results)
+	c.pos = len(c.docs)
+	return err
+}
+
+func (c *fakeCursor) Close() error {
+	c.pos = len(c.docs)
+	return nil
+}
+
+func (c *fakeCursor) Err() error {
+	return c.err
+}
@@ -0,0 +1,157 @@
+package goetest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.oease.dev/goe/modules/mongodb"
+	"go.oease.dev/omgo"
+	"testing"
+)
+
+type user struct {
+	mongodb.DefaultModel `bson:",inline"`
+	Name                 string  `bson:"name"`
+	Age                  int     `bson:"age"`
+	Role                 string  `bson:"role"`
+	Address              address `bson:"address"`
+}
+
+type address struct {
+	City string `bson:"city"`
+}
+
+func (u *user) ColName() string {
+	return "users"
+}
+
+func seedUsers(t *testing.T, db *FakeMongoDB) []*user {
+	t.Helper()
+	users := []*user{
+		{Name: "ann", Age: 31, Role: "admin", Address: address{City: "Paris"}},
+		{Name: "bob", Age: 25, Role: "member", Address: address{City: "Berlin"}},
+		{Name: "cid", Age: 42, Role: "member", Address: address{City: "Paris"}},
+		{Name: "dan", Age: 25, Role: "guest", Address: address{City: "Rome"}},
+	}
+	for _, u := range users {
+		_, err := db.Insert(u)
+		require.NoError(t, err)
+	}
+	return users
+}
+
+func names(users []user) []string {
+	out := make([]string, len(users))
+	for i, u := range users {
+		out[i] = u.Name
+	}
+	return out
+}
+
+func TestFakeMongoDBCRUD(t *testing.T) {
+	db := NewFakeMongoDB()
+	users := seedUsers(t, db)
+	// the hooks of the default model run like with the mongodb driver
+	assert.False(t, users[0].Id.IsZero())
+	assert.NotZero(t, users[0].CreateTime)
+	_, err := db.Insert(users[0])
+	assert.ErrorContains(t, err, "duplicate key error")
+
+	var found user
+	ok, err := db.FindById(&user{}, users[1].GetId(), &found)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "bob", found.Name)
+	ok, err = db.FindOne(&user{}, bson.M{"address.city": "Rome"}, &found)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "dan", found.Name)
+
+	users[1].Age = 26
+	require.NoError(t, db.Update(users[1]))
+	count, err := db.Count(&user{}, bson.M{"age": 26})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	require.NoError(t, db.Delete(users[1]))
+	assert.ErrorIs(t, db.Delete(users[1]), omgo.ErrNoSuchDocuments)
+	res, err := db.DeleteMany(&user{}, bson.M{"role": "member"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.DeletedCount)
+	assert.Len(t, db.Documents("users"), 2)
+
+	var page []user
+	totalDoc, totalPage := db.FindPage(&user{}, nil, &page, 1, 2)
+	assert.Equal(t, int64(2), totalDoc)
+	assert.Equal(t, int64(2), totalPage)
+	assert.Equal(t, []string{"dan"}, names(page))
+
+	_, err = db.Count(&user{}, bson.M{"age": bson.M{"$gt": 30}})
+	assert.EqualError(t, err, "goetest: query operator $gt is not supported by FakeMongoDB")
+	assert.Error(t, db.Aggregate(&user{}, bson.A{}, &page))
+
+	db.Reset()
+	assert.Empty(t, db.Documents("users"))
+}
+
+func TestFakeMongoDBFind(t *testing.T) {
+	db := NewFakeMongoDB()
+	seedUsers(t, db)
+
+	var all []user
+	require.NoError(t, db.Find(&user{}, bson.M{}).Sort("age", "-name").All(&all))
+	assert.Equal(t, []string{"dan", "bob", "ann", "cid"}, names(all))
+
+	var members []*user
+	require.NoError(t, db.Find(&user{}, bson.M{"role": "member"}).Sort("-age").Limit(1).All(&members))
+	require.Len(t, members, 1)
+	assert.Equal(t, "cid", members[0].Name)
+
+	var paged []user
+	require.NoError(t, db.Find(&user{}, nil).Sort("name").Skip(1).Limit(2).All(&paged))
+	assert.Equal(t, []string{"bob", "cid"}, names(paged))
+
+	var one user
+	require.NoError(t, db.Find(&user{}, bson.M{"address.city": "Paris"}).Sort("-age").Select(bson.M{"name": 1}).One(&one))
+	assert.Equal(t, "cid", one.Name)
+	assert.Zero(t, one.Age)
+	assert.False(t, one.Id.IsZero())
+	assert.ErrorIs(t, db.Find(&user{}, bson.M{"name": "eve"}).One(&one), omgo.ErrNoSuchDocuments)
+
+	count, err := db.Find(&user{}, bson.M{"age": 25}).Count()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	var cities []string
+	require.NoError(t, db.Find(&user{}, nil).(*fakeQuery).Distinct("address.city", &cities))
+	assert.Equal(t, []string{"Paris", "Berlin", "Rome"}, cities)
+
+	_, err = db.Find(&user{}, bson.M{"$or": bson.A{}}).Count()
+	assert.Error(t, err)
+}
+
+func TestFakeMongoDBFindWithCursor(t *testing.T) {
+	db := NewFakeMongoDB()
+	seedUsers(t, db)
+
+	cursor := db.FindWithCursor(&user{}, bson.M{"role": "member"})
+	var u user
+	var visited []string
+	for cursor.Next(&u) {
+		visited = append(visited, u.Name)
+	}
+	require.NoError(t, cursor.Err())
+	require.NoError(t, cursor.Close())
+	assert.Equal(t, []string{"bob", "cid"}, visited)
+
+	cursor = db.Find(&user{}, nil).Sort("-age").Cursor()
+	require.True(t, cursor.Next(&u))
+	assert.Equal(t, "cid", u.Name)
+	var rest []user
+	require.NoError(t, cursor.All(&rest))
+	assert.Equal(t, []string{"ann", "bob", "dan"}, names(rest))
+
+	// the error of an unsupported filter is reported by the cursor
+	cursor = db.FindWithCursor(&user{}, bson.M{"age": bson.M{"$in": bson.A{25}}})
+	assert.False(t, cursor.Next(&u))
+	assert.EqualError(t, cursor.Err(), "goetest: query operator $in is not supported by FakeMongoDB")
+}
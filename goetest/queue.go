@@ -0,0 +1,184 @@
+package goetest
+
+import (
+	"context"
+	"errors"
+	"github.com/goccy/go-json"
+	"go.oease.dev/goe/contracts"
+	"sort"
+	"sync"
+	"time"
+)
+
+// QueueMessage is a message pushed to a FakeQueue.
+type QueueMessage struct {
+	Queue     contracts.QueueName
+	Payload   string
+	DeliverAt time.Time
+	// Acked reports whether the message was consumed successfully by Deliver
+	Acked bool
+}
+
+// Bind unmarshals the json payload of the message into v.
+func (m *QueueMessage) Bind(v any) error {
+	return json.Unmarshal([]byte(m.Payload), v)
+}
+
+// FakeQueue is an in-memory contracts.Queue which records the pushed messages instead of consuming them.
+// Use Deliver to run the queue handler on the recorded messages synchronously.
+type FakeQueue struct {
+	mu       sync.Mutex
+	handlers map[contracts.QueueName]func(string) bool
+	messages []*QueueMessage
+}
+
+// NewFakeQueue creates an empty fake queue.
+func NewFakeQueue() *FakeQueue {
+	return &FakeQueue{handlers: make(map[contracts.QueueName]func(string) bool)}
+}
+
+func (f *FakeQueue) NewQueue(name contracts.QueueName, handler func(string) bool, cfgs ...*contracts.NewQueueCfg) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[name] = handler
+	return nil
+}
+
+// NewQueueContext declares the queue, Deliver calls the handler with context.Background().
+func (f *FakeQueue) NewQueueContext(name contracts.QueueName, handler func(ctx context.Context, payload string) bool, cfgs ...*contracts.NewQueueCfg) error {
+	return f.NewQueue(name, func(payload string) bool {
+		return handler(context.Background(), payload)
+	}, cfgs...)
+}
+
+// WithContext returns the fake itself, the pushed payloads are recorded without trace context.
+func (f *FakeQueue) WithContext(ctx context.Context) contracts.Queue {
+	return f
+}
+
+func (f *FakeQueue) PushRaw(queueName contracts.QueueName, payload string) error {
+	return f.push(queueName, payload, time.Now())
+}
+
+func (f *FakeQueue) Push(queueName contracts.QueueName, payloadPtr any) error {
+	return f.PushScheduled(queueName, payloadPtr, time.Now())
+}
+
+func (f *FakeQueue) PushDelayed(queueName contracts.QueueName, payloadPtr any, delayDuration time.Duration) error {
+	return f.PushScheduled(queueName, payloadPtr, time.Now().Add(delayDuration))
+}
+
+func (f *FakeQueue) PushDelayedRaw(queueName contracts.QueueName, payload string, delayDuration time.Duration) error {
+	return f.push(queueName, payload, time.Now().Add(delayDuration))
+}
+
+func (f *FakeQueue) PushScheduledRaw(queueName contracts.QueueName, payload string, t time.Time) error {
+	return f.push(queueName, payload, t)
+}
+
+func (f *FakeQueue) PushScheduled(queueName contracts.QueueName, payloadPtr any, t time.Time) error {
+	data, err := json.Marshal(payloadPtr)
+	if err != nil {
+		return err
+	}
+	return f.push(queueName, string(data), t)
+}
+
+func (f *FakeQueue) Stats() ([]contracts.QueueStats, error) {
+	now := time.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats := make([]contracts.QueueStats, 0, len(f.handlers))
+	for name := range f.handlers {
+		s := contracts.QueueStats{Name: name}
+		for _, msg := range f.messages {
+			if msg.Queue != name || msg.Acked {
+				continue
+			}
+			if msg.DeliverAt.After(now) {
+				s.Pending++
+			} else {
+				s.Ready++
+			}
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats, nil
+}
+
+// Messages returns the messages pushed to the queue, in push order.
+func (f *FakeQueue) Messages(queueName contracts.QueueName) []QueueMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	messages := make([]QueueMessage, 0)
+	for _, msg := range f.messages {
+		if msg.Queue == queueName {
+			messages = append(messages, *msg)
+		}
+	}
+	return messages
+}
+
+// Payloads returns the payloads pushed to the queue, in push order.
+func (f *FakeQueue) Payloads(queueName contracts.QueueName) []string {
+	messages := f.Messages(queueName)
+	payloads := make([]string, len(messages))
+	for i, msg := range messages {
+		payloads[i] = msg.Payload
+	}
+	return payloads
+}
+
+// Deliver runs the handler of the queue on the messages not acked yet, regardless of their delivery time, in push order.
+// Messages the handler returns false for stay in the queue and are delivered again by the next call.
+// It returns the number of acked messages.
+func (f *FakeQueue) Deliver(queueName contracts.QueueName) (int, error) {
+	f.mu.Lock()
+	handler, ok := f.handlers[queueName]
+	var pending []*QueueMessage
+	for _, msg := range f.messages {
+		if msg.Queue == queueName && !msg.Acked {
+			pending = append(pending, msg)
+		}
+	}
+	f.mu.Unlock()
+	if !ok {
+		return 0, errors.New("queue not found")
+	}
+	acked := 0
+	for _, msg := range pending {
+		// the handler runs without the lock, so it can push new messages
+		if handler(msg.Payload) {
+			f.mu.Lock()
+			msg.Acked = true
+			f.mu.Unlock()
+			acked++
+		}
+	}
+	return acked, nil
+}
+
+// Reset removes all recorded messages, the declared queues are kept.
+func (f *FakeQueue) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = nil
+}
+
+func (f *FakeQueue) push(queueName contracts.QueueName, payload string, t time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// same as the built-in queue, the queue must be declared by NewQueue first
+	if _, ok := f.handlers[queueName]; !ok {
+		return errors.New("queue not found")
+	}
+	f.messages = append(f.messages, &QueueMessage{
+		Queue:     queueName,
+		Payload:   payload,
+		DeliverAt: t,
+	})
+	return nil
+}
@@ -0,0 +1,233 @@
+package goetest
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/meilisearch/meilisearch-go"
+	"strings"
+	"sync"
+)
+
+// FakeSearch is an in-memory contracts.Meilisearch, documents are kept per index and identified by their "id" field.
+// Search matches the documents containing the query in any of their values, case-insensitively; filters, sorting and ranking are not supported.
+type FakeSearch struct {
+	mu      sync.RWMutex
+	indexes map[string][]map[string]any
+}
+
+// NewFakeSearch creates an empty in-memory search.
+func NewFakeSearch() *FakeSearch {
+	return &FakeSearch{indexes: make(map[string][]map[string]any)}
+}
+
+// ApplyIndexConfigs only validates the config data, indexes are created when documents are added.
+func (f *FakeSearch) ApplyIndexConfigs(configData []byte) error {
+	if !json.Valid(configData) {
+		return fmt.Errorf("invalid index config json")
+	}
+	return nil
+}
+
+// RebuildAllIndexes does nothing, there is no database to rebuild the indexes from.
+func (f *FakeSearch) RebuildAllIndexes(dbConnUri string, dbName string) error {
+	return nil
+}
+
+// WaitForTaskSuccess returns immediately, the fake applies all changes synchronously.
+func (f *FakeSearch) WaitForTaskSuccess(taskUID int64) error {
+	return nil
+}
+
+// AddDoc adds or replaces the documents, docPtr can be a document or a slice of documents.
+func (f *FakeSearch) AddDoc(indexName string, docPtr any) error {
+	docs, err := toSearchDocs(docPtr)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, doc := range docs {
+		if i := f.indexOf(indexName, doc["id"]); i >= 0 {
+			f.indexes[indexName][i] = doc
+		} else {
+			f.indexes[indexName] = append(f.indexes[indexName], doc)
+		}
+	}
+	return nil
+}
+
+func (f *FakeSearch) DelDoc(indexName string, docId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if i := f.indexOf(indexName, docId); i >= 0 {
+		docs := f.indexes[indexName]
+		f.indexes[indexName] = append(docs[:i:i], docs[i+1:]...)
+	}
+	return nil
+}
+
+// UpdateDoc merges the fields of the documents into the stored ones, documents not found are added.
+func (f *FakeSearch) UpdateDoc(indexName string, docPtr any) error {
+	docs, err := toSearchDocs(docPtr)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, doc := range docs {
+		i := f.indexOf(indexName, doc["id"])
+		if i < 0 {
+			f.indexes[indexName] = append(f.indexes[indexName], doc)
+			continue
+		}
+		for k, v := range doc {
+			f.indexes[indexName][i][k] = v
+		}
+	}
+	return nil
+}
+
+func (f *FakeSearch) GetDoc(indexName string, docId string, bindResult any) (bool, error) {
+	f.mu.RLock()
+	i := f.indexOf(indexName, docId)
+	var data []byte
+	var err error
+	if i >= 0 {
+		data, err = json.Marshal(f.indexes[indexName][i])
+	}
+	f.mu.RUnlock()
+	if i < 0 {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, bindResult)
+}
+
+func (f *FakeSearch) DeleteAllDocuments(indexName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.indexes, indexName)
+	return nil
+}
+
+// Search returns the matched documents in insertion order, paginated by the Offset and Limit, or the Page and HitsPerPage options.
+func (f *FakeSearch) Search(indexName string, query string, options *meilisearch.SearchRequest) *meilisearch.SearchResponse {
+	hits := make([]map[string]any, 0)
+	needle := []byte(strings.ToLower(query))
+	f.mu.RLock()
+	for _, doc := range f.indexes[indexName] {
+		data, err := json.Marshal(doc)
+		if err != nil {
+			continue
+		}
+		if query == "" || bytes.Contains(bytes.ToLower(data), needle) {
+			hits = append(hits, doc)
+		}
+	}
+	f.mu.RUnlock()
+
+	total := int64(len(hits))
+	res := map[string]any{"query": query, "processingTimeMs": 0}
+	if options != nil && (options.Page > 0 || options.HitsPerPage > 0) {
+		page, hitsPerPage := options.Page, options.HitsPerPage
+		if page <= 0 {
+			page = 1
+		}
+		if hitsPerPage <= 0 {
+			hitsPerPage = 20
+		}
+		res["page"] = page
+		res["hitsPerPage"] = hitsPerPage
+		res["totalHits"] = total
+		res["totalPages"] = (total + hitsPerPage - 1) / hitsPerPage
+		res["hits"] = paginate(hits, (page-1)*hitsPerPage, hitsPerPage)
+	} else {
+		offset, limit := int64(0), int64(20)
+		if options != nil {
+			offset = options.Offset
+			if options.Limit > 0 {
+				limit = options.Limit
+			}
+		}
+		res["offset"] = offset
+		res["limit"] = limit
+		res["estimatedTotalHits"] = total
+		res["hits"] = paginate(hits, offset, limit)
+	}
+	// the response is built from json, so it does not depend on the hit types of the meilisearch client
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil
+	}
+	resp := &meilisearch.SearchResponse{}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return nil
+	}
+	return resp
+}
+
+// Documents returns the documents of the index, in insertion order.
+func (f *FakeSearch) Documents(indexName string) []map[string]any {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]map[string]any(nil), f.indexes[indexName]...)
+}
+
+// Reset removes all indexes.
+func (f *FakeSearch) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.indexes = make(map[string][]map[string]any)
+}
+
+func (f *FakeSearch) indexOf(indexName string, id any) int {
+	for i, doc := range f.indexes[indexName] {
+		if fmt.Sprint(doc["id"]) == fmt.Sprint(id) {
+			return i
+		}
+	}
+	return -1
+}
+
+func paginate(hits []map[string]any, offset, limit int64) []map[string]any {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= int64(len(hits)) {
+		return []map[string]any{}
+	}
+	end := offset + limit
+	if end > int64(len(hits)) {
+		end = int64(len(hits))
+	}
+	return hits[offset:end]
+}
+
+// toSearchDocs converts a document or a slice of documents to json objects.
+func toSearchDocs(docPtr any) ([]map[string]any, error) {
+	data, err := json.Marshal(docPtr)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	var docs []map[string]any
+	if len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &docs)
+	} else {
+		var doc map[string]any
+		err = json.Unmarshal(data, &doc)
+		docs = append(docs, doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		if _, ok := doc["id"]; !ok {
+			return nil, fmt.Errorf("document has no id field")
+		}
+	}
+	return docs, nil
+}
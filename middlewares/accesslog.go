@@ -0,0 +1,265 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v3"
+	"go.oease.dev/goe/core"
+	"go.oease.dev/goe/modules/log"
+	"go.opentelemetry.io/otel/trace"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Fields of the access log, see AccessLogConfig.Fields
+const (
+	AccessLogMethod    = "method"
+	AccessLogPath      = "path"
+	AccessLogRoute     = "route"
+	AccessLogStatus    = "status"
+	AccessLogLatency   = "latency"
+	AccessLogBytesIn   = "bytes_in"
+	AccessLogBytesOut  = "bytes_out"
+	AccessLogIP        = "ip"
+	AccessLogUserAgent = "user_agent"
+	AccessLogUserID    = "user_id"
+	AccessLogRequestID = "request_id"
+	AccessLogTraceID   = "trace_id"
+)
+
+// Formats of the access log, see AccessLogConfig.Format
+const (
+	// AccessLogFormatJSON logs the fields as structured fields, they are written as json with LOG_FORMAT=json
+	AccessLogFormatJSON = "json"
+	// AccessLogFormatText logs the values of the fields in the message, separated by pipes
+	AccessLogFormatText = "text"
+)
+
+// DefaultAccessLogFields are the fields logged when AccessLogConfig.Fields is empty.
+var DefaultAccessLogFields = []string{
+	AccessLogMethod, AccessLogPath, AccessLogRoute, AccessLogStatus, AccessLogLatency, AccessLogBytesIn, AccessLogBytesOut,
+	AccessLogIP, AccessLogUserAgent, AccessLogUserID, AccessLogRequestID,
+}
+
+// staticResourcePaths are the path globs of the frontend static resources, skipped by NewRequestLoggingMiddleware.
+var staticResourcePaths = []string{"**.html", "**.css", "**.js", "**.png", "**.jpg", "**.jpeg", "**.gif", "**.svg", "**.ico"}
+
+// AccessLogConfig configures the access log middleware, the zero values log the default fields of every request as json.
+type AccessLogConfig struct {
+	// Fields are the logged fields in their order, default is DefaultAccessLogFields
+	Fields []string
+	// Format is json or text, default is json
+	Format string
+	// SkipPaths are globs of the paths which are not logged, * matches within a path segment and ** across them, such as /healthz or /assets/**
+	SkipPaths []string
+	// SkipStatuses are the response statuses which are not logged, such as 404
+	SkipStatuses []int
+	// Skip is called before the request is handled, the request is not logged if it returns true
+	Skip func(ctx fiber.Ctx) bool
+	// SlowThreshold logs the requests taking longer at the warn level, 0 disables it
+	SlowThreshold time.Duration
+	// CaptureBody logs the request and response bodies for debugging, the textual ones only, masked with Redaction
+	CaptureBody bool
+	// MaxBodySize is the number of bytes of the bodies captured, default is 2048
+	MaxBodySize int
+	// Redaction masks the captured bodies besides the redaction of the logger, the defaults of the log module are always masked
+	Redaction log.RedactConfig
+}
+
+var defaultAccessLogConfig = AccessLogConfig{
+	Format:      AccessLogFormatJSON,
+	MaxBodySize: 2048,
+}
+
+// NewAccessLogMiddleware creates the access log middleware writing to the logger of the app owning the given container.
+// The errors of the handlers are passed to the error handler of the app before the request is logged, so that the status is the one sent.
+// It returns an error if the format or a redaction pattern is invalid.
+func NewAccessLogMiddleware(c *core.Container, config ...AccessLogConfig) (fiber.Handler, error) {
+	cfg := defaultAccessLogConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if len(cfg.Fields) == 0 {
+		cfg.Fields = DefaultAccessLogFields
+	}
+	if cfg.Format == "" {
+		cfg.Format = defaultAccessLogConfig.Format
+	}
+	if cfg.Format != AccessLogFormatJSON && cfg.Format != AccessLogFormatText {
+		return nil, fmt.Errorf("unsupported access log format %q, use json or text", cfg.Format)
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaultAccessLogConfig.MaxBodySize
+	}
+	skipPaths := make([]*regexp.Regexp, len(cfg.SkipPaths))
+	for i, glob := range cfg.SkipPaths {
+		skipPaths[i] = globRegexp(glob)
+	}
+	var redactor *log.Redactor
+	if cfg.CaptureBody {
+		r, err := log.NewRedactor(cfg.Redaction)
+		if err != nil {
+			return nil, err
+		}
+		redactor = r
+	}
+	logger := c.GetLogger()
+
+	return func(ctx fiber.Ctx) error {
+		if cfg.Skip != nil && cfg.Skip(ctx) {
+			return ctx.Next()
+		}
+		for _, re := range skipPaths {
+			if re.MatchString(ctx.Path()) {
+				return ctx.Next()
+			}
+		}
+		start := time.Now()
+		chainErr := ctx.Next()
+		if chainErr != nil {
+			if err := ctx.App().ErrorHandler(ctx, chainErr); err != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+		latency := time.Since(start)
+		status := ctx.Response().StatusCode()
+		if slices.Contains(cfg.SkipStatuses, status) {
+			return nil
+		}
+
+		keysAndValues := make([]any, 0, 2*len(cfg.Fields)+6)
+		for _, field := range cfg.Fields {
+			if value, ok := accessLogValue(ctx, field, status, latency); ok {
+				keysAndValues = append(keysAndValues, field, value)
+			}
+		}
+		if chainErr != nil {
+			keysAndValues = append(keysAndValues, "error", chainErr.Error())
+		}
+		if cfg.CaptureBody {
+			keysAndValues = append(keysAndValues,
+				"request_body", captureBody(redactor, string(ctx.Request().Header.ContentType()), ctx.Request().Body(), cfg.MaxBodySize),
+				"response_body", captureBody(redactor, string(ctx.Response().Header.ContentType()), ctx.Response().Body(), cfg.MaxBodySize),
+			)
+		}
+
+		slow := cfg.SlowThreshold > 0 && latency >= cfg.SlowThreshold
+		if cfg.Format == AccessLogFormatText {
+			values := make([]string, 0, len(keysAndValues)/2)
+			for i := 1; i < len(keysAndValues); i += 2 {
+				values = append(values, toString(keysAndValues[i]))
+			}
+			// the request logger adds the request id, its fields do not repeat the ones of the message
+			textLogger := core.LoggerFrom(ctx)
+			if textLogger == nil {
+				textLogger = logger
+			}
+			if slow {
+				textLogger.Warn("slow request | " + strings.Join(values, " | "))
+			} else {
+				textLogger.Info(strings.Join(values, " | "))
+			}
+			return nil
+		}
+		if slow {
+			logger.Warnw("slow request", keysAndValues...)
+		} else {
+			logger.Infow("request", keysAndValues...)
+		}
+		return nil
+	}, nil
+}
+
+// accessLogValue returns the value of an access log field, it reports false if the request has none, such as the user id of an anonymous request.
+func accessLogValue(ctx fiber.Ctx, field string, status int, latency time.Duration) (any, bool) {
+	switch field {
+	case AccessLogMethod:
+		return ctx.Method(), true
+	case AccessLogPath:
+		return ctx.Path(), true
+	case AccessLogRoute:
+		return ctx.Route().Path, true
+	case AccessLogStatus:
+		return status, true
+	case AccessLogLatency:
+		return latency, true
+	case AccessLogBytesIn:
+		return len(ctx.Request().Body()), true
+	case AccessLogBytesOut:
+		return len(ctx.Response().Body()), true
+	case AccessLogIP:
+		return ctx.IP(), true
+	case AccessLogUserAgent:
+		return string(ctx.Request().Header.UserAgent()), true
+	case AccessLogUserID:
+		id := SessionUserID(ctx)
+		return id, id != ""
+	case AccessLogRequestID:
+		id := core.RequestIDFromContext(ctx)
+		return id, id != ""
+	case AccessLogTraceID:
+		sc := trace.SpanContextFromContext(ctx.Context())
+		return sc.TraceID().String(), sc.IsValid()
+	}
+	return nil, false
+}
+
+// captureBody returns the body for the log, redacted and truncated to max bytes, the bodies which are not textual are replaced by their size.
+// The whole body is redacted first, so that a secret cut by the truncation is masked as well.
+func captureBody(redactor *log.Redactor, contentType string, body []byte, max int) string {
+	if len(body) == 0 {
+		return ""
+	}
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	textual := strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "json") || strings.HasSuffix(mediaType, "xml") ||
+		mediaType == fiber.MIMEApplicationForm
+	if !textual {
+		return "[" + strconv.Itoa(len(body)) + " bytes]"
+	}
+	s := redactor.Redact(string(body))
+	if len(s) <= max {
+		return s
+	}
+	// the cut does not split a multibyte character
+	end := max
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + "...[truncated]"
+}
+
+// globRegexp converts a path glob to a regular expression, * matches within a path segment, ** across them and ? a single character.
+func globRegexp(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			b.WriteString("[^/]*")
+		case glob[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func toString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case int:
+		return strconv.Itoa(val)
+	case time.Duration:
+		return val.String()
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.oease.dev/goe"
+	"go.oease.dev/goe/goetest"
+	"go.oease.dev/goe/middlewares"
+	"go.oease.dev/goe/modules/log"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newAccessLogApp creates an app logging its requests as json to the returned buffer, every path but /missing answers its path.
+func newAccessLogApp(t *testing.T, cfg middlewares.AccessLogConfig) (*goetest.Harness, *bytes.Buffer) {
+	t.Helper()
+	var out bytes.Buffer
+	sampling := false
+	logger, err := log.NewWithConfig(log.Config{Format: log.FormatJSON, Writers: []io.Writer{&out}, Sampling: &sampling})
+	require.NoError(t, err)
+	h := goetest.New(t, goetest.WithAppOptions(goe.WithLogger(logger)))
+	accessLog, err := middlewares.NewAccessLogMiddleware(h.App.Container(), cfg)
+	require.NoError(t, err)
+	app := h.App.Fiber().App()
+	app.Use(accessLog)
+	app.Get("/slow", func(ctx fiber.Ctx) error {
+		time.Sleep(50 * time.Millisecond)
+		return ctx.SendString("slow")
+	})
+	app.Post("/echo", func(ctx fiber.Ctx) error {
+		ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return ctx.Send(ctx.Body())
+	})
+	app.Get("/missing", func(ctx fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+	app.Get("/*", func(ctx fiber.Ctx) error {
+		return ctx.SendString(ctx.Path())
+	})
+	return h, &out
+}
+
+// accessLogs returns the access logs written to out.
+func accessLogs(t *testing.T, out *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var logs []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(line, &entry), string(line))
+		if entry["msg"] == "request" || entry["msg"] == "slow request" {
+			logs = append(logs, entry)
+		}
+	}
+	return logs
+}
+
+func TestAccessLogSkipPaths(t *testing.T) {
+	h, out := newAccessLogApp(t, middlewares.AccessLogConfig{
+		SkipPaths: []string{"/healthz", "/assets/**", "**.css", "/api/*/status", "/v?/ping"},
+	})
+	client := h.Client()
+	for path, logged := range map[string]bool{
+		"/healthz":             false,
+		"/healthz/live":        true,
+		"/assets/js/app.js":    false,
+		"/assets":              true,
+		"/themes/dark/app.css": false,
+		"/api/v1/status":       false,
+		"/api/v1/jobs/status":  true,
+		"/v1/ping":             false,
+		"/v10/ping":            true,
+	} {
+		out.Reset()
+		require.Equal(t, http.StatusOK, client.Get(path).StatusCode, path)
+		if logged {
+			require.Len(t, accessLogs(t, out), 1, path)
+			assert.Equal(t, path, accessLogs(t, out)[0]["path"])
+		} else {
+			assert.Empty(t, accessLogs(t, out), path)
+		}
+	}
+}
+
+func TestAccessLogSkipStatuses(t *testing.T) {
+	h, out := newAccessLogApp(t, middlewares.AccessLogConfig{SkipStatuses: []int{fiber.StatusNotFound}})
+	client := h.Client()
+	// the status is the one set by the error handler for the error of the handler
+	require.Equal(t, http.StatusNotFound, client.Get("/missing").StatusCode)
+	assert.Empty(t, accessLogs(t, out))
+
+	require.Equal(t, http.StatusOK, client.Get("/orders").StatusCode)
+	logs := accessLogs(t, out)
+	require.Len(t, logs, 1)
+	assert.Equal(t, float64(http.StatusOK), logs[0]["status"])
+}
+
+func TestAccessLogSlowThreshold(t *testing.T) {
+	h, out := newAccessLogApp(t, middlewares.AccessLogConfig{SlowThreshold: 30 * time.Millisecond})
+	client := h.Client()
+	require.Equal(t, http.StatusOK, client.Get("/orders").StatusCode)
+	require.Equal(t, http.StatusOK, client.Get("/slow").StatusCode)
+
+	logs := accessLogs(t, out)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "info", logs[0]["level"])
+	assert.Equal(t, "request", logs[0]["msg"])
+	assert.Equal(t, "warn", logs[1]["level"])
+	assert.Equal(t, "slow request", logs[1]["msg"])
+	assert.Equal(t, "/slow", logs[1]["path"])
+}
+
+func TestAccessLogCaptureBody(t *testing.T) {
+	h, out := newAccessLogApp(t, middlewares.AccessLogConfig{
+		Fields:      []string{middlewares.AccessLogPath},
+		CaptureBody: true,
+		MaxBodySize: 40,
+		Redaction:   log.RedactConfig{Keys: []string{"card_number"}},
+	})
+	// the first 40 bytes cut the email address, which is masked before the body is truncated
+	body := `{"card_number":"4111","note":"mail ann.smith@example.com"}`
+	resp := h.Client().WithHeader(fiber.HeaderContentType, fiber.MIMEApplicationJSON).Post("/echo", body)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.String())
+	require.Equal(t, body, resp.String())
+
+	logs := accessLogs(t, out)
+	require.Len(t, logs, 1)
+	for _, field := range []string{"request_body", "response_body"} {
+		captured, _ := logs[0][field].(string)
+		assert.Equal(t, `{"card_number":"******","note":"mail ***...[truncated]`, captured, field)
+	}
+
+	// the bodies which are not textual are replaced by their size
+	out.Reset()
+	resp = h.Client().WithHeader(fiber.HeaderContentType, "application/octet-stream").Post("/echo", "binary")
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.String())
+	logs = accessLogs(t, out)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "[6 bytes]", logs[0]["request_body"])
+}
+
+func TestAccessLogInvalidConfig(t *testing.T) {
+	h := goetest.New(t)
+	_, err := middlewares.NewAccessLogMiddleware(h.App.Container(), middlewares.AccessLogConfig{Format: "xml"})
+	assert.EqualError(t, err, `unsupported access log format "xml", use json or text`)
+
+	_, err = middlewares.NewAccessLogMiddleware(h.App.Container(), middlewares.AccessLogConfig{
+		CaptureBody: true,
+		Redaction:   log.RedactConfig{Patterns: []string{"card=(\\d+"}},
+	})
+	assert.ErrorContains(t, err, "invalid log redaction pattern")
+}
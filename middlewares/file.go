@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"context"
 	"crypto/md5"
 	"fmt"
 	"github.com/gofiber/fiber/v3"
@@ -20,8 +21,9 @@ import (
 )
 
 type FileMiddlewares struct {
-	storage *s3minio.Storage
-	cfg     *FileMiddlewareConfig
+	container *core.Container
+	storage   *s3minio.Storage
+	cfg       *FileMiddlewareConfig
 }
 
 var defaultAllowedMimeTypes = []string{
@@ -123,43 +125,45 @@ var DefaultFileMiddlewareConfig = FileMiddlewareConfig{
 	HashRouteKey:     "hash",
 }
 
-func NewFileMiddlewares(config ...FileMiddlewareConfig) *FileMiddlewares {
-	bucket := core.UseGoeConfig().S3.Bucket
+// NewFileMiddlewares creates the file middlewares of the app owning the given container, e.g. goe.UseContainer() or app.Container().
+func NewFileMiddlewares(c *core.Container, config ...FileMiddlewareConfig) *FileMiddlewares {
+	s3Config := c.GetAppConfig().S3
+	bucket := s3Config.Bucket
 	if bucket == "" {
 		panic("S3 bucket is not configured")
 		return nil
 	}
-	endpoint := core.UseGoeConfig().S3.Endpoint
+	endpoint := s3Config.Endpoint
 	if endpoint == "" {
 		panic("S3 endpoint is not configured")
 		return nil
 	}
-	region := core.UseGoeConfig().S3.Region
+	region := s3Config.Region
 	if region == "" {
 		panic("S3 region is not configured")
 		return nil
 	}
 	bucketLookup := s3minio.BucketLookupAuto
-	if core.UseGoeConfig().S3.BucketLookup == "dns" {
+	if s3Config.BucketLookup == "dns" {
 		bucketLookup = s3minio.BucketLookupDNS
-	} else if core.UseGoeConfig().S3.BucketLookup == "path" {
+	} else if s3Config.BucketLookup == "path" {
 		bucketLookup = s3minio.BucketLookupPath
 	} else {
 		bucketLookup = s3minio.BucketLookupAuto
 	}
-	accessKey := core.UseGoeConfig().S3.AccessKey
-	secretKey := core.UseGoeConfig().S3.SecretKey
+	accessKey := s3Config.AccessKey
+	secretKey := s3Config.SecretKey
 	if accessKey == "" || secretKey == "" {
 		panic("S3 access key or secret key is not configured")
 		return nil
 	}
-	secure := core.UseGoeConfig().S3.UseSSL
+	secure := s3Config.UseSSL
 	store := s3minio.New(s3minio.Config{
 		Bucket:       bucket,
 		Endpoint:     endpoint,
 		Region:       region,
 		BucketLookup: bucketLookup,
-		Token:        core.UseGoeConfig().S3.Token,
+		Token:        s3Config.Token,
 		Secure:       secure,
 		Reset:        false,
 		Credentials: s3minio.Credentials{
@@ -171,10 +175,14 @@ func NewFileMiddlewares(config ...FileMiddlewareConfig) *FileMiddlewares {
 		ListObjectsOptions:  minio.ListObjectsOptions{},
 		RemoveObjectOptions: minio.RemoveObjectOptions{},
 	})
+	c.GetHealth().Register("s3", func(ctx context.Context) error {
+		return store.CheckBucket()
+	})
 	if len(config) == 0 {
 		return &FileMiddlewares{
-			storage: store,
-			cfg:     &DefaultFileMiddlewareConfig,
+			container: c,
+			storage:   store,
+			cfg:       &DefaultFileMiddlewareConfig,
 		}
 	}
 	if config[0].UploadLimit == 0 {
@@ -187,8 +195,9 @@ func NewFileMiddlewares(config ...FileMiddlewareConfig) *FileMiddlewares {
 		config[0].AllowedMimeTypes = DefaultFileMiddlewareConfig.AllowedMimeTypes
 	}
 	return &FileMiddlewares{
-		storage: store,
-		cfg:     &config[0],
+		container: c,
+		storage:   store,
+		cfg:       &config[0],
 	}
 }
 
@@ -230,19 +239,19 @@ func (m *FileMiddlewares) HandleUpload() fiber.Handler {
 				// Open the file, so we can read the content and calculate the hash
 				openedFile, err := file.Open()
 				if err != nil {
-					return webresult.SystemBusy(err)
+					return webresult.SystemBusyContext(ctx, err)
 				}
 				// Calculate the file hash
 				hasher := md5.New()
 				if _, err := copyIOZeroAlloc(hasher, openedFile); err != nil {
-					return webresult.SystemBusy(err)
+					return webresult.SystemBusyContext(ctx, err)
 				}
 				fileHash := fmt.Sprintf("%x", hasher.Sum(nil))
 				_ = openedFile.Close()
 
 				// check if file with same hash exist, if exist return the file info no more upload needed
 				fileInfo := &models.GoeFile{}
-				hasResult, err := core.UseGoeContainer().GetMongo().FindOne(fileInfo, bson.M{"hash": fileHash}, fileInfo)
+				hasResult, err := m.container.GetMongo().FindOne(fileInfo, bson.M{"hash": fileHash}, fileInfo)
 				if hasResult {
 					fileInfos = append(fileInfos, fileInfo)
 					continue
@@ -268,13 +277,13 @@ func (m *FileMiddlewares) HandleUpload() fiber.Handler {
 
 				// Save the file to storage
 				if err := ctx.SaveFileToStorage(file, fmt.Sprintf("%s", idealFileName), m.storage); err != nil {
-					return webresult.SystemBusy(err)
+					return webresult.SystemBusyContext(ctx, err)
 				}
 
 				// Save the file info to database
-				_, err = core.UseGoeContainer().GetMongo().Insert(fileInfo)
+				_, err = m.container.GetMongo().Insert(fileInfo)
 				if err != nil {
-					return webresult.SystemBusy(err)
+					return webresult.SystemBusyContext(ctx, err)
 				}
 				fileInfos = append(fileInfos, fileInfo)
 			}
@@ -319,18 +328,18 @@ func (m *FileMiddlewares) HandleView() fiber.Handler {
 
 		//find file info from database
 		fileInfo := &models.GoeFile{}
-		hasResult, err := core.UseGoeContainer().GetMongo().FindById(fileInfo, fileId, fileInfo)
+		hasResult, err := m.container.GetMongo().FindById(fileInfo, fileId, fileInfo)
 		if !hasResult {
 			return webresult.NotFound("file not found")
 		}
 		if err != nil {
-			return webresult.SystemBusy(err)
+			return webresult.SystemBusyContext(ctx, err)
 		}
 
 		//get file content from storage and display it
 		fileData, err := m.storage.Get(fmt.Sprintf("%s", fileInfo.UploadedName))
 		if err != nil {
-			core.UseGoeContainer().GetLogger().Warn("file not found in upstream storage or error from upstream: ", err)
+			m.container.GetLogger().Warn("file not found in upstream storage or error from upstream: ", err)
 		}
 		if fileData == nil {
 			return webresult.NotFound("file not found in upstream storage")
@@ -370,24 +379,24 @@ func (m *FileMiddlewares) HandleDelete() fiber.Handler {
 
 		//find file info from database
 		fileInfo := &models.GoeFile{}
-		hasResult, err := core.UseGoeContainer().GetMongo().FindById(fileInfo, fileId, fileInfo)
+		hasResult, err := m.container.GetMongo().FindById(fileInfo, fileId, fileInfo)
 		if !hasResult {
 			return webresult.NotFound("file not found")
 		}
 		if err != nil {
-			return webresult.SystemBusy(err)
+			return webresult.SystemBusyContext(ctx, err)
 		}
 
 		//delete file from storage
 		err = m.storage.Delete(fmt.Sprintf("%s", fileInfo.UploadedName))
 		if err != nil {
-			return webresult.SystemBusy(err)
+			return webresult.SystemBusyContext(ctx, err)
 		}
 
 		//delete file info from database
-		err = core.UseGoeContainer().GetMongo().Delete(fileInfo)
+		err = m.container.GetMongo().Delete(fileInfo)
 		if err != nil {
-			return webresult.SystemBusy(err)
+			return webresult.SystemBusyContext(ctx, err)
 		}
 
 		return webresult.SendSucceed(ctx)
@@ -409,12 +418,12 @@ func (m *FileMiddlewares) HandleMatch() fiber.Handler {
 
 		//find file info from database
 		fileInfo := &models.GoeFile{}
-		hasResult, err := core.UseGoeContainer().GetMongo().FindOne(fileInfo, bson.M{"hash": hash}, fileInfo)
+		hasResult, err := m.container.GetMongo().FindOne(fileInfo, bson.M{"hash": hash}, fileInfo)
 		if !hasResult {
 			return webresult.NotFound("hash not found")
 		}
 		if err != nil {
-			return webresult.SystemBusy(err)
+			return webresult.SystemBusyContext(ctx, err)
 		}
 
 		return webresult.SendSucceed(ctx, fileInfo)
@@ -6,39 +6,68 @@ import (
 	"github.com/gofiber/storage/redis/v3"
 	"github.com/gofiber/utils/v2"
 	"github.com/gookit/goutil/strutil"
-	"go.oease.dev/goe"
 	"go.oease.dev/goe/core"
 	"runtime"
+	"sync/atomic"
 	"time"
 )
 
-var rateLimiterStorage *redis.Storage
-
-func NewRateLimiter(qpm int) fiber.Handler {
-	if rateLimiterStorage == nil {
-		//create storage
-		redisPort := goe.UseCfg().GetOrDefaultInt("REDIS_PORT", 6379)
-		store := redis.New(redis.Config{
-			Host:     goe.UseCfg().GetOrDefaultString("REDIS_HOST", "localhost"),
+// getRateLimiterStorage returns the rate limiter storage of the container, so apps running in one process do not share counters.
+// The storage is closed when the app shuts down.
+func getRateLimiterStorage(c *core.Container) *redis.Storage {
+	return c.Resource("middlewares.rate_limiter", func() any {
+		cfg := c.GetConfig()
+		redisPort := cfg.GetOrDefaultInt("REDIS_PORT", 6379)
+		return redis.New(redis.Config{
+			Host:     cfg.GetOrDefaultString("REDIS_HOST", "localhost"),
 			Port:     redisPort,
-			Username: goe.UseCfg().GetOrDefaultString("REDIS_USERNAME", "default"),
-			Password: goe.UseCfg().GetOrDefaultString("REDIS_PASSWORD", ""),
+			Username: cfg.GetOrDefaultString("REDIS_USERNAME", "default"),
+			Password: cfg.GetOrDefaultString("REDIS_PASSWORD", ""),
 			Database: core.RedisDBRateLimiter,
 			PoolSize: 10 * runtime.GOMAXPROCS(0),
 		})
-		rateLimiterStorage = store
-	}
+	}).(*redis.Storage)
+}
+
+// NewRateLimiter creates the rate limiter of the app owning the given container, qpm is the max requests per minute of a client.
+func NewRateLimiter(c *core.Container, qpm int) fiber.Handler {
+	return newRateLimiter(c, func(fiber.Ctx) int {
+		return qpm
+	})
+}
+
+// NewConfigRateLimiter creates a rate limiter whose max requests per minute of a client is read from the config key, such as RATE_LIMIT_QPM.
+// The limit follows the changes of the key when the config is reloaded, defaultQPM is used when the key is not set or not a positive number.
+func NewConfigRateLimiter(c *core.Container, key string, defaultQPM int) fiber.Handler {
+	cfg := c.GetConfig()
+	var qpm atomic.Int64
+	qpm.Store(int64(cfg.GetOrDefaultInt(key, defaultQPM)))
+	cfg.OnChange(key, func(oldValue, newValue string) error {
+		qpm.Store(int64(cfg.GetOrDefaultInt(key, defaultQPM)))
+		return nil
+	})
+	return newRateLimiter(c, func(fiber.Ctx) int {
+		if n := int(qpm.Load()); n > 0 {
+			return n
+		}
+		return defaultQPM
+	})
+}
+
+func newRateLimiter(c *core.Container, maxFunc func(c fiber.Ctx) int) fiber.Handler {
+	store := getRateLimiterStorage(c)
+	appEnv := c.GetConfig().Get("APP_ENV")
 	return limiter.New(limiter.Config{
 		Next: func(c fiber.Ctx) bool {
-			return goe.UseCfg().Get("APP_ENV") != "prod" || c.IP() == "127.0.0.1" || c.IP() == "::1" || c.IP() == "localhost"
+			return appEnv != "prod" || c.IP() == "127.0.0.1" || c.IP() == "::1" || c.IP() == "localhost"
 		},
-		Max:          qpm,
+		MaxFunc:      maxFunc,
 		KeyGenerator: generateRequestKey,
 		Expiration:   1 * time.Minute,
 		LimitReached: func(ctx fiber.Ctx) error {
 			return fiber.NewError(fiber.StatusTooManyRequests, "too many requests")
 		},
-		Storage:           rateLimiterStorage,
+		Storage:           store,
 		LimiterMiddleware: limiter.SlidingWindow{},
 	})
 }
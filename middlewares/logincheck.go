@@ -3,6 +3,7 @@ package middlewares
 import (
 	"github.com/goccy/go-json"
 	"github.com/gofiber/fiber/v3"
+	"go.oease.dev/goe/core"
 	"go.oease.dev/goe/webresult"
 	"strings"
 )
@@ -19,6 +20,7 @@ import (
 // The UseSession function retrieves the session from the context.
 // The routePattern struct represents a route pattern with an HTTP method and path pattern.
 // The initSessionStore function initializes the session store.
+// The id of the logged-in user is added to the request logger, see goe.LogFrom.
 // This middleware is used to protect routes that require authentication, can be used as global middleware.
 func NewLoginCheckMiddleware(skipRoutes ...[]string) fiber.Handler {
 	return func(ctx fiber.Ctx) error {
@@ -29,6 +31,9 @@ func NewLoginCheckMiddleware(skipRoutes ...[]string) fiber.Handler {
 		}
 
 		if IsLoggedIn(ctx) {
+			if id := SessionUserID(ctx); id != "" {
+				core.AddLogFields(ctx, "user_id", id)
+			}
 			return ctx.Next()
 		}
 
@@ -53,7 +58,7 @@ func NewLoginInfoMiddleware() fiber.Handler {
 				userInfo := make(map[string]any)
 				err := json.Unmarshal(userData.([]byte), &userInfo)
 				if err != nil {
-					return webresult.SystemBusy(err)
+					return webresult.SystemBusyContext(ctx, err)
 				}
 				return webresult.SendSucceed(ctx, userInfo)
 			}
@@ -16,6 +16,7 @@ import (
 )
 
 type OIDCMiddleware struct {
+	container       *core.Container
 	cfg             *OIDCMiddlewareConfig
 	oauthStateStore *redis.Storage
 	oauthConfig     *oauth2.Config
@@ -34,7 +35,8 @@ var defaultOIDCMiddlewareConfig = OIDCMiddlewareConfig{
 // The function can be used to check user permission, roles, or fetch user data from database. If the function returns an error, the login process will be failed.
 type OAuthClaimDataProcessor func(claimData map[string]any) (any, error)
 
-func NewOIDCMiddleware(config ...OIDCMiddlewareConfig) *OIDCMiddleware {
+// NewOIDCMiddleware creates the OIDC middleware of the app owning the given container, e.g. goe.UseContainer() or app.Container().
+func NewOIDCMiddleware(c *core.Container, config ...OIDCMiddlewareConfig) *OIDCMiddleware {
 	cfg := defaultOIDCMiddlewareConfig
 	if len(config) > 0 {
 		cfg = config[0]
@@ -43,10 +45,11 @@ func NewOIDCMiddleware(config ...OIDCMiddlewareConfig) *OIDCMiddleware {
 		cfg.CallbackRedirectUri = defaultOIDCMiddlewareConfig.CallbackRedirectUri
 	}
 
-	oidcAppId := core.UseGoeConfig().OIDC.AppId
-	oidcAppSecret := core.UseGoeConfig().OIDC.AppSecret
-	oidcIssuer := core.UseGoeConfig().OIDC.Issuer
-	appScopes := core.UseGoeConfig().OIDC.AppScopes
+	appConfig := c.GetAppConfig()
+	oidcAppId := appConfig.OIDC.AppId
+	oidcAppSecret := appConfig.OIDC.AppSecret
+	oidcIssuer := appConfig.OIDC.Issuer
+	appScopes := appConfig.OIDC.AppScopes
 
 	if oidcAppId == "" || oidcAppSecret == "" || oidcIssuer == "" {
 		panic("OIDC config is not set properly!")
@@ -57,10 +60,10 @@ func NewOIDCMiddleware(config ...OIDCMiddlewareConfig) *OIDCMiddleware {
 	}
 
 	stateStore := redis.New(redis.Config{
-		Host:     core.UseGoeConfig().Redis.Host,
-		Port:     core.UseGoeConfig().Redis.Port,
-		Username: core.UseGoeConfig().Redis.Username,
-		Password: core.UseGoeConfig().Redis.Password,
+		Host:     appConfig.Redis.Host,
+		Port:     appConfig.Redis.Port,
+		Username: appConfig.Redis.Username,
+		Password: appConfig.Redis.Password,
 		Database: core.RedisDBAuthOAuthState,
 		PoolSize: 10 * runtime.GOMAXPROCS(0),
 	})
@@ -76,8 +79,9 @@ func NewOIDCMiddleware(config ...OIDCMiddlewareConfig) *OIDCMiddleware {
 		RedirectURL:  cfg.CallbackRedirectUri,
 		Scopes:       appScopes,
 	}
-	initSessionStore()
+	initSessionStore(c)
 	return &OIDCMiddleware{
+		container:       c,
 		cfg:             &cfg,
 		oauthStateStore: stateStore,
 		oauthConfig:     oauthConfig,
@@ -94,7 +98,7 @@ func (m *OIDCMiddleware) HandleLogin() fiber.Handler {
 		}
 		err := m.oauthStateStore.Set(authRequestStateKey, []byte(loginUri), time.Minute*5)
 		if err != nil {
-			return webresult.SystemBusy(err)
+			return webresult.SystemBusyContext(ctx, err)
 		}
 		return webresult.SendSucceed(ctx, fiber.Map{
 			"redirect": loginUri,
@@ -106,7 +110,7 @@ func (m *OIDCMiddleware) HandleLoginCallback(claimDataProcFunc ...OAuthClaimData
 	return func(ctx fiber.Ctx) error {
 		sess := UseSession(ctx)
 		if sess == nil {
-			return webresult.SystemBusy(errors.New("session not configured"))
+			return webresult.SystemBusyContext(ctx, errors.New("session not configured"))
 		}
 
 		if ctx.Query("error") != "" {
@@ -127,7 +131,7 @@ func (m *OIDCMiddleware) HandleLoginCallback(claimDataProcFunc ...OAuthClaimData
 			//userInfo := make(map[string]any)
 			//err := json.Unmarshal(userData.([]byte), &userInfo)
 			//if err != nil {
-			//	return webresult.SystemBusy(err)
+			//	return webresult.SystemBusyContext(ctx, err)
 			//}
 			//return webresult.SendSucceed(ctx, userInfo)
 			// user already logged in, but reset session to force re-login and update user information
@@ -143,7 +147,7 @@ func (m *OIDCMiddleware) HandleLoginCallback(claimDataProcFunc ...OAuthClaimData
 		}
 		stateBytes, err := m.oauthStateStore.Get(state)
 		if err != nil {
-			return webresult.SystemBusy(err)
+			return webresult.SystemBusyContext(ctx, err)
 		}
 		if stateBytes == nil {
 			return webresult.SendFailed(ctx, "Login request expired or invalid")
@@ -158,7 +162,7 @@ func (m *OIDCMiddleware) HandleLoginCallback(claimDataProcFunc ...OAuthClaimData
 		// Exchange code for tokens
 		token, err := m.oauthConfig.Exchange(ctx.Context(), code)
 		if err != nil {
-			return webresult.SystemBusy(err)
+			return webresult.SystemBusyContext(ctx, err)
 		}
 		if !token.Valid() {
 			return webresult.SendFailed(ctx, "Invalid OAuth token, or token expired")
@@ -177,18 +181,18 @@ func (m *OIDCMiddleware) HandleLoginCallback(claimDataProcFunc ...OAuthClaimData
 			InsecureSkipSignatureCheck: false,
 		}).Verify(ctx.Context(), rawIdToken)
 		if err != nil {
-			return webresult.SystemBusy(err)
+			return webresult.SystemBusyContext(ctx, err)
 		}
 
 		//err = verifiedIdToken.VerifyAccessToken(token.AccessToken)
 		//if err != nil {
-		//	return webresult.SystemBusy(err)
+		//	return webresult.SystemBusyContext(ctx, err)
 		//}
 
 		claimData := make(map[string]any)
 		err = verifiedIdToken.Claims(&claimData)
 		if err != nil {
-			return webresult.SystemBusy(err)
+			return webresult.SystemBusyContext(ctx, err)
 		}
 		var sessionUserData any
 		// Process claim data
@@ -200,7 +204,7 @@ func (m *OIDCMiddleware) HandleLoginCallback(claimDataProcFunc ...OAuthClaimData
 			}
 		} else {
 			// Process claim data with default processor
-			sessionUserData, err = defaultClaimDataProcessor(claimData)
+			sessionUserData, err = m.defaultClaimDataProcessor(claimData)
 			if err != nil {
 				return webresult.SendFailed(ctx, err.Error())
 			}
@@ -209,7 +213,7 @@ func (m *OIDCMiddleware) HandleLoginCallback(claimDataProcFunc ...OAuthClaimData
 		//save user info to session
 		codedUserInfo, err := json.Marshal(sessionUserData)
 		if err != nil {
-			return webresult.SystemBusy(err)
+			return webresult.SystemBusyContext(ctx, err)
 		}
 
 		sid := sess.ID()
@@ -224,21 +228,21 @@ func (m *OIDCMiddleware) HandleLoginCallback(claimDataProcFunc ...OAuthClaimData
 		// Fiber v3 beta.4 using session handler, manually saving no longer needed
 		//// Save session
 		//if err := sess.Save(); err != nil {
-		//	return webresult.SystemBusy(err)
+		//	return webresult.SystemBusyContext(ctx, err)
 		//}
 
 		// login success, invalid old state and redirect to home page
 		err = m.oauthStateStore.Delete(state)
 		if err != nil {
-			return webresult.SystemBusy(err)
+			return webresult.SystemBusyContext(ctx, err)
 		}
 
 		return webresult.SendSucceed(ctx, sessionUserData)
 	}
 }
 
-var defaultClaimDataProcessor = func(claimData map[string]any) (any, error) {
-	core.UseGoeContainer().GetLogger().Debug("Using default claim data processor.")
+func (m *OIDCMiddleware) defaultClaimDataProcessor(claimData map[string]any) (any, error) {
+	m.container.GetLogger().Debug("Using default claim data processor.")
 	// Default claim data processor, means that the claim data will be stored in session as is
 	return claimData, nil
 }
@@ -1,57 +1,70 @@
 package middlewares
 
 import (
+	"github.com/goccy/go-json"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/session"
 	"github.com/gofiber/storage/redis/v3"
 	"go.oease.dev/goe/core"
 	"runtime"
-	"sync"
+	"strconv"
 	"time"
 )
 
-var sessionStore *session.Store
-var sessionMiddleware fiber.Handler
-var once sync.Once
+type sessionEntry struct {
+	store      *session.Store
+	middleware fiber.Handler
+}
 
-func initSessionStore() {
-	if sessionStore != nil && sessionMiddleware != nil {
-		return
-	}
-	once.Do(func() {
-		store := redis.New(redis.Config{
-			Host:     core.UseGoeConfig().Redis.Host,
-			Port:     core.UseGoeConfig().Redis.Port,
-			Username: core.UseGoeConfig().Redis.Username,
-			Password: core.UseGoeConfig().Redis.Password,
-			Database: core.RedisDBAuthSession,
-			PoolSize: 10 * runtime.GOMAXPROCS(0),
-		})
+// Close closes the storage of the sessions, it is called when the app shuts down.
+func (e *sessionEntry) Close() error {
+	return e.store.Storage.Close()
+}
+
+// initSessionStore returns the session store of the container, so apps running in one process do not share sessions.
+func initSessionStore(c *core.Container) *sessionEntry {
+	return c.Resource("middlewares.session", func() any {
+		appConfig := c.GetAppConfig()
+		// sessions are kept in memory by the session middleware when the storage is nil,
+		// which is used when redis is not configured or the cache runs in memory, e.g. in local development and tests
+		var store fiber.Storage
+		if appConfig.Redis.Host != "" && (appConfig.Cache == nil || appConfig.Cache.Driver != core.CacheDriverMemory) {
+			store = redis.New(redis.Config{
+				Host:     appConfig.Redis.Host,
+				Port:     appConfig.Redis.Port,
+				Username: appConfig.Redis.Username,
+				Password: appConfig.Redis.Password,
+				Database: core.RedisDBAuthSession,
+				PoolSize: 10 * runtime.GOMAXPROCS(0),
+			})
+		}
 
 		//create session store
 		midw, sStore := session.NewWithStore(session.Config{
-			IdleTimeout:     time.Duration(core.UseGoeConfig().Session.Expiration) * time.Second,
+			IdleTimeout:     time.Duration(appConfig.Session.Expiration) * time.Second,
 			AbsoluteTimeout: 0,
 			Storage:         store,
-			KeyLookup:       core.UseGoeConfig().Session.KeyLookup,
+			KeyLookup:       appConfig.Session.KeyLookup,
 		})
-		sessionStore = sStore
-		sessionMiddleware = midw
-	})
+		return &sessionEntry{
+			store:      sStore,
+			middleware: midw,
+		}
+	}).(*sessionEntry)
 }
 
-func NewSessionMiddleware() fiber.Handler {
-	initSessionStore()
-	return sessionMiddleware
+// NewSessionMiddleware creates the session middleware of the app owning the given container.
+func NewSessionMiddleware(c *core.Container) fiber.Handler {
+	return initSessionStore(c).middleware
 }
 
-func GetSessionStore() *session.Store {
-	initSessionStore()
-	return sessionStore
+// GetSessionStore returns the session store of the app owning the given container.
+func GetSessionStore(c *core.Container) *session.Store {
+	return initSessionStore(c).store
 }
 
+// UseSession returns the session of the request, the session middleware must be registered before.
 func UseSession(ctx fiber.Ctx) *session.Middleware {
-	initSessionStore()
 	return session.FromContext(ctx)
 }
 
@@ -62,3 +75,30 @@ func IsLoggedIn(ctx fiber.Ctx) bool {
 	}
 	return false
 }
+
+// SessionUserID returns the id of the logged-in user, the id, sub or user_id claim of the user saved in the session, or an empty string.
+func SessionUserID(ctx fiber.Ctx) string {
+	s := UseSession(ctx)
+	if s == nil || s.Session == nil {
+		return ""
+	}
+	data, ok := s.Get("user").([]byte)
+	if !ok {
+		return ""
+	}
+	var user map[string]any
+	if err := json.Unmarshal(data, &user); err != nil {
+		return ""
+	}
+	for _, key := range []string{"id", "sub", "user_id"} {
+		switch id := user[key].(type) {
+		case string:
+			if id != "" {
+				return id
+			}
+		case float64:
+			return strconv.FormatFloat(id, 'f', -1, 64)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package middlewares_test
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.oease.dev/goe/goetest"
+	"go.oease.dev/goe/middlewares"
+	"testing"
+)
+
+func newSessionApp(t *testing.T) *goetest.Harness {
+	h := goetest.New(t)
+	app := h.App.Fiber().App()
+	app.Use(middlewares.NewSessionMiddleware(h.App.Container()))
+	app.Get("/name", func(ctx fiber.Ctx) error {
+		name, _ := middlewares.UseSession(ctx).Get("name").(string)
+		return ctx.SendString(name)
+	})
+	return h
+}
+
+func TestSessionsOfAppsAreIsolated(t *testing.T) {
+	a, b := newSessionApp(t), newSessionApp(t)
+	storeA := middlewares.GetSessionStore(a.App.Container())
+	storeB := middlewares.GetSessionStore(b.App.Container())
+	assert.Same(t, storeA, middlewares.GetSessionStore(a.App.Container()))
+	require.NotSame(t, storeA, storeB)
+
+	clientA := a.Client().WithSession(map[string]any{"name": "alice"})
+	clientB := b.Client().WithSession(map[string]any{"name": "bob"})
+	assert.Equal(t, "alice", clientA.Get("/name").String())
+	assert.Equal(t, "bob", clientB.Get("/name").String())
+
+	// the session id of an app is unknown to the other app
+	cookie := a.App.Config().Session.KeyLookup[len("cookie:"):]
+	var id string
+	for _, c := range clientA.Get("/name").Cookies {
+		if c.Name == cookie {
+			id = c.Value
+		}
+	}
+	require.NotEmpty(t, id)
+	resp := b.Client().WithHeader("Cookie", cookie+"="+id).Get("/name")
+	assert.Empty(t, resp.String())
+	data, err := storeB.Storage.Get(id)
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	// closing an app closes its session storage only
+	require.NoError(t, a.App.Container().Close())
+	assert.Equal(t, "bob", clientB.Get("/name").String())
+}
@@ -20,13 +20,13 @@ import (
 // and go client documentation at https://www.emqx.com/en/blog/how-to-use-mqtt-in-golang
 type EMQXConfig struct {
 	// ID client id
-	ID string `json:"id"`
+	ID string `json:"id" env:"HOST"`
 	// Addr emqx server addr, eg: tcp://127.0.0.1:1883
-	Addr string `json:"addr"`
+	Addr string `json:"addr" env:"ADDR" default:"tcp://localhost:1883"`
 	// Username
-	Username string `json:"username"`
+	Username string `json:"username" env:"USERNAME" default:"admin"`
 	// Password
-	Password string `json:"password"`
+	Password string `json:"password" env:"PASSWORD" default:"public" secret:"true"`
 	// MessageHandler is a callback type which can be set to be executed upon the arrival of messages published to topics to which the client is subscribed.
 	MessageHandler mqtt.MessageHandler `json:"-"`
 	// ConnectHandler OnConnectHandler is a callback that is called when the client state changes from unconnected/disconnected to connected.
@@ -34,7 +34,7 @@ type EMQXConfig struct {
 	OnConnectHandler mqtt.OnConnectHandler `json:"-"`
 	// ConnectionLostHandler is a callback that is called when the client loses its connection to the broker.
 	ConnectionLostHandler mqtt.ConnectionLostHandler `json:"-"`
-	TLSConfig             *TLSConfig                 `json:"TLSConfig"`
+	TLSConfig             *TLSConfig                 `json:"TLSConfig" prefix:"TLS_"`
 }
 
 func (cfg *EMQXConfig) Complete() {
@@ -77,10 +77,10 @@ func (cfg *EMQXConfig) Complete() {
 }
 
 type TLSConfig struct {
-	Enable   bool        `yaml:"enable"`
-	CA       string      `yaml:"ca"`
-	CertFile string      `yaml:"certFile"`
-	KeyFile  string      `yaml:"keyFile"`
+	Enable   bool        `yaml:"enable" env:"ENABLED"`
+	CA       string      `yaml:"ca" env:"CA" default:"ca.pem"`
+	CertFile string      `yaml:"certFile" env:"CERT_FILE" default:"client-crt.pem"`
+	KeyFile  string      `yaml:"keyFile" env:"KEY_FILE" default:"client-key.pem"`
 	TLS      *tls.Config `yaml:"-"`
 }
 
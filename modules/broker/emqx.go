@@ -7,9 +7,14 @@
 package broker
 
 import (
+	"context"
 	"fmt"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"go.oease.dev/goe/contracts"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 	"go.uber.org/zap"
 	"time"
 )
@@ -17,6 +22,9 @@ import (
 type EMQX struct {
 	client mqtt.Client
 	logger *zap.Logger
+	tracer trace.Tracer
+	// ctx is the context set by WithContext, the publish spans are children of its span
+	ctx context.Context
 }
 
 func NewEMQX(c *EMQXConfig) (contracts.EMQX, error) {
@@ -41,6 +49,7 @@ func NewEMQX(c *EMQXConfig) (contracts.EMQX, error) {
 	bk := &EMQX{
 		client: _client,
 		logger: zap.L().With(zap.String("module", "emqx")),
+		ctx:    context.Background(),
 	}
 
 	return bk, nil
@@ -72,16 +81,54 @@ func (b *EMQX) Unsubscribe(module string) error {
 	return nil
 }
 
+func (b *EMQX) IsConnected() bool {
+	return b.client.IsConnected()
+}
+
 func (b *EMQX) Close() {
 	// close connection
 	b.client.Disconnect(250)
 	b.logger.Debug("exit emqx broker successfully")
 }
 
+// WithLogger sets the logger of the client, default is the global zap logger.
+func (b *EMQX) WithLogger(logger *zap.Logger) *EMQX {
+	b.logger = logger
+	return b
+}
+
+// WithTracer sets the tracer starting a span for each publication.
+func (b *EMQX) WithTracer(tracer trace.Tracer) *EMQX {
+	b.tracer = tracer
+	return b
+}
+
+// WithContext returns a client sharing the connection, whose publish spans are children of the span in ctx.
+func (b *EMQX) WithContext(ctx context.Context) contracts.EMQX {
+	c := *b
+	c.ctx = ctx
+	return &c
+}
+
 func (b *EMQX) Publish(topic string, qos byte, retained bool, payload any) error {
+	tracer := b.tracer
+	if tracer == nil {
+		tracer = noop.NewTracerProvider().Tracer("")
+	}
+	_, span := tracer.Start(b.ctx, "mqtt.publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "mqtt"),
+			attribute.String("messaging.destination.name", topic),
+			attribute.Int("messaging.mqtt.qos", int(qos)),
+		),
+	)
+	defer span.End()
 	token := b.client.Publish(topic, qos, retained, payload)
 	token.Wait()
 	if token.Error() != nil {
+		span.RecordError(token.Error())
+		span.SetStatus(codes.Error, token.Error().Error())
 		b.logger.Error("MQTT publish failed", zap.Any("payload", payload), zap.Error(token.Error()))
 		return token.Error()
 	}
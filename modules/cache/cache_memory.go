@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/goccy/go-json"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time // zero means never expires
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// MemoryCache is an in-process cache with TTL support, it can replace RedisCache for local development and unit tests.
+// Entries are not shared between processes and are lost when the process exits.
+type MemoryCache struct {
+	mu       sync.RWMutex
+	entries  map[string]*memoryEntry
+	logger   Logger
+	observer Observer
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// NewMemoryCache creates an in-process cache, expired entries are removed every cleanupInterval, 0 means every minute.
+func NewMemoryCache(cleanupInterval time.Duration, logger ...Logger) *MemoryCache {
+	if cleanupInterval <= 0 {
+		cleanupInterval = time.Minute
+	}
+	mc := &MemoryCache{
+		entries: make(map[string]*memoryEntry),
+		stop:    make(chan struct{}),
+	}
+	if len(logger) > 0 && logger[0] != nil {
+		mc.logger = logger[0]
+	} else {
+		mc.logger = newDefaultLogger()
+	}
+	go mc.cleanup(cleanupInterval)
+	return mc
+}
+
+// WithObserver sets the observer notified of the cache hits and misses.
+func (m *MemoryCache) WithObserver(observer Observer) *MemoryCache {
+	m.observer = observer
+	return m
+}
+
+func (m *MemoryCache) Get(key string) []byte {
+	m.mu.RLock()
+	entry, ok := m.entries[key]
+	m.mu.RUnlock()
+	hit := ok && !entry.expired(time.Now())
+	if m.observer != nil {
+		m.observer.ObserveCacheGet(hit)
+	}
+	if !hit {
+		return nil
+	}
+	// return a copy, so callers cannot modify the cached value
+	return append([]byte(nil), entry.value...)
+}
+
+func (m *MemoryCache) GetBind(key string, bindPtr any) error {
+	res := m.Get(key)
+	if res == nil {
+		return nil
+	}
+	return json.Unmarshal(res, bindPtr)
+}
+
+// Set stores the value, an expire of 0 means the value never expires.
+func (m *MemoryCache) Set(key string, value []byte, expire time.Duration) error {
+	if key == "" || len(value) == 0 {
+		// same as the redis storage, empty keys and values are ignored
+		return nil
+	}
+	entry := &memoryEntry{value: append([]byte(nil), value...)}
+	if expire > 0 {
+		entry.expiresAt = time.Now().Add(expire)
+	}
+	m.mu.Lock()
+	m.entries[key] = entry
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryCache) SetBind(key string, bindPtr any, expire time.Duration) error {
+	if bindPtr == nil {
+		return errors.New("bindPtr is nil")
+	}
+	b, err := json.Marshal(bindPtr)
+	if err != nil {
+		m.logger.Error(err)
+		return err
+	}
+	return m.Set(key, b, expire)
+}
+
+func (m *MemoryCache) Delete(key string) error {
+	m.mu.Lock()
+	delete(m.entries, key)
+	m.mu.Unlock()
+	return nil
+}
+
+// Ping always succeeds, it keeps the same health check interface as RedisCache.
+func (m *MemoryCache) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close stops the cleanup goroutine and removes all entries.
+func (m *MemoryCache) Close() error {
+	m.once.Do(func() {
+		close(m.stop)
+		m.mu.Lock()
+		m.entries = make(map[string]*memoryEntry)
+		m.mu.Unlock()
+	})
+	return nil
+}
+
+func (m *MemoryCache) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			m.mu.Lock()
+			for key, entry := range m.entries {
+				if entry.expired(now) {
+					delete(m.entries, key)
+				}
+			}
+			m.mu.Unlock()
+		case <-m.stop:
+			return
+		}
+	}
+}
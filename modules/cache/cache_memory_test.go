@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheSetAndGet(t *testing.T) {
+	mc := NewMemoryCache(0)
+	defer mc.Close()
+
+	value := []byte("doe")
+	err := mc.Set("testKey", value, 0)
+	assert.NoError(t, err, "Should not return an error")
+	assert.Equal(t, value, mc.Get("testKey"), "Stored value should match the set value")
+
+	// the cached value is a copy
+	value[0] = 'x'
+	assert.Equal(t, []byte("doe"), mc.Get("testKey"))
+
+	assert.NoError(t, mc.Delete("testKey"))
+	assert.Nil(t, mc.Get("testKey"), "Deleted value should not be found")
+}
+
+func TestMemoryCacheExpiration(t *testing.T) {
+	mc := NewMemoryCache(10 * time.Millisecond)
+	defer mc.Close()
+
+	err := mc.Set("expKey", []byte("value"), 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), mc.Get("expKey"))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, mc.Get("expKey"), "Expired value should not be found")
+
+	mc.mu.RLock()
+	_, ok := mc.entries["expKey"]
+	mc.mu.RUnlock()
+	assert.False(t, ok, "Expired entry should be removed by the cleanup")
+}
+
+func TestMemoryCacheBind(t *testing.T) {
+	mc := NewMemoryCache(0)
+	defer mc.Close()
+
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	err := mc.SetBind("user", &user{Name: "John", Age: 30}, time.Minute)
+	assert.NoError(t, err)
+
+	var u user
+	assert.NoError(t, mc.GetBind("user", &u))
+	assert.Equal(t, user{Name: "John", Age: 30}, u)
+
+	var missing user
+	assert.NoError(t, mc.GetBind("missing", &missing))
+	assert.Equal(t, user{}, missing)
+
+	assert.Error(t, mc.SetBind("nil", nil, 0))
+}
+
+type countingObserver struct {
+	hits, misses int
+}
+
+func (o *countingObserver) ObserveCacheGet(hit bool) {
+	if hit {
+		o.hits++
+	} else {
+		o.misses++
+	}
+}
+
+func TestMemoryCacheObserver(t *testing.T) {
+	observer := &countingObserver{}
+	mc := NewMemoryCache(0).WithObserver(observer)
+	defer mc.Close()
+
+	assert.NoError(t, mc.Set("key", []byte("value"), 0))
+	mc.Get("key")
+	mc.Get("missing")
+	var v string
+	assert.NoError(t, mc.GetBind("missing", &v))
+
+	assert.Equal(t, 1, observer.hits)
+	assert.Equal(t, 2, observer.misses)
+}
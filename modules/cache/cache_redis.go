@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"context"
 	"errors"
 	"github.com/goccy/go-json"
 	"github.com/gofiber/storage/redis/v3"
@@ -9,8 +10,9 @@ import (
 )
 
 type RedisCache struct {
-	store  *redis.Storage
-	logger Logger
+	store    *redis.Storage
+	logger   Logger
+	observer Observer
 }
 
 func NewRedisCache(redisHost string, redisPort int, redisUsername string, redisPassword string, redisDB int, logger ...Logger) *RedisCache {
@@ -31,12 +33,19 @@ func NewRedisCache(redisHost string, redisPort int, redisUsername string, redisP
 	return rc
 }
 
+// WithObserver sets the observer notified of the cache hits and misses.
+func (r *RedisCache) WithObserver(observer Observer) *RedisCache {
+	r.observer = observer
+	return r
+}
+
 func (r *RedisCache) Get(key string) []byte {
 	res, err := r.store.Get(key)
 	if err != nil {
 		r.logger.Error(err)
 		return nil
 	}
+	r.observe(res != nil)
 	return res
 }
 
@@ -46,6 +55,7 @@ func (r *RedisCache) GetBind(key string, bindPtr any) error {
 		r.logger.Error(err)
 		return err
 	}
+	r.observe(res != nil)
 	if res == nil {
 		bindPtr = nil
 		return nil
@@ -73,6 +83,17 @@ func (r *RedisCache) Delete(key string) error {
 	return r.store.Delete(key)
 }
 
+// Ping checks the connection to the redis server.
+func (r *RedisCache) Ping(ctx context.Context) error {
+	return r.store.Conn().Ping(ctx).Err()
+}
+
 func (r *RedisCache) Close() error {
 	return r.store.Close()
 }
+
+func (r *RedisCache) observe(hit bool) {
+	if r.observer != nil {
+		r.observer.ObserveCacheGet(hit)
+	}
+}
@@ -0,0 +1,6 @@
+package cache
+
+// Observer is notified of every cache lookup, it is used to collect hit and miss metrics.
+type Observer interface {
+	ObserveCacheGet(hit bool)
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Getter is the source of the values bound by Bind, it is implemented by Config and contracts.Config.
+type Getter interface {
+	Get(key string) string
+}
+
+// ErrMissing is the error of a required value which is not set and has no default.
+var ErrMissing = errors.New("required value is missing")
+
+// FieldError is a value which could not be bound to a struct field.
+type FieldError struct {
+	// Key is the config key of the field, including the prefixes of its parents
+	Key string
+	// Field is the path of the struct field, such as Http.Port
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Key + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// BindError reports all the missing and invalid values found by Bind.
+type BindError struct {
+	Errors []*FieldError
+}
+
+func (e *BindError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return fmt.Sprintf("invalid configuration, %d error(s): %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the field errors, so that errors.Is(err, ErrMissing) reports whether a required value is missing.
+func (e *BindError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// Bind sets the fields of the struct ptr points to from the config, it is a shortcut of Bind(c, ptr).
+func (c *Config) Bind(ptr any) error {
+	return Bind(c, ptr)
+}
+
+// Bind sets the fields of the struct ptr points to from the values of src, according to the field tags:
+//
+//	env:"KEY"          the key of the value, fields without env or prefix tag are left untouched
+//	default:"value"    the value used when the key is not set or empty
+//	required:"true"    the key must be set or have a default
+//	prefix:"HTTP_"     on a struct or struct pointer field, binds its fields with keys prefixed by HTTP_, the prefix can be empty
+//	secret:"true"      the value is a secret, the key is marked with MarkSecret if src implements it, see Config.IsSecret
+//
+// Supported field types are strings, bools, ints, uints, floats, time.Duration (such as 1m30s), types implementing encoding.TextUnmarshaler,
+// pointers to them, comma separated slices of them, and comma separated maps of key:value pairs.
+// All the missing and invalid values are reported together in a *BindError, the fields of the valid values are set anyway.
+func Bind(src Getter, ptr any) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("config: bind target must be a non-nil pointer to a struct")
+	}
+	b := &binder{src: src}
+	b.bindStruct(rv.Elem(), "", "")
+	if marker, ok := src.(interface{ MarkSecret(keys ...string) }); ok && len(b.secrets) > 0 {
+		marker.MarkSecret(b.secrets...)
+	}
+	if len(b.errs) > 0 {
+		return &BindError{Errors: b.errs}
+	}
+	return nil
+}
+
+type binder struct {
+	src     Getter
+	errs    []*FieldError
+	secrets []string
+}
+
+func (b *binder) bindStruct(v reflect.Value, prefix string, path string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		field := v.Field(i)
+		fieldPath := sf.Name
+		if path != "" {
+			fieldPath = path + "." + sf.Name
+		}
+		if nested, ok := sf.Tag.Lookup("prefix"); ok {
+			if field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.Struct {
+				if field.IsNil() {
+					field.Set(reflect.New(field.Type().Elem()))
+				}
+				field = field.Elem()
+			}
+			if field.Kind() != reflect.Struct {
+				b.errs = append(b.errs, &FieldError{Key: prefix + nested, Field: fieldPath, Err: errors.New("prefix tag on a field which is not a struct")})
+				continue
+			}
+			b.bindStruct(field, prefix+nested, fieldPath)
+			continue
+		}
+		key, ok := sf.Tag.Lookup("env")
+		if !ok || key == "" {
+			continue
+		}
+		key = prefix + key
+		if sf.Tag.Get("secret") == "true" {
+			b.secrets = append(b.secrets, key)
+		}
+		value := b.src.Get(key)
+		if value == "" {
+			value = sf.Tag.Get("default")
+		}
+		if value == "" {
+			if sf.Tag.Get("required") == "true" {
+				b.errs = append(b.errs, &FieldError{Key: key, Field: fieldPath, Err: ErrMissing})
+			}
+			continue
+		}
+		if err := setValue(field, value); err != nil {
+			// the value is already in the message, only keep the reason of the strconv errors
+			var numErr *strconv.NumError
+			if errors.As(err, &numErr) {
+				err = numErr.Err
+			}
+			b.errs = append(b.errs, &FieldError{Key: key, Field: fieldPath, Err: fmt.Errorf("invalid value %q: %w", value, err)})
+		}
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue parses the value into the field, slices and maps are comma separated.
+func setValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := setValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+	switch field.Kind() {
+	case reflect.Slice:
+		parts := splitList(value)
+		slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setValue(slice.Index(i), part); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	case reflect.Map:
+		m := reflect.MakeMap(field.Type())
+		for _, part := range splitList(value) {
+			k, v, found := strings.Cut(part, ":")
+			if !found {
+				return fmt.Errorf("map entry %q is not a key:value pair", part)
+			}
+			key := reflect.New(field.Type().Key()).Elem()
+			if err := setValue(key, strings.TrimSpace(k)); err != nil {
+				return err
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setValue(elem, strings.TrimSpace(v)); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, elem)
+		}
+		field.Set(m)
+		return nil
+	}
+	return setScalar(field, value)
+}
+
+func setScalar(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(v)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// splitList splits a comma separated value and trims the spaces around the items, like GetStringSlice.
+func splitList(value string) []string {
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return parts
+}
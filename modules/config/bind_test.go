@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type bindHttp struct {
+	Port    int           `env:"PORT" default:"3000"`
+	Timeout time.Duration `env:"TIMEOUT" default:"5s"`
+	Origins []string      `env:"ORIGINS"`
+}
+
+type bindSettings struct {
+	Name    string         `env:"APP_NAME" required:"true"`
+	Ratio   float64        `env:"RATIO" default:"0.5"`
+	Debug   bool           `env:"DEBUG"`
+	Limit   *int           `env:"LIMIT"`
+	Weights map[string]int `env:"WEIGHTS"`
+	Http    *bindHttp      `prefix:"HTTP_"`
+	Admin   bindHttp       `prefix:"ADMIN_"`
+	Ignored string
+}
+
+func TestBind(t *testing.T) {
+	config := NewFromMap(map[string]string{
+		"APP_NAME":      "orders",
+		"DEBUG":         "true",
+		"WEIGHTS":       "a:1, b:2",
+		"HTTP_PORT":     "8080",
+		"HTTP_ORIGINS":  "https://a.io, https://b.io",
+		"ADMIN_TIMEOUT": "1m30s",
+	})
+	var s bindSettings
+	assert.NoError(t, config.Bind(&s))
+	assert.Equal(t, "orders", s.Name)
+	assert.Equal(t, 0.5, s.Ratio)
+	assert.True(t, s.Debug)
+	assert.Nil(t, s.Limit)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, s.Weights)
+	assert.Equal(t, 8080, s.Http.Port)
+	assert.Equal(t, 5*time.Second, s.Http.Timeout)
+	assert.Equal(t, []string{"https://a.io", "https://b.io"}, s.Http.Origins)
+	assert.Equal(t, 3000, s.Admin.Port)
+	assert.Equal(t, 90*time.Second, s.Admin.Timeout)
+	assert.Nil(t, s.Admin.Origins)
+}
+
+func TestBindErrors(t *testing.T) {
+	config := NewFromMap(map[string]string{
+		"RATIO":        "half",
+		"LIMIT":        "10",
+		"WEIGHTS":      "a=1",
+		"HTTP_TIMEOUT": "5",
+	})
+	var s bindSettings
+	err := config.Bind(&s)
+	var bindErr *BindError
+	assert.True(t, errors.As(err, &bindErr))
+	keys := make([]string, 0, len(bindErr.Errors))
+	for _, fe := range bindErr.Errors {
+		keys = append(keys, fe.Key)
+	}
+	assert.Equal(t, []string{"APP_NAME", "RATIO", "WEIGHTS", "HTTP_TIMEOUT"}, keys)
+	assert.True(t, errors.Is(err, ErrMissing))
+	assert.Equal(t, "Http.Timeout", bindErr.Errors[3].Field)
+	assert.Contains(t, err.Error(), `RATIO: invalid value "half": invalid syntax`)
+	// the valid values are bound anyway
+	assert.Equal(t, 10, *s.Limit)
+}
+
+func TestBindTarget(t *testing.T) {
+	var s bindSettings
+	assert.Error(t, Bind(NewFromMap(nil), s))
+	assert.Error(t, Bind(NewFromMap(nil), (*bindSettings)(nil)))
+}
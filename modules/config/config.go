@@ -1,86 +1,310 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/joho/godotenv"
 	"go.oease.dev/goe/utils"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 const (
-	defaultFileName         = "/.env"
-	defaultOverrideFileName = "/.local.env"
+	configFileName = "config"
+	envFileName    = ".env"
+	// localEnv is the suffix of the override files loaded when APP_ENV is not set
+	localEnv = "local"
 )
 
+// configFileExts are the extensions of the structured config files, when several exist they are loaded in this order.
+var configFileExts = []string{".yaml", ".yml", ".json", ".toml"}
+
 type Config struct {
+	mu     sync.RWMutex
 	envMap map[string]string
+	// secrets holds the keys of the values resolved from secrets, marked holds the keys marked by MarkSecret, see IsSecret
+	secrets map[string]bool
+	marked  map[string]bool
+	// loader reloads the values, see Reload
+	loader *loader
+	// reloadMu serializes the reloads, so that the subscribers see the changes in order
+	reloadMu sync.Mutex
+	// subMu guards the subscribers, so that they can subscribe while they are notified
+	subMu     sync.Mutex
+	listeners map[string][]func(oldValue, newValue string) error
+	onReload  []func(changes []Change)
+}
+
+// Option customizes how the configuration is loaded.
+type Option func(l *loader)
+
+// WithOverrides sets values taking precedence over all the other sources, such as the values given on the command line.
+// The keys are normalized like the keys of the config files, so http.port overrides HTTP_PORT.
+func WithOverrides(values map[string]string) Option {
+	return func(l *loader) {
+		for k, v := range values {
+			l.overrides[normalizeKey(k)] = v
+		}
+	}
 }
 
-func New(folder string) *Config {
-	c := &Config{}
-	c.read(folder)
-	envMap := make(map[string]string)
-	for _, env := range os.Environ() {
-		pair := splitEnvVar(env)
-		envMap[pair[0]] = pair[1]
+// WithSource adds a key-value source, such as a Redis hash, its values take precedence over the config files and the env files.
+func WithSource(src Source) Option {
+	return func(l *loader) {
+		l.sources = append(l.sources, src)
+	}
+}
+
+// New loads the configuration of the folder, see Load for the sources and their precedence.
+// A config file which cannot be read is reported on stdout and skipped, use Load to handle the error.
+func New(folder string, opts ...Option) *Config {
+	c, err := load(folder, opts...)
+	if err != nil {
+		fmt.Printf("Failed to load config: %v \n", err)
 	}
-	c.envMap = envMap
 	return c
 }
 
-// splitEnvVar splits the environment variable string into a key-value pair
-func splitEnvVar(env string) [2]string {
-	var pair [2]string
-	for i := range env {
-		if env[i] == '=' {
-			pair[0] = env[:i]
-			pair[1] = env[i+1:]
-			break
+// Load loads the configuration of the folder, from the lowest to the highest precedence:
+//
+//  1. config.yaml, config.yml, config.json or config.toml, nested keys are flattened, so http.port is read as HTTP_PORT
+//  2. config.<APP_ENV>.yaml and the other formats, or config.local.yaml when APP_ENV is not set
+//  3. .env
+//  4. .<APP_ENV>.env, or .local.env when APP_ENV is not set
+//  5. the sources of WithSource and AddSource, in the order they are added
+//  6. the process environment variables
+//  7. the values of WithOverrides, such as the command line flags
+//
+// The secrets are resolved on the merged values: KEY_FILE reads the value of KEY from a file, such as SMTP_PASSWORD_FILE=/run/secrets/smtp,
+// the values prefixed by enc: are decrypted with CONFIG_MASTER_KEY, and the values prefixed by the scheme of a WithSecretProvider provider are resolved by it.
+// The defaults given to the GetOrDefault methods and the default tags of Bind apply when no source has the key.
+// Missing files are skipped, a file which cannot be parsed makes Load return an error.
+// The values of the env files which are not set in the process environment are exported to it, as godotenv.Load does,
+// so that the libraries reading the environment see them, such as the OTLP exporter reading OTEL_EXPORTER_OTLP_ENDPOINT.
+func Load(folder string, opts ...Option) (*Config, error) {
+	c, err := load(folder, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewFromMap creates a config holding only the given values, without reading env files or environment variables.
+// Reload only reads the sources added by AddSource, on top of the values.
+func NewFromMap(values map[string]string) *Config {
+	l := newLoader()
+	for k, v := range values {
+		l.base[k] = v
+	}
+	envMap, secrets, _ := l.load()
+	return &Config{envMap: envMap, secrets: secrets, loader: l}
+}
+
+// load returns the config of the sources which could be read, together with the errors of the others.
+func load(folder string, opts ...Option) (*Config, error) {
+	l := newLoader()
+	l.folder = folder
+	l.environ = true
+	for _, opt := range opts {
+		if opt != nil {
+			opt(l)
 		}
 	}
-	return pair
+	envMap, secrets, err := l.load()
+	return &Config{envMap: envMap, secrets: secrets, loader: l}, err
 }
 
-func (c *Config) read(folder string) {
-	var (
-		defaultFile  = folder + defaultFileName
-		overrideFile = folder + defaultOverrideFileName
-		env          = c.Get("APP_ENV")
-	)
+type loader struct {
+	// folder is empty for the configs created from a map, no file is read then
+	folder string
+	// environ reports whether the process environment variables are read
+	environ   bool
+	base      map[string]string
+	sources   []Source
+	providers []SecretProvider
+	overrides map[string]string
+	// secretFiles holds the keys whose value can be read from the file of KEY_FILE, see WithSecretFiles
+	secretFiles map[string]bool
+	// quiet is set after the first load, so that the reloads do not print the loaded files
+	quiet bool
+	errs  []error
+}
 
-	err := godotenv.Load(defaultFile)
-	if err != nil {
-		//fmt.Printf("Failed to load config from file: %v, Err: %v \n", defaultFile, err)
-	} else {
-		fmt.Printf("Loaded config from file: %v \n", defaultFile)
+func newLoader() *loader {
+	return &loader{
+		base:        make(map[string]string),
+		overrides:   make(map[string]string),
+		secretFiles: map[string]bool{MasterKeyName: true},
+	}
+}
+
+// load returns the merged values of the layers, with the secrets resolved, and the keys of the secrets.
+func (l *loader) load() (map[string]string, map[string]bool, error) {
+	l.errs = nil
+	defer func() {
+		l.quiet = true
+	}()
+	files := make(map[string]string)
+	envFiles := make(map[string]string)
+	environ := make(map[string]string)
+	if l.environ {
+		exportedEnv.Lock()
+		for _, env := range os.Environ() {
+			pair := splitEnvVar(env)
+			// the values exported by the last load are read from the env files again, so that a reload sees their changes
+			if v, ok := exportedEnv.values[pair[0]]; ok && v == pair[1] {
+				continue
+			}
+			environ[pair[0]] = pair[1]
+		}
+		exportedEnv.Unlock()
+	}
+
+	if l.folder != "" {
+		l.readConfigFiles(configFileName, files)
+		l.readEnvFile(envFileName, envFiles)
+		// APP_ENV selects the override files, so it is resolved from the sources read so far
+		env := localEnv
+		for _, source := range []map[string]string{l.overrides, environ, envFiles, files} {
+			if v := source["APP_ENV"]; v != "" {
+				env = v
+				break
+			}
+		}
+		l.readConfigFiles(configFileName+"."+env, files)
+		l.readEnvFile("."+env+envFileName, envFiles)
+	}
+	if l.environ {
+		l.exportEnvFiles(envFiles, environ)
+	}
+	remote := l.readSources()
+
+	layers := []map[string]string{l.base, files, envFiles, remote, environ, l.overrides}
+	size := 0
+	for _, layer := range layers {
+		size += len(layer)
+	}
+	envMap := make(map[string]string, size)
+	for _, layer := range layers {
+		for k, v := range layer {
+			envMap[k] = v
+		}
 	}
+	secrets := l.resolveSecrets(envMap)
+	return envMap, secrets, errors.Join(l.errs...)
+}
 
-	switch env {
-	case "":
-		// If 'APP_ENV' is not set, then Goe will read '.env' from configs directory, and then it will be overwritten
-		// by configs present in file '.local.env'
-		err = godotenv.Overload(overrideFile)
+// readSources reads the key-value sources, a source which fails is skipped.
+func (l *loader) readSources() map[string]string {
+	values := make(map[string]string)
+	for _, src := range l.sources {
+		ctx, cancel := context.WithTimeout(context.Background(), sourceTimeout)
+		srcValues, err := src.Values(ctx)
+		cancel()
 		if err != nil {
-			//fmt.Printf("Failed to load config from file: %v, Err: %v \n", overrideFile, err)
-		} else {
-			fmt.Printf("Loaded config from file: %v \n", overrideFile)
+			l.errs = append(l.errs, fmt.Errorf("config source %s: %w", src.Name(), err))
+			continue
 		}
-	default:
-		// If 'APP_ENV' is set to x, then Goe will read '.env' from configs directory, and then it will be overwritten
-		// by configs present in file '.x.env'
-		overrideFile = fmt.Sprintf("%s/.%s.env \n", folder, env)
-		err = godotenv.Overload(overrideFile)
+		for k, v := range srcValues {
+			values[normalizeKey(k)] = v
+		}
+	}
+	return values
+}
+
+// readConfigFiles reads the structured config files with the base name, in the order of configFileExts.
+func (l *loader) readConfigFiles(name string, values map[string]string) {
+	for _, ext := range configFileExts {
+		file := filepath.Join(l.folder, name+ext)
+		data, err := os.ReadFile(file)
 		if err != nil {
-			//fmt.Printf("Failed to load config from file: %v, Err: %v \n", overrideFile, err)
-		} else {
-			fmt.Printf("Loaded config from file: %v \n", overrideFile)
+			if !errors.Is(err, fs.ErrNotExist) {
+				l.errs = append(l.errs, err)
+			}
+			continue
+		}
+		if err := decodeFile(ext, data, values); err != nil {
+			l.errs = append(l.errs, fmt.Errorf("%s: %w", file, err))
+			continue
+		}
+		if !l.quiet {
+			fmt.Printf("Loaded config from file: %v \n", file)
 		}
 	}
 }
 
+func (l *loader) readEnvFile(name string, values map[string]string) {
+	file := filepath.Join(l.folder, name)
+	fileValues, err := godotenv.Read(file)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			l.errs = append(l.errs, fmt.Errorf("%s: %w", file, err))
+		}
+		return
+	}
+	for k, v := range fileValues {
+		values[k] = v
+	}
+	if !l.quiet {
+		fmt.Printf("Loaded config from file: %v \n", file)
+	}
+}
+
+// exportedEnv holds the values of the env files exported to the process environment by the last load,
+// it is shared by the loaders as they share the process environment.
+var exportedEnv = struct {
+	sync.Mutex
+	values map[string]string
+}{values: make(map[string]string)}
+
+// exportEnvFiles sets the values of the env files which are not set in the process environment,
+// and unsets the values exported by the last load which are no longer in the env files.
+func (l *loader) exportEnvFiles(envFiles, environ map[string]string) {
+	exportedEnv.Lock()
+	defer exportedEnv.Unlock()
+	for k := range exportedEnv.values {
+		if _, ok := envFiles[k]; ok {
+			continue
+		}
+		if _, set := environ[k]; !set {
+			_ = os.Unsetenv(k)
+		}
+		delete(exportedEnv.values, k)
+	}
+	for k, v := range envFiles {
+		if _, set := environ[k]; set {
+			delete(exportedEnv.values, k)
+			continue
+		}
+		if err := os.Setenv(k, v); err != nil {
+			l.errs = append(l.errs, fmt.Errorf("export %s: %w", k, err))
+			continue
+		}
+		exportedEnv.values[k] = v
+	}
+}
+
+// splitEnvVar splits the environment variable string into a key-value pair
+func splitEnvVar(env string) [2]string {
+	var pair [2]string
+	for i := range env {
+		if env[i] == '=' {
+			pair[0] = env[:i]
+			pair[1] = env[i+1:]
+			break
+		}
+	}
+	return pair
+}
+
 func (c *Config) Get(key string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.envMap[key]
 }
 
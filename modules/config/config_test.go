@@ -6,37 +6,52 @@ import (
 )
 
 func TestGet(t *testing.T) {
+	keepEnviron(t)
 	config := New("./test")
 	env := config.Get("Key")
 	assert.Equal(t, env, "key")
 }
 
 func TestGetInt(t *testing.T) {
+	keepEnviron(t)
 	config := New("./test")
 	data := config.GetInt("IntKey")
 	assert.Equal(t, data, 2123)
 }
 
 func TestGetBool(t *testing.T) {
+	keepEnviron(t)
 	config := New("./test")
 	data := config.GetBool("BoolKey")
 	assert.Equal(t, data, true)
 }
 
 func TestGetStringSlice(t *testing.T) {
+	keepEnviron(t)
 	config := New("./test")
 	data := config.GetStringSlice("StringSliceKey")
 	assert.Equal(t, data, []string{"i", "am", "groot"})
 }
 
 func TestGetIntSlice(t *testing.T) {
+	keepEnviron(t)
 	config := New("./test")
 	data := config.GetIntSlice("IntSliceKey")
 	assert.Equal(t, data, []int{1, 5, 8, 2})
 }
 
 func TestGetBoolSlice(t *testing.T) {
+	keepEnviron(t)
 	config := New("./test")
 	data := config.GetBoolSlice("BoolSliceKey")
 	assert.Equal(t, data, []bool{false, true, true, false})
 }
+
+func TestNewFromMap(t *testing.T) {
+	values := map[string]string{"Key": "value", "IntKey": "42"}
+	config := NewFromMap(values)
+	values["Key"] = "changed"
+	assert.Equal(t, "value", config.Get("Key"))
+	assert.Equal(t, 42, config.GetInt("IntKey"))
+	assert.Equal(t, "default", config.GetOrDefaultString("MissingKey", "default"))
+}
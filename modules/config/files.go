@@ -0,0 +1,138 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// decodeFile parses a structured config file and adds its flattened values, later files overwrite the keys of earlier ones.
+func decodeFile(ext string, data []byte, values map[string]string) error {
+	var doc map[string]any
+	switch ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		// numbers are kept as written, 4194304 must not become 4.194304e+06
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return err
+		}
+	case ".toml":
+		var err error
+		if doc, err = parseTOML(data); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported config file format %s", ext)
+	}
+	return flatten("", doc, values)
+}
+
+// flatten adds the values of the nested maps with the keys joined by underscores,
+// so that {"http": {"port": 3000}} is read with GetInt("HTTP_PORT") like the environment variables.
+// Lists of values are joined by commas, as read by GetStringSlice, the tables of a list are flattened with their index,
+// so that the host of the first table of servers is read as SERVERS_0_HOST.
+func flatten(prefix string, value any, values map[string]string) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if err := flatten(joinKey(prefix, k), child, values); err != nil {
+				return err
+			}
+		}
+	case map[any]any:
+		for k, child := range v {
+			if err := flatten(joinKey(prefix, fmt.Sprint(k)), child, values); err != nil {
+				return err
+			}
+		}
+	case []map[string]any:
+		for i, child := range v {
+			if err := flatten(joinKey(prefix, strconv.Itoa(i)), child, values); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if isTableList(v) {
+			for i, child := range v {
+				if err := flatten(joinKey(prefix, strconv.Itoa(i)), child, values); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, ok := scalarString(item)
+			if !ok {
+				return fmt.Errorf("%s: lists can hold values or tables, not lists or both", prefix)
+			}
+			items[i] = s
+		}
+		values[prefix] = strings.Join(items, ",")
+	default:
+		s, ok := scalarString(v)
+		if !ok {
+			return fmt.Errorf("%s: unsupported value of type %T", prefix, v)
+		}
+		values[prefix] = s
+	}
+	return nil
+}
+
+// isTableList reports whether the list holds tables only.
+func isTableList(list []any) bool {
+	for _, item := range list {
+		switch item.(type) {
+		case map[string]any, map[any]any:
+		default:
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+func scalarString(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case json.Number:
+		return v.String(), true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	}
+	return "", false
+}
+
+func joinKey(prefix, key string) string {
+	key = normalizeKey(key)
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+// normalizeKey turns a key of a config file or of the command line into an environment variable name, http.read-timeout becomes HTTP_READ_TIMEOUT.
+func normalizeKey(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(strings.TrimSpace(key)))
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// keepEnviron restores the process environment at the end of the test, as Load exports the values of the env files.
+func keepEnviron(t *testing.T) {
+	t.Helper()
+	environ := os.Environ()
+	t.Cleanup(func() {
+		os.Clearenv()
+		for _, env := range environ {
+			pair := splitEnvVar(env)
+			_ = os.Setenv(pair[0], pair[1])
+		}
+	})
+}
+
+func TestLoadLayers(t *testing.T) {
+	keepEnviron(t)
+	t.Setenv("QUEUE_FETCH_LIMIT", "20")
+	config, err := Load("./test/layered")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", config.Get("APP_ENV"))
+	// config.yaml
+	assert.Equal(t, "LayeredApp", config.Get("APP_NAME"))
+	assert.Equal(t, 4194304, config.GetInt("HTTP_BODY_LIMIT"))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, config.GetStringSlice("HTTP_TRUSTED_PROXIES"))
+	// config.toml
+	assert.Equal(t, "memory", config.Get("CACHE_DRIVER"))
+	// config.prod.json over config.yaml
+	assert.Equal(t, 4000, config.GetInt("HTTP_PORT"))
+	assert.Equal(t, "0.25", config.Get("TRACING_SAMPLE_RATIO"))
+	// .prod.env over .env over the config files
+	assert.Equal(t, 5, config.GetInt("QUEUE_CONCURRENCY"))
+	// the process environment over .env
+	assert.Equal(t, 20, config.GetInt("QUEUE_FETCH_LIMIT"))
+}
+
+func TestLoadOverrides(t *testing.T) {
+	keepEnviron(t)
+	t.Setenv("HTTP_PORT", "5000")
+	config, err := Load("./test/layered", WithOverrides(map[string]string{"http.port": "6000", "app-env": "staging"}))
+	require.NoError(t, err)
+	assert.Equal(t, 6000, config.GetInt("HTTP_PORT"))
+	// the overridden APP_ENV selects the override files, config.prod.json and .prod.env are not read
+	assert.Equal(t, "staging", config.Get("APP_ENV"))
+	assert.Equal(t, 4, config.GetInt("QUEUE_CONCURRENCY"))
+	assert.Equal(t, "", config.Get("TRACING_SAMPLE_RATIO"))
+}
+
+func TestLoadExportsEnvFiles(t *testing.T) {
+	keepEnviron(t)
+	t.Setenv("QUEUE_CONCURRENCY", "8")
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".env"), "OTEL_EXPORTER_OTLP_ENDPOINT=collector:4318\nQUEUE_CONCURRENCY=2\n")
+	config, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "collector:4318", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	// the process environment is not overwritten
+	assert.Equal(t, "8", os.Getenv("QUEUE_CONCURRENCY"))
+	assert.Equal(t, 8, config.GetInt("QUEUE_CONCURRENCY"))
+
+	// a reload reads the changes of the exported values
+	writeFile(t, filepath.Join(dir, ".env"), "OTEL_EXPORTER_OTLP_ENDPOINT=otel:4318\n")
+	require.NoError(t, config.Reload())
+	assert.Equal(t, "otel:4318", config.Get("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	assert.Equal(t, "otel:4318", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+
+	writeFile(t, filepath.Join(dir, ".env"), "")
+	require.NoError(t, config.Reload())
+	assert.Equal(t, "", config.Get("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	_, ok := os.LookupEnv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	assert.False(t, ok)
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	keepEnviron(t)
+	_, err := Load("./test/invalid")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config.yaml")
+
+	// New keeps the sources which could be read
+	config := New("./test/invalid")
+	assert.Equal(t, "InvalidApp", config.Get("APP_NAME"))
+	assert.Equal(t, "", config.Get("HTTP_PORT"))
+}
+
+func TestFlatten(t *testing.T) {
+	values := make(map[string]string)
+	require.NoError(t, decodeFile(".json", []byte(`{"mailer": {"smtp": {"port": 587, "tls": true}}, "oidc": {"app_scopes": ["openid", "email"]}, "empty": null}`), values))
+	assert.Equal(t, map[string]string{
+		"MAILER_SMTP_PORT": "587",
+		"MAILER_SMTP_TLS":  "true",
+		"OIDC_APP_SCOPES":  "openid,email",
+		"EMPTY":            "",
+	}, values)
+
+	// the tables of a list are flattened with their index
+	values = make(map[string]string)
+	require.NoError(t, decodeFile(".yaml", []byte("servers:\n  - host: a\n  - host: b\n    port: 8080\n"), values))
+	assert.Equal(t, map[string]string{"SERVERS_0_HOST": "a", "SERVERS_1_HOST": "b", "SERVERS_1_PORT": "8080"}, values)
+
+	err := decodeFile(".yaml", []byte("servers:\n  - host: a\n  - b\n"), values)
+	assert.ErrorContains(t, err, "SERVERS")
+	err = decodeFile(".yaml", []byte("matrix:\n  - [1, 2]\n"), values)
+	assert.ErrorContains(t, err, "MATRIX")
+}
+
+func TestDecodeTOML(t *testing.T) {
+	values := make(map[string]string)
+	require.NoError(t, decodeFile(".toml", []byte(`
+# comment
+title = "goe \"app\"" # trailing comment
+path = 'C:\configs'
+multiline = """
+line one \
+  continued"""
+"quoted key" = 1_000
+site.name = "dotted"
+
+[http]
+port = 3000
+ratio = 0.5
+enabled = true
+started = 2025-04-17T16:02:00Z
+released = 2025-04-17
+opens = 08:30:00
+proxies = [
+  "10.0.0.1",
+  "10.0.0.2", # comment
+]
+
+[http.limits]
+inline = { rate = 10, burst = 20 }
+
+[[servers]]
+host = "a"
+
+[[servers]]
+host = "b"
+port = 8080
+`), values))
+	assert.Equal(t, map[string]string{
+		"TITLE":                    `goe "app"`,
+		"PATH":                     `C:\configs`,
+		"MULTILINE":                "line one continued",
+		"QUOTED KEY":               "1000",
+		"SITE_NAME":                "dotted",
+		"HTTP_PORT":                "3000",
+		"HTTP_RATIO":               "0.5",
+		"HTTP_ENABLED":             "true",
+		"HTTP_STARTED":             "2025-04-17T16:02:00Z",
+		"HTTP_RELEASED":            "2025-04-17",
+		"HTTP_OPENS":               "08:30:00",
+		"HTTP_PROXIES":             "10.0.0.1,10.0.0.2",
+		"HTTP_LIMITS_INLINE_RATE":  "10",
+		"HTTP_LIMITS_INLINE_BURST": "20",
+		"SERVERS_0_HOST":           "a",
+		"SERVERS_1_HOST":           "b",
+		"SERVERS_1_PORT":           "8080",
+	}, values)
+
+	for _, invalid := range []string{
+		"key = ",
+		"key = \"unterminated",
+		"key = 1\nkey = 2",
+		"key = 1 2",
+		"a = 1\n[a]",
+		"[a]\nx = 1\n[a]\ny = 2",
+		"[[servers]]\nhost = \"a\"\n[servers]",
+	} {
+		err := decodeFile(".toml", []byte(invalid), make(map[string]string))
+		assert.Error(t, err, invalid)
+	}
+}
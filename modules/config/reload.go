@@ -0,0 +1,204 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sourceTimeout bounds the time to read a key-value source on every reload.
+const sourceTimeout = 10 * time.Second
+
+// Source is a key-value store holding config values, such as a Redis hash or a MongoDB collection.
+// It is read on every reload, its keys are normalized like the keys of the config files.
+type Source interface {
+	// Name identifies the source in the errors, such as redis:goe:config
+	Name() string
+	// Values returns all the values of the source
+	Values(ctx context.Context) (map[string]string, error)
+}
+
+// SourceFunc adapts a function to a Source.
+func SourceFunc(name string, fn func(ctx context.Context) (map[string]string, error)) Source {
+	return &funcSource{name: name, fn: fn}
+}
+
+type funcSource struct {
+	name string
+	fn   func(ctx context.Context) (map[string]string, error)
+}
+
+func (s *funcSource) Name() string {
+	return s.name
+}
+
+func (s *funcSource) Values(ctx context.Context) (map[string]string, error) {
+	return s.fn(ctx)
+}
+
+// Change is a value changed by a reload, a removed key has an empty New value.
+type Change struct {
+	Key string
+	Old string
+	New string
+	// Live reports whether the subscribers of OnChange applied the new value,
+	// the other changes take effect after a restart, as the values are read at startup.
+	Live bool
+	// Err holds the errors of the subscribers which failed to apply the new value, the change is not Live then.
+	Err error
+}
+
+// OnChange subscribes fn to the changes of the key, it is called by Reload with the old and the new value.
+// The subscribers are called in the order they subscribed, one change at a time, they must not block nor call Reload.
+// fn returns an error if it cannot apply the new value, the change is reported as needing a restart then.
+func (c *Config) OnChange(key string, fn func(oldValue, newValue string) error) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if c.listeners == nil {
+		c.listeners = make(map[string][]func(oldValue, newValue string) error)
+	}
+	c.listeners[key] = append(c.listeners[key], fn)
+}
+
+// OnReload subscribes fn to the reloads changing at least one value, it is called after the subscribers of OnChange.
+// It is used to report the changes which require a restart.
+func (c *Config) OnReload(fn func(changes []Change)) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	c.onReload = append(c.onReload, fn)
+}
+
+// AddSource adds a key-value source, and reloads the config to read it.
+// The values of the sources take precedence over the config files and the env files, and are overridden by the environment variables.
+func (c *Config) AddSource(src Source) error {
+	c.reloadMu.Lock()
+	c.loader.sources = append(c.loader.sources, src)
+	c.reloadMu.Unlock()
+	return c.Reload()
+}
+
+// Reload reads all the sources again, and notifies the subscribers of the changed values.
+// If a source cannot be read, the current values are kept and the error is returned.
+func (c *Config) Reload() error {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+	envMap, secrets, err := c.loader.load()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	old := c.envMap
+	c.envMap = envMap
+	c.secrets = secrets
+	c.mu.Unlock()
+
+	changes := diff(old, envMap)
+	if len(changes) == 0 {
+		return nil
+	}
+	c.subMu.Lock()
+	listeners := make([][]func(oldValue, newValue string) error, len(changes))
+	for i, change := range changes {
+		listeners[i] = c.listeners[change.Key]
+	}
+	onReload := c.onReload
+	c.subMu.Unlock()
+	for i, change := range changes {
+		var errs []error
+		for _, fn := range listeners[i] {
+			if err := fn(change.Old, change.New); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		changes[i].Err = errors.Join(errs...)
+		changes[i].Live = len(listeners[i]) > 0 && changes[i].Err == nil
+	}
+	for _, fn := range onReload {
+		fn(changes)
+	}
+	return nil
+}
+
+// Watch reloads the config every interval until ctx is done, the config files are only read again when they change.
+// The errors of the reloads are given to onError, which can be nil, the current values are kept then.
+func (c *Config) Watch(ctx context.Context, interval time.Duration, onError func(err error)) {
+	// the files are compared with their state when Watch is called, not when the goroutine starts
+	stamp := c.loader.stamp()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// the sources are remote, their changes cannot be detected without reading them
+			current := c.loader.stamp()
+			if current == stamp && !c.hasSources() {
+				continue
+			}
+			if err := c.Reload(); err != nil {
+				if onError != nil {
+					onError(err)
+				}
+				continue
+			}
+			stamp = current
+		}
+	}()
+}
+
+func (c *Config) hasSources() bool {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+	return len(c.loader.sources) > 0
+}
+
+// stamp returns the sizes and the modification times of the config files, a different stamp means that the files changed.
+func (l *loader) stamp() string {
+	if l.folder == "" {
+		return ""
+	}
+	entries, err := os.ReadDir(l.folder)
+	if err != nil {
+		return err.Error()
+	}
+	var b strings.Builder
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, configFileName+".") && !strings.HasSuffix(name, envFileName) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		_, _ = fmt.Fprintf(&b, "%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
+
+// diff returns the keys added, changed or removed between the values, sorted by key.
+// A missing key and an empty value are the same, as Get returns an empty string for both.
+func diff(old, current map[string]string) []Change {
+	var changes []Change
+	for k, v := range current {
+		if old[k] != v {
+			changes = append(changes, Change{Key: k, Old: old[k], New: v})
+		}
+	}
+	for k, v := range old {
+		if _, ok := current[k]; !ok && v != "" {
+			changes = append(changes, Change{Key: k, Old: v})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
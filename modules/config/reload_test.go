@@ -0,0 +1,153 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config.yaml"), "log:\n  level: info\nhttp:\n  port: 3000\n")
+	config, err := Load(dir)
+	require.NoError(t, err)
+
+	var levels []string
+	config.OnChange("LOG_LEVEL", func(oldValue, newValue string) error {
+		levels = append(levels, oldValue+"->"+newValue)
+		return nil
+	})
+	// a change is live only if all its subscribers applied it
+	errPort := errors.New("the server is listening already")
+	var ports []string
+	config.OnChange("HTTP_PORT", func(oldValue, newValue string) error {
+		ports = append(ports, newValue)
+		return nil
+	})
+	config.OnChange("HTTP_PORT", func(oldValue, newValue string) error {
+		return errPort
+	})
+	var reloaded []Change
+	config.OnReload(func(changes []Change) {
+		reloaded = append(reloaded, changes...)
+	})
+
+	writeFile(t, filepath.Join(dir, "config.yaml"), "log:\n  level: debug\nhttp:\n  port: 4000\nqueue:\n  concurrency: 2\n")
+	require.NoError(t, config.Reload())
+	assert.Equal(t, "debug", config.Get("LOG_LEVEL"))
+	assert.Equal(t, 4000, config.GetInt("HTTP_PORT"))
+	assert.Equal(t, []string{"info->debug"}, levels)
+	assert.Equal(t, []string{"4000"}, ports)
+	assert.ErrorIs(t, reloaded[0].Err, errPort)
+	assert.Equal(t, []Change{
+		{Key: "HTTP_PORT", Old: "3000", New: "4000", Err: errors.Join(errPort)},
+		{Key: "LOG_LEVEL", Old: "info", New: "debug", Live: true},
+		{Key: "QUEUE_CONCURRENCY", New: "2"},
+	}, reloaded)
+
+	// an invalid file keeps the current values
+	reloaded = nil
+	writeFile(t, filepath.Join(dir, "config.yaml"), "log: [debug\n")
+	assert.Error(t, config.Reload())
+	assert.Equal(t, "debug", config.Get("LOG_LEVEL"))
+	assert.Empty(t, reloaded)
+
+	// a reload without changes does not notify
+	writeFile(t, filepath.Join(dir, "config.yaml"), "log:\n  level: debug\nhttp:\n  port: 4000\nqueue:\n  concurrency: 2\n")
+	require.NoError(t, config.Reload())
+	assert.Empty(t, reloaded)
+	assert.Equal(t, []string{"info->debug"}, levels)
+}
+
+func TestReloadSources(t *testing.T) {
+	keepEnviron(t)
+	t.Setenv("HTTP_PORT", "5000")
+	values := map[string]string{"queue.concurrency": "4", "HTTP_PORT": "6000"}
+	var failing error
+	src := SourceFunc("test", func(ctx context.Context) (map[string]string, error) {
+		return values, failing
+	})
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".env"), "QUEUE_CONCURRENCY=2\n")
+	config, err := Load(dir, WithSource(src))
+	require.NoError(t, err)
+	// the sources override the env files, and are overridden by the environment variables
+	assert.Equal(t, 4, config.GetInt("QUEUE_CONCURRENCY"))
+	assert.Equal(t, 5000, config.GetInt("HTTP_PORT"))
+
+	var concurrency string
+	config.OnChange("QUEUE_CONCURRENCY", func(oldValue, newValue string) error {
+		concurrency = newValue
+		return nil
+	})
+	values = map[string]string{}
+	require.NoError(t, config.Reload())
+	assert.Equal(t, "2", concurrency)
+
+	failing = errors.New("connection refused")
+	values = map[string]string{"QUEUE_CONCURRENCY": "8"}
+	err = config.Reload()
+	assert.ErrorContains(t, err, "config source test: connection refused")
+	assert.Equal(t, 2, config.GetInt("QUEUE_CONCURRENCY"))
+}
+
+func TestAddSourceFromMap(t *testing.T) {
+	config := NewFromMap(map[string]string{"APP_NAME": "app", "LOG_LEVEL": "info"})
+	require.NoError(t, config.AddSource(SourceFunc("test", func(ctx context.Context) (map[string]string, error) {
+		return map[string]string{"log-level": "warn"}, nil
+	})))
+	assert.Equal(t, "app", config.Get("APP_NAME"))
+	assert.Equal(t, "warn", config.Get("LOG_LEVEL"))
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	writeFile(t, file, "log:\n  level: info\n")
+	config, err := Load(dir)
+	require.NoError(t, err)
+
+	changed := make(chan string, 1)
+	config.OnChange("LOG_LEVEL", func(oldValue, newValue string) error {
+		changed <- newValue
+		return nil
+	})
+	var mu sync.Mutex
+	var errs []error
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config.Watch(ctx, 10*time.Millisecond, func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	})
+
+	writeFile(t, file, "log:\n  level: error\n")
+	// the modification time may not change within the resolution of the file system, the size does
+	select {
+	case level := <-changed:
+		assert.Equal(t, "error", level)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the change of the file was not detected")
+	}
+	assert.Equal(t, "error", config.Get("LOG_LEVEL"))
+
+	writeFile(t, file, "log: [\n")
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) > 0
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "error", config.Get("LOG_LEVEL"))
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.oease.dev/goe/utils"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MasterKeyName is the key of the master key decrypting the enc: values, it must be 16, 24 or 32 bytes long.
+	// It is usually given by the environment or by a file with CONFIG_MASTER_KEY_FILE, not by the config files holding the encrypted values.
+	MasterKeyName = "CONFIG_MASTER_KEY"
+	// EncryptedScheme is the prefix of the values encrypted with the master key, see Encrypt.
+	EncryptedScheme = "enc"
+	// fileSuffix marks the keys holding the path of a file containing the value of the key without the suffix, such as SMTP_PASSWORD_FILE,
+	// it is only followed for the master key and the keys given by WithSecretFiles.
+	fileSuffix = "_FILE"
+)
+
+// SecretProvider resolves the values referencing a secret stored elsewhere, such as a vault or a cloud secret manager.
+// A value is resolved by the provider whose scheme prefixes it, vault:secret/db#password is given to the vault provider as secret/db#password.
+type SecretProvider interface {
+	// Scheme is the prefix of the values resolved by the provider, without the colon
+	Scheme() string
+	// Secret returns the secret referenced by ref, the value without the scheme and the colon
+	Secret(ctx context.Context, ref string) (string, error)
+}
+
+// WithSecretProvider adds a provider resolving the values prefixed by its scheme, a provider replaces the built-in provider of its scheme.
+func WithSecretProvider(p SecretProvider) Option {
+	return func(l *loader) {
+		l.providers = append(l.providers, p)
+	}
+}
+
+// WithSecretFiles lets the values of the keys be read from files, KEY_FILE holds the path of the file containing the value of KEY.
+// Only the master key and these keys are read from files, so that the settings ending with _FILE, such as EMQX_TLS_KEY_FILE, keep their value.
+// SecretKeys returns the keys of the secret fields of a config struct.
+func WithSecretFiles(keys ...string) Option {
+	return func(l *loader) {
+		for _, key := range keys {
+			l.secretFiles[normalizeKey(key)] = true
+		}
+	}
+}
+
+// SecretKeys returns the keys of the fields tagged with secret:"true" of the struct ptr points to, including the fields of the nested structs.
+func SecretKeys(ptr any) []string {
+	t := reflect.TypeOf(ptr)
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	b := &binder{src: emptyGetter{}}
+	b.bindStruct(reflect.New(t.Elem()).Elem(), "", "")
+	return b.secrets
+}
+
+// emptyGetter has no values, it is used to walk the fields of a struct.
+type emptyGetter struct{}
+
+func (emptyGetter) Get(string) string {
+	return ""
+}
+
+// Encrypt encrypts the value with the master key, the result is prefixed by enc: and can be written in the config files and the env files.
+func Encrypt(masterKey, value string) (string, error) {
+	if err := checkMasterKey(masterKey); err != nil {
+		return "", err
+	}
+	encrypted, err := utils.UseEncryption(masterKey).Encrypt([]byte(value))
+	if err != nil {
+		return "", err
+	}
+	return EncryptedScheme + ":" + encrypted, nil
+}
+
+func checkMasterKey(masterKey string) error {
+	switch len(masterKey) {
+	case 0:
+		return fmt.Errorf("%s is not set", MasterKeyName)
+	case 16, 24, 32:
+		return nil
+	}
+	return fmt.Errorf("%s must be 16, 24 or 32 bytes long, not %d", MasterKeyName, len(masterKey))
+}
+
+// encryptedProvider decrypts the enc: values with the master key.
+type encryptedProvider struct {
+	masterKey string
+}
+
+func (p *encryptedProvider) Scheme() string {
+	return EncryptedScheme
+}
+
+func (p *encryptedProvider) Secret(_ context.Context, ref string) (string, error) {
+	if err := checkMasterKey(p.masterKey); err != nil {
+		return "", err
+	}
+	data, err := utils.UseEncryption(p.masterKey).Decrypt(ref)
+	if err != nil {
+		return "", err
+	}
+	// the encryption is not authenticated, a wrong master key is only detected by the garbage it decrypts
+	if !utf8.Valid(data) {
+		return "", errors.New("the decrypted value is not valid text, the master key may be wrong")
+	}
+	return string(data), nil
+}
+
+// resolveSecrets replaces the values of the KEY_FILE keys of the secret files and the values of the secret providers, and returns the keys of the resolved secrets.
+// The values which cannot be resolved are removed, so that an encrypted value or a reference is not used as the secret itself.
+func (l *loader) resolveSecrets(values map[string]string) map[string]bool {
+	secrets := map[string]bool{MasterKeyName: true}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// KEY_FILE holds the path of a file containing the value of KEY, such as a Docker or Kubernetes secret, a value set for KEY wins
+	for _, k := range keys {
+		key, ok := strings.CutSuffix(k, fileSuffix)
+		if !ok || !l.secretFiles[key] || values[k] == "" || values[key] != "" {
+			continue
+		}
+		data, err := os.ReadFile(values[k])
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("%s: %w", k, err))
+			continue
+		}
+		values[key] = strings.TrimRight(string(data), "\r\n")
+		secrets[key] = true
+	}
+
+	providers := map[string]SecretProvider{EncryptedScheme: &encryptedProvider{masterKey: values[MasterKeyName]}}
+	for _, p := range l.providers {
+		providers[p.Scheme()] = p
+	}
+	for k, v := range values {
+		scheme, ref, found := strings.Cut(v, ":")
+		if !found {
+			continue
+		}
+		p, ok := providers[scheme]
+		if !ok {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), sourceTimeout)
+		secret, err := p.Secret(ctx, ref)
+		cancel()
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("%s: %s secret: %w", k, scheme, err))
+			delete(values, k)
+			continue
+		}
+		values[k] = secret
+		secrets[k] = true
+	}
+	return secrets
+}
+
+// IsSecret reports whether the value of the key is a secret: the master key, a value read from a KEY_FILE file or resolved by a secret provider,
+// or a value bound to a struct field tagged with secret:"true". Secret values must be redacted when the config is printed or logged.
+func (c *Config) IsSecret(key string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.secrets[key] || c.marked[key]
+}
+
+// MarkSecret marks the values of the keys as secrets, see IsSecret.
+func (c *Config) MarkSecret(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.marked == nil {
+		c.marked = make(map[string]bool)
+	}
+	for _, key := range keys {
+		c.marked[key] = true
+	}
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testMasterKey = "0123456789abcdef0123456789abcdef"
+
+func TestSecretFile(t *testing.T) {
+	keepEnviron(t)
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "smtp_password")
+	writeFile(t, secret, "s3cr3t\n")
+	writeFile(t, filepath.Join(dir, ".env"), "SMTP_PASSWORD_FILE="+secret+"\nS3_SECRET_KEY=plain\nS3_SECRET_KEY_FILE="+secret+"\n")
+	secretFiles := WithSecretFiles("SMTP_PASSWORD", "s3.secret-key")
+	config, err := Load(dir, secretFiles)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", config.Get("SMTP_PASSWORD"))
+	assert.True(t, config.IsSecret("SMTP_PASSWORD"))
+	// a value set for the key wins over its file
+	assert.Equal(t, "plain", config.Get("S3_SECRET_KEY"))
+	assert.False(t, config.IsSecret("S3_SECRET_KEY"))
+
+	writeFile(t, filepath.Join(dir, ".env"), "SMTP_PASSWORD_FILE="+filepath.Join(dir, "missing")+"\n")
+	_, err = Load(dir, secretFiles)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SMTP_PASSWORD_FILE")
+}
+
+func TestSettingsEndingWithFile(t *testing.T) {
+	keepEnviron(t)
+	type tls struct {
+		CertFile string `env:"CERT_FILE" default:"client-crt.pem"`
+		KeyFile  string `env:"KEY_FILE" default:"client-key.pem"`
+	}
+	type emqx struct {
+		TLS      *tls   `prefix:"TLS_"`
+		Password string `env:"PASSWORD" secret:"true"`
+	}
+	dir := t.TempDir()
+	// the files do not exist in the working directory of the test, they must not be read
+	writeFile(t, filepath.Join(dir, ".env"), "EMQX_TLS_KEY_FILE=client-key.pem\nEMQX_TLS_CERT_FILE="+filepath.Join(dir, "certs", "client-crt.pem")+"\n")
+	keys := SecretKeys(&struct {
+		EMQX emqx `prefix:"EMQX_"`
+	}{})
+	assert.Equal(t, []string{"EMQX_PASSWORD"}, keys)
+	config, err := Load(dir, WithSecretFiles(keys...))
+	require.NoError(t, err)
+	assert.Equal(t, "", config.Get("EMQX_TLS_KEY"))
+	assert.Equal(t, "", config.Get("EMQX_TLS_CERT"))
+	assert.Equal(t, "client-key.pem", config.Get("EMQX_TLS_KEY_FILE"))
+
+	var cfg struct {
+		EMQX emqx `prefix:"EMQX_"`
+	}
+	require.NoError(t, config.Bind(&cfg))
+	assert.Equal(t, "client-key.pem", cfg.EMQX.TLS.KeyFile)
+	assert.Equal(t, filepath.Join(dir, "certs", "client-crt.pem"), cfg.EMQX.TLS.CertFile)
+}
+
+func TestEncryptedValues(t *testing.T) {
+	encrypted, err := Encrypt(testMasterKey, "s3cr3t")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "enc:"))
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config.yaml"), "oidc:\n  app_secret: "+encrypted+"\n")
+	t.Setenv(MasterKeyName, testMasterKey)
+	config, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", config.Get("OIDC_APP_SECRET"))
+	assert.True(t, config.IsSecret("OIDC_APP_SECRET"))
+	assert.True(t, config.IsSecret(MasterKeyName))
+
+	// the encrypted value is not used as the secret when it cannot be decrypted
+	t.Setenv(MasterKeyName, "")
+	_, err = Load(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), MasterKeyName+" is not set")
+	assert.Equal(t, "", New(dir).Get("OIDC_APP_SECRET"))
+
+	_, err = Encrypt("short", "s3cr3t")
+	assert.Error(t, err)
+}
+
+type testProvider struct {
+	secrets map[string]string
+}
+
+func (p *testProvider) Scheme() string {
+	return "vault"
+}
+
+func (p *testProvider) Secret(_ context.Context, ref string) (string, error) {
+	secret, ok := p.secrets[ref]
+	if !ok {
+		return "", errors.New("secret not found")
+	}
+	return secret, nil
+}
+
+func TestSecretProvider(t *testing.T) {
+	provider := &testProvider{secrets: map[string]string{"db#password": "s3cr3t"}}
+	config, err := Load(t.TempDir(), WithOverrides(map[string]string{
+		"REDIS_PASSWORD": "vault:db#password",
+		"REDIS_HOST":     "localhost",
+	}), WithSecretProvider(provider))
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", config.Get("REDIS_PASSWORD"))
+	assert.True(t, config.IsSecret("REDIS_PASSWORD"))
+	assert.False(t, config.IsSecret("REDIS_HOST"))
+
+	_, err = Load(t.TempDir(), WithOverrides(map[string]string{"REDIS_PASSWORD": "vault:missing"}), WithSecretProvider(provider))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "REDIS_PASSWORD")
+}
+
+func TestBindMarksSecrets(t *testing.T) {
+	var target struct {
+		User     string `env:"USER"`
+		Password string `env:"PASSWORD" secret:"true"`
+	}
+	config := NewFromMap(map[string]string{"USER": "admin", "PASSWORD": "s3cr3t"})
+	require.NoError(t, config.Bind(&target))
+	assert.True(t, config.IsSecret("PASSWORD"))
+	assert.False(t, config.IsSecret("USER"))
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"github.com/BurntSushi/toml"
+	"time"
+)
+
+// parseTOML parses a TOML config file, the local dates and times have no time zone and are kept as written.
+func parseTOML(data []byte) (map[string]any, error) {
+	var doc map[string]any
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		return nil, err
+	}
+	return localTimes(doc).(map[string]any), nil
+}
+
+// localTimes replaces the local dates and times of the decoded value by their TOML text,
+// they are decoded in the zones named after their type.
+func localTimes(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			v[k] = localTimes(child)
+		}
+	case []map[string]any:
+		for _, child := range v {
+			localTimes(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = localTimes(child)
+		}
+	case time.Time:
+		switch v.Location().String() {
+		case "datetime-local":
+			return v.Format("2006-01-02T15:04:05.999999999")
+		case "date-local":
+			return v.Format(time.DateOnly)
+		case "time-local":
+			return v.Format("15:04:05.999999999")
+		}
+	}
+	return value
+}
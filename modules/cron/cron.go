@@ -1,13 +1,25 @@
 package cron
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/go-co-op/gocron/v2"
+	"reflect"
+	"runtime"
+	"time"
 )
 
 type CronJobModule struct {
 	scheduler gocron.Scheduler
 	started   bool
+	observer  Observer
+}
+
+// Observer is notified of every run of the jobs, a job which panics is notified with an error.
+// It is used to collect job run metrics.
+type Observer interface {
+	ObserveCronRun(job string, duration time.Duration, err error)
 }
 
 func NewCronJobService() (*CronJobModule, error) {
@@ -34,6 +46,17 @@ func (c *CronJobModule) Start() {
 	c.started = true
 }
 
+// WithObserver sets the observer notified of the job runs, it only applies to the jobs defined after it.
+func (c *CronJobModule) WithObserver(observer Observer) *CronJobModule {
+	c.observer = observer
+	return c
+}
+
+// IsStarted reports whether the scheduler has been started.
+func (c *CronJobModule) IsStarted() bool {
+	return c.started
+}
+
 func (c *CronJobModule) Close() error {
 	if c.scheduler == nil {
 		return errors.New("scheduler is not initialized")
@@ -44,6 +67,24 @@ func (c *CronJobModule) Close() error {
 	return c.scheduler.Shutdown()
 }
 
+// Shutdown stops the scheduler and waits until the running jobs complete or ctx is done.
+func (c *CronJobModule) Shutdown(ctx context.Context) error {
+	if c.scheduler == nil || !c.started {
+		return nil
+	}
+	c.started = false
+	result := make(chan error, 1)
+	go func() {
+		result <- c.scheduler.Shutdown()
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (c *CronJobModule) DefineJob(definition gocron.JobDefinition, handler func()) error {
 	if c.scheduler == nil {
 		return errors.New("scheduler is not initialized")
@@ -51,6 +92,27 @@ func (c *CronJobModule) DefineJob(definition gocron.JobDefinition, handler func(
 	if c.started {
 		return errors.New("scheduler is already started, jobs can only be defined before starting the scheduler")
 	}
-	_, err := c.scheduler.NewJob(definition, gocron.NewTask(handler))
+	// the job is named after the handler function, as gocron does by default, so the name survives the observer wrapper
+	name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+	_, err := c.scheduler.NewJob(definition, gocron.NewTask(c.observed(name, handler)), gocron.WithName(name))
 	return err
 }
+
+// observed wraps the handler to notify the observer of each run.
+func (c *CronJobModule) observed(name string, handler func()) func() {
+	observer := c.observer
+	if observer == nil {
+		return handler
+	}
+	return func() {
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				observer.ObserveCronRun(name, time.Since(start), fmt.Errorf("job panicked: %v", r))
+				panic(r)
+			}
+		}()
+		handler()
+		observer.ObserveCronRun(name, time.Since(start), nil)
+	}
+}
@@ -0,0 +1,169 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// CheckFunc checks a component, it returns nil if the component is healthy.
+type CheckFunc func(ctx context.Context) error
+
+// ComponentStatus is the result of a single component check.
+type ComponentStatus struct {
+	Status    Status `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report is the aggregated result of all component checks.
+type Report struct {
+	Status     Status                      `json:"status"`
+	Message    string                      `json:"message,omitempty"`
+	Components map[string]*ComponentStatus `json:"components,omitempty"`
+}
+
+// Registry holds the health checkers registered by modules, and the readiness state of the app.
+type Registry struct {
+	mu      sync.RWMutex
+	checks  map[string]CheckFunc
+	ready   atomic.Bool
+	timeout time.Duration
+}
+
+// NewRegistry creates a health registry, each check is cancelled after the given timeout, 0 means 5 seconds.
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Registry{
+		checks:  make(map[string]CheckFunc),
+		timeout: timeout,
+	}
+}
+
+// Register registers a checker of the named component, a checker registered with the same name is replaced.
+func (r *Registry) Register(name string, check CheckFunc) {
+	if name == "" || check == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = check
+}
+
+// Unregister removes the checker of the named component.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checks, name)
+}
+
+// Names returns the sorted names of the registered components.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetReady sets the readiness of the app, it is set to false when the app starts shutting down so load balancers drain traffic.
+func (r *Registry) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+// IsReady reports the readiness state set by SetReady, it does not run the checks.
+func (r *Registry) IsReady() bool {
+	return r.ready.Load()
+}
+
+// Liveness reports whether the process is alive, it does not run the component checks.
+func (r *Registry) Liveness() *Report {
+	return &Report{Status: StatusUp}
+}
+
+// Readiness runs all component checks concurrently, the report is down if the app is not ready or any component is down.
+func (r *Registry) Readiness(ctx context.Context) *Report {
+	report := r.Check(ctx)
+	if !r.IsReady() {
+		report.Status = StatusDown
+		report.Message = "not ready"
+	}
+	return report
+}
+
+// Check runs all component checks concurrently and aggregates the results.
+func (r *Registry) Check(ctx context.Context) *Report {
+	r.mu.RLock()
+	checks := make(map[string]CheckFunc, len(r.checks))
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+	r.mu.RUnlock()
+
+	report := &Report{
+		Status:     StatusUp,
+		Components: make(map[string]*ComponentStatus, len(checks)),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check CheckFunc) {
+			defer wg.Done()
+			status := r.runCheck(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[name] = status
+			if status.Status == StatusDown {
+				report.Status = StatusDown
+			}
+		}(name, check)
+	}
+	wg.Wait()
+	return report
+}
+
+func (r *Registry) runCheck(ctx context.Context, check CheckFunc) *ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				result <- errors.New("health check panicked")
+			}
+		}()
+		result <- check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	status := &ComponentStatus{
+		Status:    StatusUp,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
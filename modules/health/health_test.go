@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCheckAggregatesComponents(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Register("db", func(ctx context.Context) error { return nil })
+	r.Register("cache", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	report := r.Check(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, StatusUp, report.Components["db"].Status)
+	assert.Equal(t, StatusDown, report.Components["cache"].Status)
+	assert.Equal(t, "connection refused", report.Components["cache"].Error)
+	assert.Equal(t, []string{"cache", "db"}, r.Names())
+}
+
+func TestCheckTimeout(t *testing.T) {
+	r := NewRegistry(50 * time.Millisecond)
+	r.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	report := r.Check(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusDown, report.Components["slow"].Status)
+}
+
+func TestCheckRecoversPanic(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Register("broken", func(ctx context.Context) error { panic("boom") })
+
+	report := r.Check(context.Background())
+	assert.Equal(t, StatusDown, report.Components["broken"].Status)
+}
+
+func TestReadiness(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Register("db", func(ctx context.Context) error { return nil })
+
+	assert.Equal(t, StatusDown, r.Readiness(context.Background()).Status)
+	r.SetReady(true)
+	assert.Equal(t, StatusUp, r.Readiness(context.Background()).Status)
+	r.SetReady(false)
+	report := r.Readiness(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, StatusUp, report.Components["db"].Status)
+	assert.Equal(t, StatusUp, r.Liveness().Status)
+}
+
+func TestUnregister(t *testing.T) {
+	r := NewRegistry(0)
+	r.Register("db", func(ctx context.Context) error { return errors.New("down") })
+	r.Unregister("db")
+
+	report := r.Check(context.Background())
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Empty(t, report.Components)
+}
@@ -0,0 +1,339 @@
+package i18n
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// builtin holds the catalogs of the messages of the framework, such as the default messages of webresult and of the validation rules.
+//
+//go:embed locales/*.json
+var builtin embed.FS
+
+// Config configures a Bundle.
+type Config struct {
+	// DefaultLocale is the locale of the messages missing in the catalog of a locale, and of the requests without a supported locale, default is en
+	DefaultLocale string
+	// Locales are the locales which can be matched, default is every locale with a catalog
+	Locales []string
+}
+
+// Bundle holds the message catalogs of the locales, the catalogs of the framework are loaded first and can be overwritten.
+// The keys of the messages are dotted paths such as mail.welcome.subject, or the English messages of the framework such as "resource not found".
+// It is safe for concurrent use.
+type Bundle struct {
+	defaultLocale string
+	// locales restricts the matched locales, all the locales with a catalog are matched when it is empty
+	locales  []string
+	mu       sync.RWMutex
+	catalogs map[string]map[string]string
+}
+
+// New creates a bundle holding the catalogs of the framework.
+func New(cfg Config) *Bundle {
+	if cfg.DefaultLocale == "" {
+		cfg.DefaultLocale = "en"
+	}
+	b := &Bundle{
+		defaultLocale: Canonical(cfg.DefaultLocale),
+		catalogs:      make(map[string]map[string]string),
+	}
+	for _, locale := range cfg.Locales {
+		if locale = Canonical(locale); locale != "" {
+			b.locales = append(b.locales, locale)
+		}
+	}
+	sort.Strings(b.locales)
+	if err := b.LoadFS(builtin, "locales"); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// DefaultLocale returns the default locale.
+func (b *Bundle) DefaultLocale() string {
+	return b.defaultLocale
+}
+
+// Locales returns the locales which can be matched, sorted.
+func (b *Bundle) Locales() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]string{}, b.candidates()...)
+}
+
+// AddMessages adds messages to the catalog of the locale, they overwrite the messages with the same keys.
+func (b *Bundle) AddMessages(locale string, messages map[string]string) {
+	locale = Canonical(locale)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	catalog, ok := b.catalogs[locale]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		b.catalogs[locale] = catalog
+	}
+	for key, message := range messages {
+		catalog[key] = message
+	}
+}
+
+// LoadFS loads the json and yaml catalogs of the directory of fsys, such as an embed.FS, the name of a file is its locale, such as en.json or zh-CN.yaml.
+// The keys of the nested objects are joined by dots, {"mail": {"subject": "Welcome"}} holds the message mail.subject.
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		messages, err := decodeCatalog(ext, data)
+		if err != nil {
+			return fmt.Errorf("invalid i18n catalog %s: %w", entry.Name(), err)
+		}
+		b.AddMessages(strings.TrimSuffix(entry.Name(), ext), messages)
+	}
+	return nil
+}
+
+// LoadDir loads the json and yaml catalogs of the directory, see LoadFS.
+func (b *Bundle) LoadDir(dir string) error {
+	return b.LoadFS(os.DirFS(dir), ".")
+}
+
+// Match returns the first of the preferred locales which can be matched, such as the languages of the Accept-Language header, or the default locale.
+// A locale matches itself, its language, such as en for en-GB, and the locales of its language, such as en-US for en.
+func (b *Bundle) Match(preferred ...string) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, p := range preferred {
+		locale := Canonical(p)
+		if locale == "" {
+			continue
+		}
+		if b.supported(locale) {
+			return locale
+		}
+		language, _, _ := strings.Cut(locale, "-")
+		if b.supported(language) {
+			return language
+		}
+		for _, candidate := range b.candidates() {
+			if l, _, _ := strings.Cut(candidate, "-"); l == language {
+				return candidate
+			}
+		}
+	}
+	return b.defaultLocale
+}
+
+func (b *Bundle) supported(locale string) bool {
+	if len(b.locales) > 0 {
+		for _, l := range b.locales {
+			if l == locale {
+				return true
+			}
+		}
+		return false
+	}
+	_, ok := b.catalogs[locale]
+	return ok
+}
+
+// candidates returns the locales which can be matched, sorted, the lock must be held.
+func (b *Bundle) candidates() []string {
+	if len(b.locales) > 0 {
+		return b.locales
+	}
+	locales := make([]string, 0, len(b.catalogs))
+	for locale := range b.catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Lookup returns the message of the key in the catalog of the locale, of its language, or of the default locale.
+func (b *Bundle) Lookup(locale, key string) (string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, l := range b.fallbacks(Canonical(locale)) {
+		if message, ok := b.catalogs[l][key]; ok {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// fallbacks returns the locales looked up for a message of the locale, the lock must be held.
+func (b *Bundle) fallbacks(locale string) []string {
+	locales := make([]string, 0, 3)
+	if locale != "" {
+		locales = append(locales, locale)
+		if language, _, found := strings.Cut(locale, "-"); found {
+			locales = append(locales, language)
+		}
+	}
+	return append(locales, b.defaultLocale)
+}
+
+// T translates the key to the locale, the key itself is the message if no catalog has it.
+// The placeholders of the message, such as {name}, are replaced by the key-value pairs of args.
+func (b *Bundle) T(locale, key string, args ...any) string {
+	message, ok := b.Lookup(locale, key)
+	if !ok {
+		message = key
+	}
+	return Format(message, args...)
+}
+
+// Messages returns the messages of the locale whose keys start with prefix, such as validation., without the prefix.
+// The messages of the default locale and of the language of the locale are overwritten by the ones of the locale.
+func (b *Bundle) Messages(locale, prefix string) map[string]string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	fallbacks := b.fallbacks(Canonical(locale))
+	messages := make(map[string]string)
+	for i := len(fallbacks) - 1; i >= 0; i-- {
+		for key, message := range b.catalogs[fallbacks[i]] {
+			if name, ok := strings.CutPrefix(key, prefix); ok {
+				messages[name] = message
+			}
+		}
+	}
+	return messages
+}
+
+// Format replaces the placeholders of the message, such as {name}, with the values of the key-value pairs of args.
+func Format(message string, args ...any) string {
+	if len(args) < 2 || !strings.ContainsRune(message, '{') {
+		return message
+	}
+	pairs := make([]string, 0, len(args))
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, "{"+fmt.Sprint(args[i])+"}", fmt.Sprint(args[i+1]))
+	}
+	return strings.NewReplacer(pairs...).Replace(message)
+}
+
+// Canonical returns the locale in its canonical form, the language in lower case and the region in upper case, such as zh-CN for zh_cn.
+func Canonical(locale string) string {
+	parts := strings.FieldsFunc(strings.TrimSpace(locale), func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 2:
+			parts[i] = strings.ToUpper(part)
+		case len(part) == 4:
+			// the script, such as zh-Hant
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		default:
+			parts[i] = strings.ToLower(part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// ParseAcceptLanguage returns the locales of an Accept-Language header by decreasing quality, the wildcard is skipped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale  string
+		quality float64
+	}
+	var items []weighted
+	for _, part := range strings.Split(header, ",") {
+		locale, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		locale = strings.TrimSpace(locale)
+		if locale == "" || locale == "*" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > 0 {
+			items = append(items, weighted{locale: locale, quality: quality})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].quality > items[j].quality
+	})
+	locales := make([]string, len(items))
+	for i, item := range items {
+		locales[i] = item.locale
+	}
+	return locales
+}
+
+// decodeCatalog parses a json or yaml catalog and flattens its nested objects.
+func decodeCatalog(ext string, data []byte) (map[string]string, error) {
+	var doc map[string]any
+	if ext == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, err
+		}
+	} else if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	messages := make(map[string]string)
+	if err := flatten("", doc, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func flatten(prefix string, value any, messages map[string]string) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if err := flatten(joinKey(prefix, k), child, messages); err != nil {
+				return err
+			}
+		}
+	case map[any]any:
+		for k, child := range v {
+			if err := flatten(joinKey(prefix, fmt.Sprint(k)), child, messages); err != nil {
+				return err
+			}
+		}
+	case string:
+		messages[prefix] = v
+	case json.Number, bool, int, int64, uint64, float64:
+		messages[prefix] = fmt.Sprint(v)
+	default:
+		return fmt.Errorf("%s: unsupported message of type %T", prefix, v)
+	}
+	return nil
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
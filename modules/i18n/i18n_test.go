@@ -0,0 +1,68 @@
+package i18n
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadFS(t *testing.T) {
+	b := New(Config{})
+	fsys := fstest.MapFS{
+		"locales/en.json":    {Data: []byte(`{"mail": {"welcome": {"subject": "Welcome {name}"}}, "resource not found": "nothing here"}`)},
+		"locales/pt_br.yaml": {Data: []byte("mail:\n  welcome:\n    subject: Bem-vindo {name}\n")},
+		"locales/README.md":  {Data: []byte("# catalogs")},
+	}
+	require.NoError(t, b.LoadFS(fsys, "locales"))
+
+	assert.Equal(t, "Welcome Jane", b.T("en", "mail.welcome.subject", "name", "Jane"))
+	assert.Equal(t, "Bem-vindo Jane", b.T("pt-BR", "mail.welcome.subject", "name", "Jane"))
+	// the catalogs of the app overwrite the ones of the framework
+	assert.Equal(t, "nothing here", b.T("en", "resource not found"))
+	assert.Equal(t, "Ressource introuvable", b.T("fr", "resource not found"))
+	// the default locale, then the key itself
+	assert.Equal(t, "Welcome Jane", b.T("fr", "mail.welcome.subject", "name", "Jane"))
+	assert.Equal(t, "missing.key", b.T("fr", "missing.key"))
+
+	err := b.LoadFS(fstest.MapFS{"de.json": {Data: []byte(`{"list": ["a"]}`)}}, ".")
+	assert.ErrorContains(t, err, "de.json")
+}
+
+func TestMatch(t *testing.T) {
+	b := New(Config{DefaultLocale: "en"})
+	b.AddMessages("pt-BR", map[string]string{"hello": "Olá"})
+
+	assert.Equal(t, "de", b.Match("de-AT"))
+	assert.Equal(t, "zh-CN", b.Match("zh_cn"))
+	assert.Equal(t, "zh-CN", b.Match("zh"))
+	assert.Equal(t, "pt-BR", b.Match("pt"))
+	assert.Equal(t, "fr", b.Match("", "ja", "fr-CA"))
+	assert.Equal(t, "en", b.Match("ja"))
+
+	restricted := New(Config{DefaultLocale: "en", Locales: []string{"en", "de"}})
+	assert.Equal(t, "en", restricted.Match("fr"))
+	assert.Equal(t, []string{"de", "en"}, restricted.Locales())
+}
+
+func TestMessages(t *testing.T) {
+	b := New(Config{})
+	b.AddMessages("de-AT", map[string]string{"validation.required": "{field} fehlt"})
+
+	messages := b.Messages("de-AT", "validation.")
+	assert.Equal(t, "{field} fehlt", messages["required"])
+	assert.Equal(t, "{field} ist keine gültige E-Mail-Adresse", messages["email"])
+	assert.NotContains(t, messages, "Go Back")
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	assert.Equal(t, []string{"fr-CH", "fr", "en", "de"}, ParseAcceptLanguage("fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5"))
+	assert.Equal(t, []string{"en"}, ParseAcceptLanguage("de;q=0, en;q=0.5, ja;q=x"))
+	assert.Empty(t, ParseAcceptLanguage(""))
+}
+
+func TestCanonical(t *testing.T) {
+	assert.Equal(t, "zh-Hant-TW", Canonical("ZH_hant_tw"))
+	assert.Equal(t, "en", Canonical(" EN "))
+	assert.Equal(t, "", Canonical(""))
+}
@@ -3,11 +3,18 @@ package log
 import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"time"
 )
 
 type Log struct {
 	zapLogger *zap.Logger
 	zapSugar  *zap.SugaredLogger
+	// levels are the levels of the root logger and its named loggers, shared by the loggers derived from the root
+	levels *levelRegistry
+	// name is the name of a named logger, empty for the root logger and its children
+	name string
+	// files are the rotated files of the file output, closed by Close
+	files []*RotatingFile
 }
 
 func (jl *Log) Debug(args ...any) {
@@ -90,6 +97,41 @@ func (jl *Log) Panicw(msg string, keysAndValues ...any) {
 	jl.zapSugar.Panicw(msg, keysAndValues...)
 }
 
+// With returns a child logger adding the key-value pairs to its logs, such as a request id.
+// The child shares the level and the outputs of the logger, closing it does not close the log files.
+func (jl *Log) With(keysAndValues ...any) *Log {
+	zapLogger := jl.zapLogger.Sugar().With(keysAndValues...).Desugar()
+	return &Log{
+		zapLogger: zapLogger,
+		zapSugar:  zapLogger.Sugar().WithOptions(zap.AddCallerSkip(1)),
+		levels:    jl.levels,
+		name:      jl.name,
+	}
+}
+
+// Named returns the logger of a module, such as queue, its logs carry the logger name and its level can be changed with SetLevelFor.
+// Its level follows the level of the root logger until it is set, the loggers with the same name share their level.
+// The name of a named logger is appended to the name of its parent, separated by a dot.
+func (jl *Log) Named(name string) *Log {
+	fullName := name
+	if jl.name != "" {
+		fullName = jl.name + "." + name
+	}
+	level := jl.levels.namedLevel(fullName)
+	zapLogger := jl.zapLogger.Named(name).WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		if lc, ok := core.(*levelCore); ok {
+			core = lc.Core
+		}
+		return &levelCore{Core: core, level: level}
+	}))
+	return &Log{
+		zapLogger: zapLogger,
+		zapSugar:  zapLogger.Sugar().WithOptions(zap.AddCallerSkip(1)),
+		levels:    jl.levels,
+		name:      fullName,
+	}
+}
+
 func (jl *Log) GetZapLogger() *zap.Logger {
 	return jl.zapLogger
 }
@@ -98,6 +140,31 @@ func (jl *Log) GetZapSugarLogger() *zap.SugaredLogger {
 	return jl.zapSugar
 }
 
+// SetLevel changes the minimum level of the logs at runtime, level is debug, info, warn, error, dpanic, panic or fatal.
+// The level of a named logger is changed for its name only, see SetLevelFor.
+func (jl *Log) SetLevel(level string) error {
+	return jl.levels.set(jl.name, level, 0)
+}
+
+// Level returns the minimum level of the logs, such as info.
+func (jl *Log) Level() string {
+	return jl.levels.level(jl.name).Level
+}
+
+// SetLevelFor changes the level of the named logger, or of the root logger if name is empty, at runtime.
+// An empty level makes a named logger follow the root level again. If revertAfter is positive, the previous level is restored after it,
+// such as debug for ten minutes in production.
+func (jl *Log) SetLevelFor(name, level string, revertAfter time.Duration) error {
+	return jl.levels.set(name, level, revertAfter)
+}
+
+// Levels returns the level of the root logger, then of the named loggers sorted by name.
+func (jl *Log) Levels() []LoggerLevel {
+	return jl.levels.levels()
+}
+
+// Close flushes the logs and closes the log files, the logs written after Close are lost.
 func (jl *Log) Close() {
-	jl.zapLogger.Sync()
+	_ = jl.zapLogger.Sync()
+	_ = jl.closeFiles()
 }
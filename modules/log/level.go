@@ -0,0 +1,201 @@
+package log
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoggerLevel is the level of the root logger or of a named logger, see Levels.
+type LoggerLevel struct {
+	// Name is the name of the named logger, such as queue, empty for the root logger
+	Name string `json:"name"`
+	// Level is the minimum level of the logs, such as info
+	Level string `json:"level"`
+	// Inherited reports whether the named logger follows the level of the root logger
+	Inherited bool `json:"inherited"`
+	// RevertAt is the time the previous level is restored, if the level was set for a duration
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// namedLevel is the level of a named logger, it follows the level of the root logger until it is set.
+type namedLevel struct {
+	root  zap.AtomicLevel
+	level zap.AtomicLevel
+	set   atomic.Bool
+}
+
+func (l *namedLevel) Enabled(level zapcore.Level) bool {
+	if l.set.Load() {
+		return l.level.Enabled(level)
+	}
+	return l.root.Enabled(level)
+}
+
+// levelState is a level to restore, set is false when a named logger inherits the root level.
+type levelState struct {
+	level zapcore.Level
+	set   bool
+}
+
+type levelRevert struct {
+	timer *time.Timer
+	at    time.Time
+	// prev is the level before the first of the pending changes, a later change for a duration does not extend the change before it
+	prev levelState
+}
+
+// levelRegistry holds the levels of a root logger and of its named loggers, it is shared by all the loggers derived from the root.
+type levelRegistry struct {
+	root    zap.AtomicLevel
+	mu      sync.Mutex
+	named   map[string]*namedLevel
+	reverts map[string]*levelRevert
+}
+
+func newLevelRegistry(root zap.AtomicLevel) *levelRegistry {
+	return &levelRegistry{root: root, named: make(map[string]*namedLevel), reverts: make(map[string]*levelRevert)}
+}
+
+// namedLevel returns the level of the named logger, it is created on first use.
+func (r *levelRegistry) namedLevel(name string) *namedLevel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	level, ok := r.named[name]
+	if !ok {
+		level = &namedLevel{root: r.root, level: zap.NewAtomicLevel()}
+		r.named[name] = level
+	}
+	return level
+}
+
+func (r *levelRegistry) state(name string) (levelState, error) {
+	if name == "" {
+		return levelState{level: r.root.Level(), set: true}, nil
+	}
+	level, ok := r.named[name]
+	if !ok {
+		return levelState{}, fmt.Errorf("unknown logger %q", name)
+	}
+	return levelState{level: level.level.Level(), set: level.set.Load()}, nil
+}
+
+func (r *levelRegistry) apply(name string, s levelState) {
+	if name == "" {
+		r.root.SetLevel(s.level)
+		return
+	}
+	level := r.named[name]
+	level.level.SetLevel(s.level)
+	level.set.Store(s.set)
+}
+
+// set changes the level of the named logger, or of the root logger if name is empty.
+// An empty level makes a named logger inherit the root level again. The previous level is restored after revertAfter, if it is positive.
+func (r *levelRegistry) set(name, level string, revertAfter time.Duration) error {
+	next := levelState{}
+	if level != "" {
+		l, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		next = levelState{level: l, set: true}
+	} else if name == "" {
+		return fmt.Errorf("level is required for the root logger")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prev, err := r.state(name)
+	if err != nil {
+		return err
+	}
+	if !next.set {
+		// the inherited level is kept, so that the state can be restored
+		next.level = prev.level
+	}
+	if pending, ok := r.reverts[name]; ok {
+		pending.timer.Stop()
+		delete(r.reverts, name)
+		prev = pending.prev
+	}
+	r.apply(name, next)
+	if revertAfter > 0 {
+		revert := &levelRevert{at: time.Now().Add(revertAfter), prev: prev}
+		revert.timer = time.AfterFunc(revertAfter, func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			// the change may have been replaced since the timer was started
+			if r.reverts[name] != revert {
+				return
+			}
+			delete(r.reverts, name)
+			r.apply(name, revert.prev)
+		})
+		r.reverts[name] = revert
+	}
+	return nil
+}
+
+func (r *levelRegistry) level(name string) LoggerLevel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.levelLocked(name)
+}
+
+func (r *levelRegistry) levelLocked(name string) LoggerLevel {
+	ll := LoggerLevel{Name: name, Level: r.root.Level().String()}
+	if level, ok := r.named[name]; ok && level.set.Load() {
+		ll.Level = level.level.Level().String()
+	} else if name != "" {
+		ll.Inherited = true
+	}
+	if revert, ok := r.reverts[name]; ok {
+		at := revert.at
+		ll.RevertAt = &at
+	}
+	return ll
+}
+
+// levels returns the level of the root logger, then of the named loggers sorted by name.
+func (r *levelRegistry) levels() []LoggerLevel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.named))
+	for name := range r.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	levels := make([]LoggerLevel, 0, len(names)+1)
+	levels = append(levels, r.levelLocked(""))
+	for _, name := range names {
+		levels = append(levels, r.levelLocked(name))
+	}
+	return levels
+}
+
+// levelCore filters the logs of the wrapped core by the level of the logger, the wrapped core accepts all levels.
+// It is the outermost core, Named replaces its level with the level of the named logger.
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(entry.Level) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
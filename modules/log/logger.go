@@ -1,8 +1,14 @@
 package log
 
 import (
+	"errors"
+	"fmt"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"io"
+	"os"
+	"sort"
+	"time"
 )
 
 type Level int
@@ -12,6 +18,46 @@ const (
 	LevelDev
 )
 
+// Outputs of the logs, see Config.Outputs
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+)
+
+// Formats of the logs, see Config.Format
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Config configures the logger created by NewWithConfig, the zero values keep the preset selected by Development.
+type Config struct {
+	// Development selects the development preset: console format with colored levels, debug level and stack traces from the warnings.
+	// The production preset logs json from the info level, with stack traces from the errors, and samples the repeated logs.
+	Development bool
+	// Level is the minimum level: debug, info, warn, error, dpanic, panic or fatal, it can be changed later with SetLevel
+	Level string
+	// Format is json or console
+	Format string
+	// Outputs are stdout, stderr or file, file writes to the rotated file of File. Default is stderr, unless Writers are given.
+	Outputs []string
+	// File is the log file of the file output
+	File FileConfig
+	// Writers are additional outputs, such as a log shipper, they are not closed by Close
+	Writers []io.Writer
+	// Sampling enables the sampling of the repeated logs, nil keeps the preset
+	Sampling *bool
+	// SamplingInitial is the number of logs with the same level and message written every second before sampling, default 100
+	SamplingInitial int
+	// SamplingThereafter writes one of every SamplingThereafter logs after SamplingInitial, default 100
+	SamplingThereafter int
+	// Fields are added to every log, such as the app name and version
+	Fields map[string]any
+	// Redaction masks the sensitive field values and message parts, such as passwords, tokens and emails, nil disables it
+	Redaction *RedactConfig
+}
+
 func New(level ...Level) *Log {
 	ll := LevelProd
 	if len(level) == 0 {
@@ -19,13 +65,139 @@ func New(level ...Level) *Log {
 	} else {
 		ll = level[0]
 	}
-	j := &Log{}
+	// the presets only write to stderr, they cannot fail
+	j, _ := NewWithConfig(Config{Development: ll == LevelDev})
+	return j
+}
+
+// NewWithConfig creates a logger with the outputs, format and sampling of cfg.
+func NewWithConfig(cfg Config) (*Log, error) {
 	zapCfg := zap.NewProductionConfig()
-	if ll == LevelDev {
+	if cfg.Development {
 		zapCfg = zap.NewDevelopmentConfig()
 		zapCfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	}
-	j.zapLogger, _ = zapCfg.Build()
+	if cfg.Level != "" {
+		l, err := zapcore.ParseLevel(cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		zapCfg.Level.SetLevel(l)
+	}
+
+	var encoder zapcore.Encoder
+	switch cfg.Format {
+	case "":
+		if zapCfg.Encoding == FormatJSON {
+			encoder = zapcore.NewJSONEncoder(zapCfg.EncoderConfig)
+		} else {
+			encoder = zapcore.NewConsoleEncoder(zapCfg.EncoderConfig)
+		}
+	case FormatJSON:
+		// colors are escape sequences in json
+		zapCfg.EncoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
+		encoder = zapcore.NewJSONEncoder(zapCfg.EncoderConfig)
+	case FormatConsole:
+		zapCfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		encoder = zapcore.NewConsoleEncoder(zapCfg.EncoderConfig)
+	default:
+		return nil, fmt.Errorf("unsupported log format %q, use json or console", cfg.Format)
+	}
+
+	var redact *Redactor
+	if cfg.Redaction != nil {
+		r, err := NewRedactor(*cfg.Redaction)
+		if err != nil {
+			return nil, err
+		}
+		redact = r
+	}
+
+	j := &Log{levels: newLevelRegistry(zapCfg.Level)}
+	outputs := cfg.Outputs
+	if len(outputs) == 0 && len(cfg.Writers) == 0 {
+		outputs = []string{OutputStderr}
+	}
+	syncers := make([]zapcore.WriteSyncer, 0, len(outputs)+len(cfg.Writers))
+	for _, output := range outputs {
+		switch output {
+		case OutputStdout:
+			syncers = append(syncers, zapcore.Lock(os.Stdout))
+		case OutputStderr:
+			syncers = append(syncers, zapcore.Lock(os.Stderr))
+		case OutputFile:
+			file, err := NewRotatingFile(cfg.File)
+			if err != nil {
+				_ = j.closeFiles()
+				return nil, err
+			}
+			j.files = append(j.files, file)
+			syncers = append(syncers, file)
+		default:
+			_ = j.closeFiles()
+			return nil, fmt.Errorf("unsupported log output %q, use stdout, stderr or file", output)
+		}
+	}
+	for _, w := range cfg.Writers {
+		syncers = append(syncers, zapcore.Lock(zapcore.AddSync(w)))
+	}
+
+	opts := []zap.Option{zap.ErrorOutput(zapcore.Lock(os.Stderr)), zap.AddCaller()}
+	if cfg.Development {
+		opts = append(opts, zap.Development(), zap.AddStacktrace(zapcore.WarnLevel))
+	} else {
+		opts = append(opts, zap.AddStacktrace(zapcore.ErrorLevel))
+	}
+	if len(cfg.Fields) > 0 {
+		keys := make([]string, 0, len(cfg.Fields))
+		for k := range cfg.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fields := make([]zap.Field, len(keys))
+		for i, k := range keys {
+			fields[i] = zap.Any(k, cfg.Fields[k])
+		}
+		opts = append(opts, zap.Fields(fields...))
+	}
+
+	// the levels are filtered by the outermost core, so that the named loggers can log below the root level
+	core := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(syncers...), zapcore.DebugLevel)
+	if redact != nil {
+		// the sampling wraps the redaction, so that the sampled logs are not masked
+		core = &redactCore{Core: core, r: redact}
+	}
+	sampling := zapCfg.Sampling != nil
+	if cfg.Sampling != nil {
+		sampling = *cfg.Sampling
+	}
+	if sampling {
+		initial, thereafter := cfg.SamplingInitial, cfg.SamplingThereafter
+		if initial <= 0 {
+			initial = 100
+		}
+		if thereafter <= 0 {
+			thereafter = 100
+		}
+		core = zapcore.NewSamplerWithOptions(core, time.Second, initial, thereafter)
+	}
+	j.zapLogger = zap.New(&levelCore{Core: core, level: zapCfg.Level}, opts...)
 	j.zapSugar = j.zapLogger.Sugar().WithOptions(zap.AddCallerSkip(1))
+	return j, nil
+}
+
+// NewNop creates a logger which discards all logs, it is useful in tests.
+func NewNop() *Log {
+	j := &Log{zapLogger: zap.NewNop(), levels: newLevelRegistry(zap.NewAtomicLevel())}
+	j.zapSugar = j.zapLogger.Sugar()
 	return j
 }
+
+func (jl *Log) closeFiles() error {
+	var errs []error
+	for _, file := range jl.files {
+		errs = append(errs, file.Close())
+	}
+	jl.files = nil
+	return errors.Join(errs...)
+}
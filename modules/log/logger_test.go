@@ -0,0 +1,244 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewWithConfig(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewWithConfig(Config{
+		Level:   "warn",
+		Format:  FormatJSON,
+		Writers: []io.Writer{&buf},
+		Fields:  map[string]any{"app": "TestApp", "env": "test"},
+	})
+	require.NoError(t, err)
+	logger.Info("hidden")
+	logger.Warnw("visible", "user", "jane")
+	logger.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "warn", entry["level"])
+	assert.Equal(t, "visible", entry["msg"])
+	assert.Equal(t, "jane", entry["user"])
+	assert.Equal(t, "TestApp", entry["app"])
+	assert.Equal(t, "test", entry["env"])
+
+	require.NoError(t, logger.SetLevel("info"))
+	buf.Reset()
+	logger.Info("shown")
+	assert.Contains(t, buf.String(), "shown")
+}
+
+func TestNewWithConfigErrors(t *testing.T) {
+	_, err := NewWithConfig(Config{Format: "xml"})
+	assert.Error(t, err)
+	_, err = NewWithConfig(Config{Outputs: []string{"syslog"}})
+	assert.Error(t, err)
+	_, err = NewWithConfig(Config{Level: "verbose"})
+	assert.Error(t, err)
+	_, err = NewWithConfig(Config{Outputs: []string{OutputFile}})
+	assert.Error(t, err)
+}
+
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+	enabled := true
+	logger, err := NewWithConfig(Config{
+		Format:             FormatJSON,
+		Writers:            []io.Writer{&buf},
+		Sampling:           &enabled,
+		SamplingInitial:    2,
+		SamplingThereafter: 5,
+	})
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		logger.Info("repeated")
+	}
+	// the first 2, then the 5th after them
+	assert.Equal(t, 3, strings.Count(buf.String(), "repeated"))
+}
+
+func TestFileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	logger, err := NewWithConfig(Config{Format: FormatConsole, Outputs: []string{OutputFile}, File: FileConfig{Path: path}})
+	require.NoError(t, err)
+	logger.Info("to the file")
+	logger.Close()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "to the file")
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	file, err := NewRotatingFile(FileConfig{Path: path, MaxSize: 1, MaxBackups: 2, MaxAge: 7})
+	require.NoError(t, err)
+	defer file.Close()
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	file.now = func() time.Time {
+		return now
+	}
+
+	entry := bytes.Repeat([]byte("x"), megabyte/2)
+	for i := 0; i < 8; i++ {
+		_, err := file.Write(entry)
+		require.NoError(t, err)
+		now = now.Add(time.Minute)
+	}
+	// every third write rotates, the oldest backups beyond 2 are removed
+	backups, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	require.NoError(t, err)
+	assert.Len(t, backups, 2)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(megabyte), info.Size())
+
+	// the backups older than MaxAge are removed on the next rotation
+	now = now.Add(8 * 24 * time.Hour)
+	require.NoError(t, file.Rotate())
+	backups, err = filepath.Glob(filepath.Join(dir, "app-*.log"))
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+
+	require.NoError(t, file.Close())
+	_, err = file.Write(entry)
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewWithConfig(Config{Format: FormatJSON, Writers: []io.Writer{&buf}})
+	require.NoError(t, err)
+	child := logger.With("request_id", "abc")
+	child.Info("from the child")
+	logger.Info("from the parent")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"request_id":"abc"`)
+	assert.Contains(t, lines[0], "logger_test.go")
+	assert.NotContains(t, lines[1], "request_id")
+
+	// the child follows the level of the parent
+	require.NoError(t, logger.SetLevel("error"))
+	buf.Reset()
+	child.Info("hidden")
+	assert.Empty(t, buf.String())
+}
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewWithConfig(Config{
+		Format:    FormatJSON,
+		Writers:   []io.Writer{&buf},
+		Redaction: &RedactConfig{Keys: []string{"card"}, Patterns: []string{`pin (\d+)`}},
+	})
+	require.NoError(t, err)
+	logger.With("access_token", "t0k3n").Infow("login of jane@example.com with pin 1234",
+		"X-Auth-Token", "abc",
+		"user", map[string]any{"sub": "42", "email": "jane@example.com"},
+		"credit_card", "4111",
+		"url", "/callback?state=s1&access_token=t0k3n",
+		"error", errors.New("exchange failed: Bearer t0k3n"),
+		"path", "/orders",
+	)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "login of ****** with pin ******", entry["msg"])
+	assert.Equal(t, RedactMask, entry["access_token"])
+	assert.Equal(t, RedactMask, entry["X-Auth-Token"])
+	assert.Equal(t, RedactMask, entry["user"])
+	assert.Equal(t, RedactMask, entry["credit_card"])
+	assert.Equal(t, "/callback?state=s1&access_token=******", entry["url"])
+	assert.Equal(t, "exchange failed: Bearer ******", entry["error"])
+	assert.Equal(t, "/orders", entry["path"])
+	assert.NotContains(t, buf.String(), "t0k3n")
+
+	// nested values are masked by key
+	buf.Reset()
+	logger.Infow("claims", "claims", map[string]any{"sub": "42", "email": "jane@example.com", "profile": map[string]any{"password": "p"}})
+	assert.Contains(t, buf.String(), `"sub":"42"`)
+	assert.NotContains(t, buf.String(), "jane@example.com")
+	assert.NotContains(t, buf.String(), `"password":"p"`)
+
+	_, err = NewWithConfig(Config{Redaction: &RedactConfig{Patterns: []string{"("}}})
+	assert.Error(t, err)
+}
+
+func TestNamedLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewWithConfig(Config{Level: "info", Format: FormatJSON, Writers: []io.Writer{&buf}})
+	require.NoError(t, err)
+	queue := logger.Named("queue")
+	mongo := logger.Named("mongodb")
+
+	require.NoError(t, logger.SetLevelFor("queue", "debug", 0))
+	queue.Debug("queue debug")
+	mongo.Debug("mongodb debug")
+	logger.Debug("root debug")
+	assert.Contains(t, buf.String(), `"logger":"queue"`)
+	assert.Contains(t, buf.String(), "queue debug")
+	assert.NotContains(t, buf.String(), "mongodb debug")
+	assert.NotContains(t, buf.String(), "root debug")
+
+	// the named loggers which are not set follow the root level
+	require.NoError(t, logger.SetLevel("error"))
+	buf.Reset()
+	mongo.Warn("mongodb warn")
+	queue.With("job", "1").Debug("queue child debug")
+	assert.NotContains(t, buf.String(), "mongodb warn")
+	assert.Contains(t, buf.String(), "queue child debug")
+
+	levels := logger.Levels()
+	require.Len(t, levels, 3)
+	assert.Equal(t, LoggerLevel{Name: "", Level: "error"}, levels[0])
+	assert.Equal(t, LoggerLevel{Name: "mongodb", Level: "error", Inherited: true}, levels[1])
+	assert.Equal(t, LoggerLevel{Name: "queue", Level: "debug"}, levels[2])
+
+	require.NoError(t, logger.SetLevelFor("queue", "", 0))
+	assert.Equal(t, "error", queue.Level())
+	assert.Error(t, logger.SetLevelFor("mail", "debug", 0))
+	assert.Error(t, logger.SetLevelFor("", "", 0))
+	assert.Error(t, logger.SetLevelFor("queue", "verbose", 0))
+}
+
+func TestLevelRevert(t *testing.T) {
+	logger, err := NewWithConfig(Config{Level: "info", Writers: []io.Writer{io.Discard}})
+	require.NoError(t, err)
+	logger.Named("queue")
+
+	require.NoError(t, logger.SetLevelFor("", "debug", 50*time.Millisecond))
+	// a second change for a duration restores the level before the first one
+	require.NoError(t, logger.SetLevelFor("", "warn", 50*time.Millisecond))
+	require.NoError(t, logger.SetLevelFor("queue", "debug", 50*time.Millisecond))
+	levels := logger.Levels()
+	assert.Equal(t, "warn", levels[0].Level)
+	assert.NotNil(t, levels[0].RevertAt)
+
+	assert.Eventually(t, func() bool {
+		levels := logger.Levels()
+		return levels[0].Level == "info" && levels[1].Inherited && levels[0].RevertAt == nil
+	}, time.Second, 10*time.Millisecond)
+
+	// a change without duration cancels the pending revert
+	require.NoError(t, logger.SetLevelFor("", "debug", 20*time.Millisecond))
+	require.NoError(t, logger.SetLevel("error"))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "error", logger.Level())
+}
@@ -0,0 +1,247 @@
+package log
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"regexp"
+	"strings"
+)
+
+// RedactMask replaces the redacted values.
+const RedactMask = "******"
+
+// DefaultRedactKeys are the field keys whose values are always masked, such as the session "user" payload.
+// A key matches if it ends with one of them, case-insensitively and with - read as _, so token also matches access_token and X-Auth-Token.
+var DefaultRedactKeys = []string{
+	"password", "passwd", "secret", "token", "authorization", "cookie", "api_key", "apikey", "private_key", "email", "user",
+}
+
+// defaultRedactPatterns mask the bearer tokens, the JWTs and the email addresses in the messages and string values.
+// The match is masked, or its first group if the pattern has groups.
+var defaultRedactPatterns = []string{
+	`(?i)bearer\s+([a-z0-9\-._~+/]+=*)`,
+	`eyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]*`,
+	`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`,
+}
+
+// RedactConfig configures the masking of the sensitive data in the logs, it is always added to the defaults.
+type RedactConfig struct {
+	// Keys are field keys whose values are masked in addition to DefaultRedactKeys, they are matched like them.
+	// Their key=value and "key":"value" pairs are masked in the messages as well.
+	Keys []string
+	// Patterns are regular expressions masked in the messages and the string values, in addition to the bearer tokens, JWTs and email addresses.
+	// The match is masked, or its first group if the pattern has groups, such as `card=(\d+)`.
+	Patterns []string
+}
+
+// Redactor masks the values of sensitive keys in the log fields, and the sensitive patterns in the log messages.
+// It can mask texts written elsewhere than the logs as well, such as captured request bodies.
+type Redactor struct {
+	keys     []string
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor creates a redactor masking the defaults and the keys and patterns of cfg.
+func NewRedactor(cfg RedactConfig) (*Redactor, error) {
+	r := &Redactor{}
+	for _, key := range append(append([]string{}, DefaultRedactKeys...), cfg.Keys...) {
+		if key = normalizeKey(key); key != "" {
+			r.keys = append(r.keys, key)
+		}
+	}
+	quoted := make([]string, len(r.keys))
+	for i, key := range r.keys {
+		quoted[i] = strings.ReplaceAll(regexp.QuoteMeta(key), "_", "[_-]")
+	}
+	// key=value and "key": "value" pairs of the sensitive keys, such as the query of a url or a json payload
+	pairs := `(?i)["']?[a-z0-9_-]*(?:` + strings.Join(quoted, "|") + `)["']?\s*[:=]\s*["']?([^"'&\s,;}]+)`
+	for _, pattern := range append(append([]string{pairs}, defaultRedactPatterns...), cfg.Patterns...) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "-", "_")
+}
+
+// Sensitive reports whether the values of the field key are masked.
+func (r *Redactor) Sensitive(key string) bool {
+	key = normalizeKey(key)
+	for _, k := range r.keys {
+		if strings.HasSuffix(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact masks the sensitive patterns in s, and the values of the key=value pairs of the sensitive keys.
+func (r *Redactor) Redact(s string) string {
+	for _, re := range r.patterns {
+		matches := re.FindAllStringSubmatchIndex(s, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			start, end := m[0], m[1]
+			// the first group, if it matched
+			if len(m) > 2 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(RedactMask)
+			last = end
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
+
+// value masks the values of the sensitive keys of maps, and the sensitive patterns of strings, it reports whether v was changed.
+func (r *Redactor) value(v any) (any, bool) {
+	switch val := v.(type) {
+	case string:
+		masked := r.Redact(val)
+		return masked, masked != val
+	case map[string]any:
+		var out map[string]any
+		for k, item := range val {
+			masked, changed := any(RedactMask), true
+			if !r.Sensitive(k) {
+				masked, changed = r.value(item)
+			}
+			if changed {
+				if out == nil {
+					out = make(map[string]any, len(val))
+					for k2, v2 := range val {
+						out[k2] = v2
+					}
+				}
+				out[k] = masked
+			}
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	case map[string]string:
+		var out map[string]string
+		for k, item := range val {
+			masked := RedactMask
+			if !r.Sensitive(k) {
+				masked = r.Redact(item)
+			}
+			if masked != item {
+				if out == nil {
+					out = make(map[string]string, len(val))
+					for k2, v2 := range val {
+						out[k2] = v2
+					}
+				}
+				out[k] = masked
+			}
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	case []any:
+		var out []any
+		for i, item := range val {
+			if masked, changed := r.value(item); changed {
+				if out == nil {
+					out = append([]any{}, val...)
+				}
+				out[i] = masked
+			}
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	}
+	return v, false
+}
+
+// field masks the field, it reports whether it was changed.
+func (r *Redactor) field(f zapcore.Field) (zapcore.Field, bool) {
+	if f.Type == zapcore.SkipType || f.Key == "" {
+		return f, false
+	}
+	if r.Sensitive(f.Key) {
+		return zap.String(f.Key, RedactMask), true
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		if masked := r.Redact(f.String); masked != f.String {
+			f.String = masked
+			return f, true
+		}
+	case zapcore.ByteStringType:
+		if b, ok := f.Interface.([]byte); ok {
+			if masked := r.Redact(string(b)); masked != string(b) {
+				return zap.String(f.Key, masked), true
+			}
+		}
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			if masked := r.Redact(err.Error()); masked != err.Error() {
+				return zap.String(f.Key, masked), true
+			}
+		}
+	case zapcore.ReflectType:
+		if masked, changed := r.value(f.Interface); changed {
+			return zap.Any(f.Key, masked), true
+		}
+	}
+	return f, false
+}
+
+// fields masks the fields, the slice is copied if any of them is changed.
+func (r *Redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		if masked, changed := r.field(f); changed {
+			if out == nil {
+				out = append([]zapcore.Field{}, fields...)
+			}
+			out[i] = masked
+		}
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// redactCore masks the messages and fields before they are written by the wrapped core.
+// It must wrap the core writing the logs, so that Check does not bypass it.
+type redactCore struct {
+	zapcore.Core
+	r *Redactor
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.fields(fields)), r: c.r}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.r.Redact(entry.Message)
+	return c.Core.Write(entry, c.r.fields(fields))
+}
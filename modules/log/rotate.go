@@ -0,0 +1,186 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxSize = 100
+	megabyte       = 1024 * 1024
+	// backupTimeFormat is the rotation time in the name of the rotated files, app.log becomes app-2024-05-01T10-30-00.000.log
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+)
+
+// FileConfig configures the log file of the file output.
+type FileConfig struct {
+	// Path of the log file, the rotated files are kept in the same directory with their rotation time in their name
+	Path string
+	// MaxSize is the size in megabytes from which the file is rotated, default is 100
+	MaxSize int
+	// MaxAge is the number of days the rotated files are kept, 0 keeps them regardless of their age
+	MaxAge int
+	// MaxBackups is the number of rotated files kept, 0 keeps them all
+	MaxBackups int
+}
+
+// RotatingFile is a log file which is rotated when it reaches its max size, the old rotated files are removed by age and count.
+// It is safe for concurrent use.
+type RotatingFile struct {
+	mu     sync.Mutex
+	cfg    FileConfig
+	file   *os.File
+	size   int64
+	closed bool
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewRotatingFile opens the log file for appending, the directory is created if needed.
+func NewRotatingFile(cfg FileConfig) (*RotatingFile, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("log file path is required for the file output")
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = defaultMaxSize
+	}
+	f := &RotatingFile{cfg: cfg, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.cfg.Path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write writes a log entry, the file is rotated first if the entry would exceed the max size.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	// an entry larger than the max size is written to an empty file rather than dropped
+	if f.size > 0 && f.size+int64(len(p)) > int64(f.cfg.MaxSize)*megabyte {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Sync flushes the file to the disk.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Close closes the file, the writes after Close fail.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	return f.file.Close()
+}
+
+// Rotate renames the log file with the current time and opens a new one.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	dir, prefix, ext := f.nameParts()
+	backup := filepath.Join(dir, prefix+f.now().Format(backupTimeFormat)+ext)
+	if err := os.Rename(f.cfg.Path, backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.removeBackups()
+	return nil
+}
+
+// nameParts splits the path of the log file into its directory, the prefix of the rotated files and the extension, such as app- and .log.
+func (f *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(f.cfg.Path)
+	name := filepath.Base(f.cfg.Path)
+	ext = filepath.Ext(name)
+	return dir, strings.TrimSuffix(name, ext) + "-", ext
+}
+
+// removeBackups removes the rotated files older than MaxAge and the oldest ones beyond MaxBackups, the errors are ignored.
+func (f *RotatingFile) removeBackups() {
+	if f.cfg.MaxAge <= 0 && f.cfg.MaxBackups <= 0 {
+		return
+	}
+	dir, prefix, ext := f.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type backup struct {
+		path string
+		time time.Time
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext), time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), time: t})
+	}
+	// newest first
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	cutoff := f.now().Add(-time.Duration(f.cfg.MaxAge) * 24 * time.Hour)
+	for i, b := range backups {
+		if f.cfg.MaxBackups > 0 && i >= f.cfg.MaxBackups || f.cfg.MaxAge > 0 && b.time.Before(cutoff) {
+			_ = os.Remove(b.path)
+		}
+	}
+}
@@ -1,14 +1,23 @@
 package mail
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"go.oease.dev/goe/contracts"
+	"go.oease.dev/goe/modules/i18n"
 	"go.oease.dev/goe/modules/mail/providers"
 	"go.oease.dev/goe/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	htmltemplate "html/template"
 	"io"
 	"net/mail"
 	"sync"
+	texttemplate "text/template"
 )
 
 // EmailDeliveryQueueName is the queue name for email delivery
@@ -22,9 +31,31 @@ type MailerManager struct {
 	queue           contracts.Queue
 	fromName        string
 	fromEmail       string
+	observer        Observer
+	tracer          trace.Tracer
+	translator      Translator
 	mu              sync.RWMutex
 }
 
+// Observer is notified of every email sent by a provider, queued emails are notified when they are consumed.
+// It is used to collect send metrics per provider.
+type Observer interface {
+	ObserveMailSend(provider string, err error)
+}
+
+// Translator translates the subjects and the templates of the emails to their locale, such as the i18n bundle of the app.
+type Translator interface {
+	T(locale, key string, args ...any) string
+}
+
+// TemplateFuncs are the functions of the email templates, they must be added to the templates before they are parsed.
+// The t function translates a key to the locale of the email, such as {{ t "mail.welcome.title" "name" .Name }}.
+var TemplateFuncs = texttemplate.FuncMap{
+	"t": func(key string, args ...any) string {
+		return i18n.Format(key, args...)
+	},
+}
+
 // NewMailerManager creates a new MailerManager
 func NewMailerManager(logger contracts.Logger, queue contracts.Queue, fromName, fromEmail string) *MailerManager {
 	return &MailerManager{
@@ -54,6 +85,69 @@ func (m *MailerManager) RegisterProvider(provider contracts.MailProvider, factor
 	return nil
 }
 
+// WithObserver sets the observer notified of the sent emails.
+func (m *MailerManager) WithObserver(observer Observer) *MailerManager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observer = observer
+	return m
+}
+
+// WithTracer sets the tracer starting a span for each email sent by a provider.
+func (m *MailerManager) WithTracer(tracer trace.Tracer) *MailerManager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tracer = tracer
+	return m
+}
+
+// WithTranslator sets the translator of the subjects and the templates of the emails.
+func (m *MailerManager) WithTranslator(translator Translator) *MailerManager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.translator = translator
+	return m
+}
+
+// translate translates the key to the locale, the key is formatted as is without a translator.
+func (m *MailerManager) translate(locale, key string, args ...any) string {
+	m.mu.RLock()
+	translator := m.translator
+	m.mu.RUnlock()
+	if translator == nil {
+		return i18n.Format(key, args...)
+	}
+	return translator.T(locale, key, args...)
+}
+
+// send sends the message with the provider in a span child of the span in ctx, and notifies the observer
+func (m *MailerManager) send(ctx context.Context, provider contracts.EmailProvider, name contracts.MailProvider, message *contracts.EmailMessage) error {
+	m.mu.RLock()
+	observer := m.observer
+	tracer := m.tracer
+	m.mu.RUnlock()
+	if tracer == nil {
+		tracer = noop.NewTracerProvider().Tracer("")
+	}
+	_, span := tracer.Start(ctx, "mail.send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("mail.provider", string(provider.Name())),
+			attribute.Int("mail.recipients", len(message.To)+len(message.Cc)+len(message.Bcc)),
+		),
+	)
+	err := provider.Send(message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	if observer != nil {
+		observer.ObserveMailSend(string(name), err)
+	}
+	return err
+}
+
 // SetDefaultProvider sets the default email provider
 func (m *MailerManager) SetDefaultProvider(provider contracts.MailProvider) error {
 	m.mu.Lock()
@@ -74,6 +168,7 @@ func (m *MailerManager) GetSender(provider contracts.MailProvider) contracts.Ema
 
 	return &emailSender{
 		manager:  m,
+		ctx:      context.Background(),
 		provider: provider,
 		from: &mail.Address{
 			Name:    m.fromName,
@@ -107,6 +202,7 @@ func (m *MailerManager) getProvider(provider contracts.MailProvider) (contracts.
 // emailSender implements the contracts.EmailSender interface
 type emailSender struct {
 	manager     *MailerManager
+	ctx         context.Context
 	provider    contracts.MailProvider
 	from        *mail.Address
 	to          []*mail.Address
@@ -117,6 +213,18 @@ type emailSender struct {
 	textBody    string
 	headers     map[string]string
 	attachments map[string]string
+	locale      string
+	// the templates are rendered when the email is sent, so that they are translated to the locale set after them
+	htmlTemplate *htmltemplate.Template
+	htmlData     any
+	textTemplate *texttemplate.Template
+	textData     any
+}
+
+// WithContext sets the context of the email, its span is the parent of the send span, also when the email is queued
+func (s *emailSender) WithContext(ctx context.Context) contracts.EmailSender {
+	s.ctx = ctx
+	return s
 }
 
 // To sets the recipients
@@ -167,6 +275,62 @@ func (s *emailSender) Attachments(a map[string]string) contracts.EmailSender {
 	return s
 }
 
+// Locale sets the locale of the email, the subject and the templates are translated to it
+func (s *emailSender) Locale(locale string) contracts.EmailSender {
+	s.locale = locale
+	return s
+}
+
+// HTMLTemplate renders the HTML body with the template when the email is sent, the template is parsed with TemplateFuncs
+func (s *emailSender) HTMLTemplate(tmpl *htmltemplate.Template, data any) contracts.EmailSender {
+	s.htmlTemplate = tmpl
+	s.htmlData = data
+	return s
+}
+
+// TextTemplate renders the text body with the template when the email is sent, the template is parsed with TemplateFuncs
+func (s *emailSender) TextTemplate(tmpl *texttemplate.Template, data any) contracts.EmailSender {
+	s.textTemplate = tmpl
+	s.textData = data
+	return s
+}
+
+// render translates the subject and renders the templates to the locale of the email
+func (s *emailSender) render() error {
+	if s.locale != "" {
+		s.subject = s.manager.translate(s.locale, s.subject)
+	}
+	funcs := texttemplate.FuncMap{
+		"t": func(key string, args ...any) string {
+			return s.manager.translate(s.locale, key, args...)
+		},
+	}
+	var buf bytes.Buffer
+	if s.htmlTemplate != nil {
+		// the templates are cloned, so that the functions of concurrent emails do not overwrite each other
+		tmpl, err := s.htmlTemplate.Clone()
+		if err != nil {
+			return fmt.Errorf("failed to clone the html template: %w", err)
+		}
+		if err := tmpl.Funcs(funcs).Execute(&buf, s.htmlData); err != nil {
+			return fmt.Errorf("failed to render the html template: %w", err)
+		}
+		s.htmlBody = buf.String()
+		buf.Reset()
+	}
+	if s.textTemplate != nil {
+		tmpl, err := s.textTemplate.Clone()
+		if err != nil {
+			return fmt.Errorf("failed to clone the text template: %w", err)
+		}
+		if err := tmpl.Funcs(funcs).Execute(&buf, s.textData); err != nil {
+			return fmt.Errorf("failed to render the text template: %w", err)
+		}
+		s.textBody = buf.String()
+	}
+	return nil
+}
+
 // Send sends the email
 func (s *emailSender) Send(useQueue ...bool) error {
 	// Get the provider
@@ -175,6 +339,10 @@ func (s *emailSender) Send(useQueue ...bool) error {
 		return err
 	}
 
+	if err := s.render(); err != nil {
+		return err
+	}
+
 	// Create the message
 	message := &contracts.EmailMessage{
 		From:        s.from,
@@ -207,15 +375,15 @@ func (s *emailSender) Send(useQueue ...bool) error {
 		// Queue the message for sending
 		// Note: We can't directly queue the message because it contains io.Reader which can't be serialized
 		// Instead, we'll queue a simplified version and process the attachments when consuming
-		return s.manager.queueMessage(s.provider, message, s.attachments)
+		return s.manager.queueMessage(s.ctx, s.provider, message, s.attachments)
 	}
 
 	// Send the message directly
-	return provider.Send(message)
+	return s.manager.send(s.ctx, provider, s.provider, message)
 }
 
 // queueMessage queues a message for sending
-func (m *MailerManager) queueMessage(provider contracts.MailProvider, message *contracts.EmailMessage, attachments map[string]string) error {
+func (m *MailerManager) queueMessage(ctx context.Context, provider contracts.MailProvider, message *contracts.EmailMessage, attachments map[string]string) error {
 	// Create a queue-friendly version of the message
 	queuedMessage := struct {
 		Provider    contracts.MailProvider `json:"provider"`
@@ -241,12 +409,17 @@ func (m *MailerManager) queueMessage(provider contracts.MailProvider, message *c
 		Attachments: attachments, // Use the original file paths
 	}
 
-	// Push to queue
-	return m.queue.Push(EmailDeliveryQueueName, queuedMessage)
+	// Push to queue, the trace of ctx is carried by the message
+	return m.queue.WithContext(ctx).Push(EmailDeliveryQueueName, queuedMessage)
 }
 
 // ProcessQueuedMessage processes a queued message
 func (m *MailerManager) ProcessQueuedMessage(payload string) bool {
+	return m.ProcessQueuedMessageContext(context.Background(), payload)
+}
+
+// ProcessQueuedMessageContext processes a queued message, the send span is a child of the span in ctx
+func (m *MailerManager) ProcessQueuedMessageContext(ctx context.Context, payload string) bool {
 	// Parse the queued message
 	var queuedMessage struct {
 		Provider    contracts.MailProvider `json:"provider"`
@@ -297,7 +470,8 @@ func (m *MailerManager) ProcessQueuedMessage(payload string) bool {
 	}
 
 	// Send the message
-	if err := provider.Send(message); err != nil {
+	err = m.send(ctx, provider, queuedMessage.Provider, message)
+	if err != nil {
 		m.logger.Error("Failed to send queued message: ", err)
 		return false
 	}
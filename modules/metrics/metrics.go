@@ -0,0 +1,202 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// QueueStats is the number of messages of a queue in each state, read on every scrape.
+type QueueStats struct {
+	Name       string
+	Pending    int64
+	Ready      int64
+	Processing int64
+}
+
+// Metrics holds the prometheus registry of an app and the collectors fed by the built-in modules.
+// The Observe methods are safe to call on a nil Metrics, they do nothing then.
+type Metrics struct {
+	registry  *prometheus.Registry
+	namespace string
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	queueEvents   *prometheus.CounterVec
+	cacheRequests *prometheus.CounterVec
+	mongoDuration *prometheus.HistogramVec
+	mailSends     *prometheus.CounterVec
+	cronRuns      *prometheus.CounterVec
+	cronDuration  *prometheus.HistogramVec
+}
+
+// New creates a registry with the go runtime and process collectors, and the collectors of the built-in modules.
+// The metric names are prefixed with the namespace, e.g. goe_http_requests_total, an empty namespace means no prefix.
+func New(namespace string) *Metrics {
+	m := &Metrics{
+		registry:  prometheus.NewRegistry(),
+		namespace: namespace,
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of http requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of http requests by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		queueEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "queue",
+			Name:      "events_total",
+			Help:      "Number of messages by queue and event, such as new_message, ack, nack, retry and final_failed.",
+		}, []string{"queue", "event"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "requests_total",
+			Help:      "Number of cache lookups by result, hit or miss.",
+		}, []string{"result"}),
+		mongoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "mongodb",
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of mongodb operations by operation, collection and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "collection", "status"}),
+		mailSends: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "mail",
+			Name:      "sends_total",
+			Help:      "Number of emails sent by provider and status.",
+		}, []string{"provider", "status"}),
+		cronRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cron",
+			Name:      "job_runs_total",
+			Help:      "Number of cron job runs by job and status.",
+		}, []string{"job", "status"}),
+		cronDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "cron",
+			Name:      "job_duration_seconds",
+			Help:      "Duration of cron job runs by job.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"job"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.queueEvents,
+		m.cacheRequests,
+		m.mongoDuration,
+		m.mailSends,
+		m.cronRuns,
+		m.cronDuration,
+	)
+	return m
+}
+
+// Registry returns the prometheus registry, it can be used to gather the metrics or to register collectors directly.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Register registers custom collectors of the application, they are exposed together with the built-in ones.
+func (m *Metrics) Register(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := m.registry.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MustRegister registers custom collectors of the application, it panics if a collector cannot be registered.
+func (m *Metrics) MustRegister(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+// Handler returns an http handler serving the metrics in the prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	// a failed collector, such as the queue gauges when redis is down, does not fail the other metrics
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// WatchQueues exposes the pending, ready and processing messages of the queues as gauges, stats is called on every scrape.
+func (m *Metrics) WatchQueues(stats func() ([]QueueStats, error)) error {
+	if m == nil || stats == nil {
+		return nil
+	}
+	return m.registry.Register(newQueueCollector(m.namespace, stats))
+}
+
+// ObserveHTTPRequest records a request handled by the route, route is the route pattern such as /users/:id, not the request path.
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveQueueEvent records count messages of the queue reaching the event, such as ack or retry.
+func (m *Metrics) ObserveQueueEvent(queue, event string, count int) {
+	if m == nil || count <= 0 {
+		return
+	}
+	m.queueEvents.WithLabelValues(queue, event).Add(float64(count))
+}
+
+// ObserveCacheGet records a cache lookup.
+func (m *Metrics) ObserveCacheGet(hit bool) {
+	if m == nil {
+		return
+	}
+	if hit {
+		m.cacheRequests.WithLabelValues("hit").Inc()
+	} else {
+		m.cacheRequests.WithLabelValues("miss").Inc()
+	}
+}
+
+// ObserveMongoOperation records the latency of a mongodb operation on the collection.
+func (m *Metrics) ObserveMongoOperation(operation, collection string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.mongoDuration.WithLabelValues(operation, collection, status(err)).Observe(duration.Seconds())
+}
+
+// ObserveMailSend records an email sent by the provider.
+func (m *Metrics) ObserveMailSend(provider string, err error) {
+	if m == nil {
+		return
+	}
+	m.mailSends.WithLabelValues(provider, status(err)).Inc()
+}
+
+// ObserveCronRun records a run of the cron job.
+func (m *Metrics) ObserveCronRun(job string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.cronRuns.WithLabelValues(job, status(err)).Inc()
+	m.cronDuration.WithLabelValues(job).Observe(duration.Seconds())
+}
+
+func status(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, rec.Code)
+	return string(body)
+}
+
+func TestObserveBuiltinMetrics(t *testing.T) {
+	m := New("goe")
+	m.ObserveHTTPRequest("GET", "/users/:id", 200, 20*time.Millisecond)
+	m.ObserveHTTPRequest("GET", "/users/:id", 200, 30*time.Millisecond)
+	m.ObserveQueueEvent("emails", "ack", 2)
+	m.ObserveQueueEvent("emails", "retry", 0)
+	m.ObserveCacheGet(true)
+	m.ObserveCacheGet(false)
+	m.ObserveMongoOperation("find_one", "users", time.Millisecond, nil)
+	m.ObserveMailSend("smtp", errors.New("connection refused"))
+	m.ObserveCronRun("main.cleanup", time.Second, nil)
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `goe_http_requests_total{method="GET",route="/users/:id",status="200"} 2`)
+	assert.Contains(t, body, `goe_http_request_duration_seconds_count{method="GET",route="/users/:id"} 2`)
+	assert.Contains(t, body, `goe_queue_events_total{event="ack",queue="emails"} 2`)
+	assert.NotContains(t, body, `event="retry"`)
+	assert.Contains(t, body, `goe_cache_requests_total{result="hit"} 1`)
+	assert.Contains(t, body, `goe_cache_requests_total{result="miss"} 1`)
+	assert.Contains(t, body, `goe_mongodb_operation_duration_seconds_count{collection="users",operation="find_one",status="success"} 1`)
+	assert.Contains(t, body, `goe_mail_sends_total{provider="smtp",status="failure"} 1`)
+	assert.Contains(t, body, `goe_cron_job_runs_total{job="main.cleanup",status="success"} 1`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestRegisterCustomCollector(t *testing.T) {
+	m := New("")
+	orders := prometheus.NewCounter(prometheus.CounterOpts{Name: "orders_total", Help: "Number of orders."})
+	assert.NoError(t, m.Register(orders))
+	orders.Add(3)
+
+	assert.Contains(t, scrape(t, m), "orders_total 3")
+	assert.Error(t, m.Register(orders), "a collector cannot be registered twice")
+}
+
+func TestWatchQueues(t *testing.T) {
+	m := New("goe")
+	failing := false
+	assert.NoError(t, m.WatchQueues(func() ([]QueueStats, error) {
+		if failing {
+			return nil, errors.New("redis is down")
+		}
+		return []QueueStats{{Name: "emails", Pending: 1, Ready: 2, Processing: 3}}, nil
+	}))
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `goe_queue_pending_messages{queue="emails"} 1`)
+	assert.Contains(t, body, `goe_queue_ready_messages{queue="emails"} 2`)
+	assert.Contains(t, body, `goe_queue_processing_messages{queue="emails"} 3`)
+
+	// a failed count does not fail the other metrics
+	failing = true
+	m.ObserveCacheGet(true)
+	body = scrape(t, m)
+	assert.NotContains(t, body, "goe_queue_pending_messages")
+	assert.Contains(t, body, `goe_cache_requests_total{result="hit"} 1`)
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	assert.NotPanics(t, func() {
+		m.ObserveHTTPRequest("GET", "/", 200, time.Millisecond)
+		m.ObserveCacheGet(true)
+		m.ObserveCronRun("job", time.Millisecond, nil)
+		assert.NoError(t, m.WatchQueues(func() ([]QueueStats, error) { return nil, nil }))
+	})
+}
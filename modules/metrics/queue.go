@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// queueCollector reads the message counts of the queues on every scrape, so the gauges are never stale.
+type queueCollector struct {
+	stats      func() ([]QueueStats, error)
+	pending    *prometheus.Desc
+	ready      *prometheus.Desc
+	processing *prometheus.Desc
+}
+
+func newQueueCollector(namespace string, stats func() ([]QueueStats, error)) *queueCollector {
+	return &queueCollector{
+		stats: stats,
+		pending: prometheus.NewDesc(prometheus.BuildFQName(namespace, "queue", "pending_messages"),
+			"Number of messages whose delivery time has not arrived.", []string{"queue"}, nil),
+		ready: prometheus.NewDesc(prometheus.BuildFQName(namespace, "queue", "ready_messages"),
+			"Number of messages whose delivery time has arrived but which are not delivered yet.", []string{"queue"}, nil),
+		processing: prometheus.NewDesc(prometheus.BuildFQName(namespace, "queue", "processing_messages"),
+			"Number of messages being processed by the consumers.", []string{"queue"}, nil),
+	}
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.pending
+	ch <- c.ready
+	ch <- c.processing
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.stats()
+	if err != nil {
+		// a failed count is reported as an invalid metric, the scrape fails instead of exposing wrong values
+		ch <- prometheus.NewInvalidMetric(c.pending, err)
+		return
+	}
+	for _, s := range stats {
+		ch <- prometheus.MustNewConstMetric(c.pending, prometheus.GaugeValue, float64(s.Pending), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.ready, prometheus.GaugeValue, float64(s.Ready), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.processing, prometheus.GaugeValue, float64(s.Processing), s.Name)
+	}
+}
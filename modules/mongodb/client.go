@@ -3,6 +3,7 @@ package mongodb
 import (
 	"context"
 	"go.oease.dev/omgo"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type MongoDB struct {
@@ -10,6 +11,10 @@ type MongoDB struct {
 	client      *omgo.Client
 	dbName      string
 	logger      Logger
+	observer    Observer
+	tracer      trace.Tracer
+	// boundCtx is the context set by WithContext, the operations run with context.Background() if it is nil
+	boundCtx context.Context
 }
 
 // NewMongoDB returns a new instance of MongoDB connected to the specified database.
@@ -44,10 +49,12 @@ func NewMongoDB(connectionUri string, databaseName string, logger ...Logger) (*M
 	return m, nil
 }
 
-// newCtx returns a new context.
-// It doesn't take any parameters.
-// It returns the created context.
+// newCtx returns the context of the operations.
+// It is the context set by WithContext, or context.Background().
 func (m *MongoDB) newCtx() context.Context {
+	if m.boundCtx != nil {
+		return m.boundCtx
+	}
 	return context.Background()
 }
 
@@ -0,0 +1,68 @@
+package mongodb
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"time"
+)
+
+// Observer is notified of every mongodb operation, it is used to collect latency metrics.
+// Find and FindWithCursor return lazy queries which are executed by the caller, they are neither observed nor traced.
+type Observer interface {
+	ObserveMongoOperation(operation, collection string, duration time.Duration, err error)
+}
+
+// WithObserver sets the observer notified of the mongodb operations.
+func (m *MongoDB) WithObserver(observer Observer) *MongoDB {
+	m.observer = observer
+	return m
+}
+
+// WithTracer sets the tracer starting a span for each mongodb operation, as a child of the span in the context given by WithContext.
+func (m *MongoDB) WithTracer(tracer trace.Tracer) *MongoDB {
+	m.tracer = tracer
+	return m
+}
+
+// WithContext returns a copy of the client running its operations with ctx, the copy shares the connection.
+// The operations are cancelled with ctx, and their spans are children of the span in ctx.
+func (m *MongoDB) WithContext(ctx context.Context) *MongoDB {
+	c := *m
+	c.boundCtx = ctx
+	return &c
+}
+
+// track starts the span of an operation, the returned function ends it and notifies the observer.
+// A query without result is not a failure.
+func (m *MongoDB) track(operation string, model IDefaultModel) (context.Context, func(err error)) {
+	start := time.Now()
+	tracer := m.tracer
+	if tracer == nil {
+		tracer = noop.NewTracerProvider().Tracer("")
+	}
+	ctx, span := tracer.Start(m.ctx(), "mongodb."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "mongodb"),
+			attribute.String("db.name", m.dbName),
+			attribute.String("db.operation", operation),
+			attribute.String("db.mongodb.collection", model.ColName()),
+		),
+	)
+	return ctx, func(err error) {
+		if IsNoResult(err) {
+			err = nil
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		if m.observer != nil {
+			m.observer.ObserveMongoOperation(operation, model.ColName(), time.Since(start), err)
+		}
+	}
+}
@@ -82,12 +82,15 @@ func (m *MongoDB) FindPage(model IDefaultModel, filter any, res any, pageSize in
 		filter = bson.D{}
 	}
 
-	countDoc, err := m.col(model).Find(m.ctx(), filter).Count()
+	ctx, done := m.track("find_page", model)
+	countDoc, err := m.col(model).Find(ctx, filter).Count()
 	if IsNoResult(err) {
+		done(nil)
 		res = nil
 		return 0, 0
 	}
 	if err != nil {
+		done(err)
 		res = nil
 		m.logger.Error(err)
 		return 0, 0
@@ -111,12 +114,13 @@ func (m *MongoDB) FindPage(model IDefaultModel, filter any, res any, pageSize in
 	}
 
 	//find the documents
-	query := m.col(model).Find(m.ctx(), filter)
+	query := m.col(model).Find(ctx, filter)
 	if opt != nil {
 		query.Select(opt.selector)
 		query.Sort(opt.fields...)
 	}
 	err = query.Limit(limit).Skip(offset).All(res)
+	done(err)
 	if IsNoResult(err) {
 		res = nil
 		return 0, 0
@@ -139,7 +143,9 @@ func (m *MongoDB) FindOne(model IDefaultModel, filter any, res any) (bool, error
 	if filter == nil {
 		filter = bson.D{}
 	}
-	err := m.col(model).Find(m.ctx(), filter).One(res)
+	ctx, done := m.track("find_one", model)
+	err := m.col(model).Find(ctx, filter).One(res)
+	done(err)
 	if IsNoResult(err) {
 		res = nil
 		return false, nil
@@ -157,7 +163,9 @@ func (m *MongoDB) FindById(model IDefaultModel, id string, res any) (bool, error
 		return false, errors.New("must initialize MongoDB first, by calling NewMongodb() method")
 	}
 
-	err := m.col(model).Find(m.ctx(), bson.M{"_id": MustHexToObjectId(id)}).One(res)
+	ctx, done := m.track("find_by_id", model)
+	err := m.col(model).Find(ctx, bson.M{"_id": MustHexToObjectId(id)}).One(res)
+	done(err)
 	if IsNoResult(err) {
 		res = nil
 		return false, nil
@@ -193,7 +201,10 @@ func (m *MongoDB) Insert(model IDefaultModel) (*omgo.InsertOneResult, error) {
 		return nil, errors.New("must initialize MongoDB first, by calling NewMongodb() method")
 	}
 
-	return m.col(model).InsertOne(m.ctx(), model, options.InsertOneOptions{InsertHook: model})
+	ctx, done := m.track("insert", model)
+	res, err := m.col(model).InsertOne(ctx, model, options.InsertOneOptions{InsertHook: model})
+	done(err)
+	return res, err
 }
 
 // InsertMany inserts multiple documents into a MongoDB collection based on the provided model and slice of documents.
@@ -221,7 +232,10 @@ func (m *MongoDB) InsertMany(model IDefaultModel, docs []any) (*omgo.InsertManyR
 		return nil, errors.New("must initialize MongoDB first, by calling NewMongodb() method")
 	}
 
-	return m.col(model).InsertMany(m.ctx(), docs, options.InsertManyOptions{InsertHook: model})
+	ctx, done := m.track("insert_many", model)
+	res, err := m.col(model).InsertMany(ctx, docs, options.InsertManyOptions{InsertHook: model})
+	done(err)
+	return res, err
 }
 
 // Update is a method that updates a single document in a MongoDB collection.
@@ -240,7 +254,10 @@ func (m *MongoDB) Update(model IDefaultModel) error {
 	// add the ID to the filter
 	f["_id"] = model.GetObjectID()
 
-	return m.col(model).UpdateOne(m.ctx(), f, bson.M{"$set": model}, options.UpdateOptions{UpdateHook: model})
+	ctx, done := m.track("update", model)
+	err := m.col(model).UpdateOne(ctx, f, bson.M{"$set": model}, options.UpdateOptions{UpdateHook: model})
+	done(err)
+	return err
 }
 
 // Delete is a method that deletes a single document from a MongoDB collection.
@@ -254,7 +271,10 @@ func (m *MongoDB) Delete(model IDefaultModel) error {
 		return errors.New("model does not have an ID, please provide an ID or find the document first")
 	}
 
-	return m.col(model).RemoveId(m.ctx(), model.GetObjectID())
+	ctx, done := m.track("delete", model)
+	err := m.col(model).RemoveId(ctx, model.GetObjectID())
+	done(err)
+	return err
 }
 
 // DeleteMany is a method that deletes multiple documents from a MongoDB collection based on the provided filter.
@@ -267,7 +287,10 @@ func (m *MongoDB) DeleteMany(model IDefaultModel, filter any) (*omgo.DeleteResul
 		return nil, errors.New("filter cannot be nil, please provide a filter")
 	}
 
-	return m.col(model).RemoveAll(m.ctx(), filter)
+	ctx, done := m.track("delete_many", model)
+	res, err := m.col(model).RemoveAll(ctx, filter)
+	done(err)
+	return res, err
 }
 
 // Aggregate is a method that performs an aggregation pipeline operation on a MongoDB collection.
@@ -276,7 +299,10 @@ func (m *MongoDB) Aggregate(model IDefaultModel, pipeline any, res any) error {
 		return errors.New("must initialize MongoDB first, by calling NewMongodb() method")
 	}
 
-	return m.col(model).Aggregate(m.ctx(), pipeline).All(res)
+	ctx, done := m.track("aggregate", model)
+	err := m.col(model).Aggregate(ctx, pipeline).All(res)
+	done(err)
+	return err
 }
 
 // IsExist is a method that checks if a document exists in a MongoDB collection based on the provided filter.
@@ -290,7 +316,9 @@ func (m *MongoDB) IsExist(model IDefaultModel, filter any) (bool, error) {
 		filter = bson.D{}
 	}
 
-	err := m.col(model).Find(m.ctx(), filter).One(nil)
+	ctx, done := m.track("is_exist", model)
+	err := m.col(model).Find(ctx, filter).One(nil)
+	done(err)
 	if IsNoResult(err) {
 		return false, nil
 	}
@@ -309,5 +337,8 @@ func (m *MongoDB) Count(model IDefaultModel, filter any) (int64, error) {
 		filter = bson.D{}
 	}
 
-	return m.col(model).Find(m.ctx(), filter).Count()
+	ctx, done := m.track("count", model)
+	count, err := m.col(model).Find(ctx, filter).Count()
+	done(err)
+	return count, err
 }
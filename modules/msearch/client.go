@@ -2,23 +2,29 @@ package msearch
 
 import (
 	"context"
+	"errors"
 	"github.com/goccy/go-json"
 	"github.com/meilisearch/meilisearch-go"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.oease.dev/omgo"
+	"go.opentelemetry.io/otel/trace"
 	"sync"
 )
 
 type MSearch struct {
 	initialized bool
 	client      meilisearch.ServiceManager
-	once        sync.Once
+	// once is shared by the copies created by WithContext, the index configs are applied once
+	once        *sync.Once
 	indexConfig *IndexConfigs
 	logger      Logger
+	tracer      trace.Tracer
+	// boundCtx is the context set by WithContext, the spans are children of its span
+	boundCtx context.Context
 }
 
 func NewMSearch(hostUrl string, key string, logger ...Logger) *MSearch {
-	ms := &MSearch{}
+	ms := &MSearch{once: &sync.Once{}}
 	ms.client = meilisearch.New(hostUrl, meilisearch.WithAPIKey(key))
 	if len(logger) > 0 && logger[0] != nil {
 		ms.logger = logger[0]
@@ -28,6 +34,12 @@ func NewMSearch(hostUrl string, key string, logger ...Logger) *MSearch {
 	return ms
 }
 
+// Ping checks the health of the meilisearch server.
+func (ms *MSearch) Ping(ctx context.Context) error {
+	_, err := ms.client.HealthWithContext(ctx)
+	return err
+}
+
 func (ms *MSearch) ApplyIndexConfigs(configData []byte) error {
 	cfg := &IndexConfigs{}
 	cfg.ConfigData = &configDataMap{}
@@ -65,6 +77,9 @@ func (ms *MSearch) ApplyIndexConfigs(configData []byte) error {
 }
 
 func (ms *MSearch) RebuildAllIndexes(dbConnUri string, dbName string) error {
+	if !ms.initialized || ms.indexConfig == nil {
+		return errors.New("index configs are not applied, call ApplyIndexConfigs first")
+	}
 	//rebuild all indexes
 	ms.logger.Debug("Rebuilding all indexes...")
 	dbClient, err := omgo.NewClient(context.Background(), &omgo.Config{
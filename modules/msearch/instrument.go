@@ -0,0 +1,49 @@
+package msearch
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// WithTracer sets the tracer starting a span for each document and search operation, as a child of the span in the context given by WithContext.
+func (ms *MSearch) WithTracer(tracer trace.Tracer) *MSearch {
+	ms.tracer = tracer
+	return ms
+}
+
+// WithContext returns a copy of the client whose spans are children of the span in ctx, the copy shares the connection.
+func (ms *MSearch) WithContext(ctx context.Context) *MSearch {
+	c := *ms
+	c.boundCtx = ctx
+	return &c
+}
+
+// track starts the span of an operation on the index, the returned function ends it.
+func (ms *MSearch) track(operation string, indexName string) func(err error) {
+	tracer := ms.tracer
+	if tracer == nil {
+		tracer = noop.NewTracerProvider().Tracer("")
+	}
+	ctx := ms.boundCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, span := tracer.Start(ctx, "meilisearch."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "meilisearch"),
+			attribute.String("db.operation", operation),
+			attribute.String("meilisearch.index", indexName),
+		),
+	)
+	return func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
@@ -73,7 +73,9 @@ func (ms *MSearch) WaitForTaskSuccess(taskUID int64) error {
 //
 // Note: This method depends on the MSearch.WaitForTaskSuccess method for waiting
 // for the task to complete. Please refer to the documentation of that method for more details.
-func (ms *MSearch) AddDoc(indexName string, docPtr any) error {
+func (ms *MSearch) AddDoc(indexName string, docPtr any) (err error) {
+	done := ms.track("add_doc", indexName)
+	defer func() { done(err) }()
 	resp, err := ms.client.Index(indexName).AddDocuments(docPtr)
 	if err != nil {
 		return err
@@ -88,7 +90,9 @@ func (ms *MSearch) AddDoc(indexName string, docPtr any) error {
 // The WaitForTaskSuccess() method waits for a task with a specified taskUID to complete.
 // It polls for the status of the task every 50 milliseconds, increasing the wait time for the next check by doubling it,
 // up to a maximum delay of 1 second. This is done to limit the number of calls to the GetTask function when the task takes a significant amount of time to complete.
-func (ms *MSearch) DelDoc(indexName string, docId string) error {
+func (ms *MSearch) DelDoc(indexName string, docId string) (err error) {
+	done := ms.track("delete_doc", indexName)
+	defer func() { done(err) }()
 	resp, err := ms.client.Index(indexName).DeleteDocument(docId)
 	if err != nil {
 		return err
@@ -102,7 +106,9 @@ func (ms *MSearch) DelDoc(indexName string, docId string) error {
 // If there's an error during the update process, it returns the error.
 // Finally, it calls WaitForTaskSuccess with the TaskUID from the response,
 // to wait for the update task to complete.underlying concrete type
-func (ms *MSearch) UpdateDoc(indexName string, docPtr any) error {
+func (ms *MSearch) UpdateDoc(indexName string, docPtr any) (err error) {
+	done := ms.track("update_doc", indexName)
+	defer func() { done(err) }()
 	resp, err := ms.client.Index(indexName).UpdateDocuments(docPtr)
 	if err != nil {
 		return err
@@ -110,8 +116,10 @@ func (ms *MSearch) UpdateDoc(indexName string, docPtr any) error {
 	return ms.WaitForTaskSuccess(resp.TaskUID)
 }
 
-func (ms *MSearch) GetDoc(indexName string, docId string, bindResult any) (bool, error) {
-	err := ms.client.Index(indexName).GetDocument(docId, nil, bindResult)
+func (ms *MSearch) GetDoc(indexName string, docId string, bindResult any) (found bool, err error) {
+	done := ms.track("get_doc", indexName)
+	defer func() { done(err) }()
+	err = ms.client.Index(indexName).GetDocument(docId, nil, bindResult)
 	if err != nil {
 		if err.(*meilisearch.Error).StatusCode == 404 {
 			return false, nil
@@ -125,7 +133,9 @@ func (ms *MSearch) GetDoc(indexName string, docId string, bindResult any) (bool,
 // It returns a *meilisearch.SearchResponse containing the search results.
 // If there's an error occurring while performing the search query, it will log the error and return nil.
 func (ms *MSearch) Search(indexName string, query string, options *meilisearch.SearchRequest) *meilisearch.SearchResponse {
+	done := ms.track("search", indexName)
 	resp, err := ms.client.Index(indexName).Search(query, options)
+	done(err)
 	if err != nil {
 		ms.logger.Error(err)
 		return nil
@@ -134,7 +144,9 @@ func (ms *MSearch) Search(indexName string, query string, options *meilisearch.S
 }
 
 // DeleteAllDocuments is a method that deletes all documents in the specified index.
-func (ms *MSearch) DeleteAllDocuments(indexName string) error {
+func (ms *MSearch) DeleteAllDocuments(indexName string) (err error) {
+	done := ms.track("delete_all_documents", indexName)
+	defer func() { done(err) }()
 	resp, err := ms.client.Index(indexName).DeleteAllDocuments()
 	if err != nil {
 		return err
@@ -0,0 +1,210 @@
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// MIMEApplicationProblemJSON is the content type of the RFC 7807 problem details.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Type is a registered application error, clients branch on its code instead of parsing the messages.
+// It is an error itself, so that it can be returned as is, and matched by errors.Is with the errors created from it.
+type Type struct {
+	// Code is the stable code of the error, such as item_not_found
+	Code string
+	// Status is the http status of the responses
+	Status int
+	// Message is the message of the responses, it is the key of the i18n catalogs, and its {name} placeholders are replaced by the details
+	Message string
+}
+
+var (
+	mu    sync.RWMutex
+	types = make(map[string]*Type)
+)
+
+// The types of the errors of the framework, the errors of webresult and the validation errors have their codes.
+var (
+	BadRequest       = Register("bad_request", http.StatusBadRequest, "invalid request data")
+	Unauthorized     = Register("unauthorized", http.StatusUnauthorized, "unauthorized")
+	Forbidden        = Register("forbidden", http.StatusForbidden, "forbidden")
+	NotFound         = Register("not_found", http.StatusNotFound, "resource not found")
+	TooManyRequests  = Register("too_many_requests", http.StatusTooManyRequests, "too many requests")
+	ValidationFailed = Register("validation_failed", http.StatusBadRequest, "validation failed")
+	Internal         = Register("internal_error", http.StatusInternalServerError, "system busy")
+)
+
+// Register registers the type of an application error, usually in a package variable.
+// It panics if the code is empty or already registered, or if the status is not an error status.
+func Register(code string, status int, message string) *Type {
+	if code == "" {
+		panic("problem: the code is required")
+	}
+	if status < 400 || status > 599 {
+		panic(fmt.Sprintf("problem: invalid status %d of %s", status, code))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := types[code]; ok {
+		panic(fmt.Sprintf("problem: %s is already registered", code))
+	}
+	t := &Type{Code: code, Status: status, Message: message}
+	types[code] = t
+	return t
+}
+
+// Lookup returns the registered type of the code.
+func Lookup(code string) (*Type, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	t, ok := types[code]
+	return t, ok
+}
+
+// Types returns the registered types sorted by code, such as for the documentation of an api.
+func Types() []*Type {
+	mu.RLock()
+	defer mu.RUnlock()
+	list := make([]*Type, 0, len(types))
+	for _, t := range types {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Code < list[j].Code
+	})
+	return list
+}
+
+// ForStatus returns the type of the framework for the http status, such as the one of the *fiber.Error errors, or nil.
+func ForStatus(status int) *Type {
+	switch status {
+	case http.StatusBadRequest:
+		return BadRequest
+	case http.StatusUnauthorized:
+		return Unauthorized
+	case http.StatusForbidden:
+		return Forbidden
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusTooManyRequests:
+		return TooManyRequests
+	case http.StatusInternalServerError:
+		return Internal
+	}
+	return nil
+}
+
+// Error returns the message of the type.
+func (t *Type) Error() string {
+	return t.Message
+}
+
+// New creates an error of the type.
+func (t *Type) New() *Error {
+	return &Error{Type: t}
+}
+
+// With creates an error of the type with a detail, see Error.With.
+func (t *Type) With(key string, value any) *Error {
+	return t.New().With(key, value)
+}
+
+// Wrap creates an error of the type caused by err, the cause is logged and matched by errors.Is but not sent to the clients.
+func (t *Type) Wrap(err error) *Error {
+	return &Error{Type: t, cause: err}
+}
+
+// Error is an occurrence of an application error, it is rendered by the error handler of goe.
+type Error struct {
+	Type *Type
+	// Details are sent to the clients with the code, they replace the placeholders of the message
+	Details map[string]any
+	cause   error
+}
+
+// With adds a detail, such as the id of the missing item, it returns the error itself.
+func (e *Error) With(key string, value any) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details[key] = value
+	return e
+}
+
+// Args returns the details as the key-value pairs of the placeholders of the message, sorted by key.
+func (e *Error) Args() []any {
+	keys := make([]string, 0, len(e.Details))
+	for key := range e.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	args := make([]any, 0, 2*len(keys))
+	for _, key := range keys {
+		args = append(args, key, e.Details[key])
+	}
+	return args
+}
+
+// Error returns the code and the message of the type, and the cause if any.
+func (e *Error) Error() string {
+	msg := e.Type.Code + ": " + e.Type.Message
+	if e.cause != nil {
+		msg += ": " + e.cause.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the cause of the error.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether the error is of the type target, so that errors.Is(err, ErrItemNotFound) matches the errors created by ErrItemNotFound.With.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Type)
+	return ok && e.Type == t
+}
+
+// As returns the application error of err, a type returned as is is an error without details.
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	var t *Type
+	if errors.As(err, &t) {
+		return t.New(), true
+	}
+	return nil, false
+}
+
+// Problem is the RFC 7807 problem details of a response, the code and the details are extension members.
+type Problem struct {
+	// Type is the URI of the type, about:blank without a base URI
+	Type string `json:"type"`
+	// Title is the http status text
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code,omitempty"`
+	// Details are the details of the error, see Error.With
+	Details map[string]any `json:"details,omitempty"`
+	// Errors are the failed rules by field of a validation error
+	Errors map[string][]string `json:"errors,omitempty"`
+}
+
+// TypeURI returns the URI of the code under the base URI, such as https://example.com/problems/item_not_found, or about:blank without a base URI.
+func TypeURI(base, code string) string {
+	if base == "" || code == "" {
+		return "about:blank"
+	}
+	if base[len(base)-1] != '/' {
+		base += "/"
+	}
+	return base + code
+}
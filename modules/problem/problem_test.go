@@ -0,0 +1,65 @@
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+var errItemNotFound = Register("item_not_found", http.StatusNotFound, "item {id} not found")
+
+func TestRegister(t *testing.T) {
+	assert.PanicsWithValue(t, "problem: item_not_found is already registered", func() {
+		Register("item_not_found", http.StatusNotFound, "item not found")
+	})
+	assert.Panics(t, func() { Register("", http.StatusBadRequest, "bad") })
+	assert.Panics(t, func() { Register("created", http.StatusCreated, "created") })
+
+	found, ok := Lookup("item_not_found")
+	require.True(t, ok)
+	assert.Same(t, errItemNotFound, found)
+	codes := make([]string, 0)
+	for _, typ := range Types() {
+		codes = append(codes, typ.Code)
+	}
+	assert.IsIncreasing(t, codes)
+	assert.Contains(t, codes, "validation_failed")
+}
+
+func TestError(t *testing.T) {
+	cause := errors.New("no documents in result")
+	err := fmt.Errorf("find item: %w", errItemNotFound.Wrap(cause).With("id", "42").With("collection", "items"))
+
+	assert.ErrorIs(t, err, errItemNotFound)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, NotFound)
+	assert.Equal(t, "find item: item_not_found: item {id} not found: no documents in result", err.Error())
+
+	e, ok := As(err)
+	require.True(t, ok)
+	assert.Equal(t, []any{"collection", "items", "id", "42"}, e.Args())
+
+	// a type returned as is
+	e, ok = As(fmt.Errorf("wrapped: %w", Forbidden))
+	require.True(t, ok)
+	assert.Same(t, Forbidden, e.Type)
+	assert.Empty(t, e.Details)
+
+	_, ok = As(errors.New("plain"))
+	assert.False(t, ok)
+}
+
+func TestForStatus(t *testing.T) {
+	assert.Same(t, NotFound, ForStatus(http.StatusNotFound))
+	assert.Same(t, Internal, ForStatus(http.StatusInternalServerError))
+	assert.Nil(t, ForStatus(http.StatusTeapot))
+}
+
+func TestTypeURI(t *testing.T) {
+	assert.Equal(t, "about:blank", TypeURI("", "not_found"))
+	assert.Equal(t, "https://example.com/problems/not_found", TypeURI("https://example.com/problems", "not_found"))
+	assert.Equal(t, "https://example.com/problems/not_found", TypeURI("https://example.com/problems/", "not_found"))
+}
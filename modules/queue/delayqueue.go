@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"go.oease.dev/goe/utils"
@@ -41,6 +42,13 @@ type DelayQueue struct {
 	scriptPreload      bool
 	// for batch consume
 	consumeBuffer chan string
+	// done is closed when the main loop exits
+	done <-chan struct{}
+	// feedWg tracks goroutines sending fetched messages to consumeBuffer
+	feedWg sync.WaitGroup
+	// workerWg tracks worker goroutines consuming messages from consumeBuffer
+	workerWg sync.WaitGroup
+	workers  workerPool
 
 	eventListener EventListener
 }
@@ -192,6 +200,21 @@ func (q *DelayQueue) WithConcurrent(c uint) *DelayQueue {
 	return q
 }
 
+// SetConcurrent changes the number of concurrent consumers, unlike WithConcurrent it can be called while the queue is consuming.
+// Consumers are added immediately, and removed once they finish their current message.
+func (q *DelayQueue) SetConcurrent(c uint) {
+	if c == 0 {
+		panic("concurrent cannot be 0")
+	}
+	q.concurrent = c
+	if atomic.LoadInt32(&q.running) == 0 {
+		return
+	}
+	for n := q.workers.resize(int(c)); n > 0; n-- {
+		q.startWorker()
+	}
+}
+
 // WithDefaultRetryCount customizes the max number of retry, it effects of messages in this queue
 // use WithRetryCount during DelayQueue.SendScheduleMsg or DelayQueue.SendDelayMsg to specific retry count of particular message
 func (q *DelayQueue) WithDefaultRetryCount(count uint) *DelayQueue {
@@ -628,14 +651,11 @@ func (q *DelayQueue) StartConsume() (done <-chan struct{}) {
 	q.ticker = time.NewTicker(q.fetchInterval)
 	q.consumeBuffer = make(chan string, q.fetchLimit)
 	done0 := make(chan struct{})
+	q.done = done0
 	// start worker
+	q.workers.reset(int(q.concurrent))
 	for i := 0; i < int(q.concurrent); i++ {
-		q.goWithRecover(func() {
-			for id := range q.consumeBuffer {
-				q.callback(id)
-				q.afterConsume()
-			}
-		})
+		q.startWorker()
 	}
 	// start main loop
 	go func() {
@@ -647,7 +667,9 @@ func (q *DelayQueue) StartConsume() (done <-chan struct{}) {
 				if err != nil {
 					log.Printf("consume error: %v", err)
 				}
+				q.feedWg.Add(1)
 				q.goWithRecover(func() {
+					defer q.feedWg.Done()
 					for _, id := range ids {
 						q.consumeBuffer <- id
 					}
@@ -661,14 +683,55 @@ func (q *DelayQueue) StartConsume() (done <-chan struct{}) {
 	return done0
 }
 
-// StopConsume stops consumer goroutine
+// startWorker starts a consumer of the fetched messages.
+func (q *DelayQueue) startWorker() {
+	buffer := q.consumeBuffer
+	q.workerWg.Add(1)
+	q.goWithRecover(func() {
+		defer q.workerWg.Done()
+		for id := range buffer {
+			q.callback(id)
+			q.afterConsume()
+			if q.workers.stop() {
+				return
+			}
+		}
+	})
+}
+
+// StopConsume stops consumer goroutine, it does not wait for the in-flight messages, use Shutdown to wait for them
 func (q *DelayQueue) StopConsume() {
 	close(q.close)
 	q.setNotRunning()
 	if q.ticker != nil {
 		q.ticker.Stop()
 	}
-	close(q.consumeBuffer)
+	done, buffer := q.done, q.consumeBuffer
+	go func() {
+		// close the buffer after the main loop and all feeders exit, so that no message is sent to a closed channel
+		<-done
+		q.feedWg.Wait()
+		close(buffer)
+	}()
+}
+
+// Shutdown stops consuming and waits until the in-flight and fetched messages are consumed or ctx is done.
+// Messages not acknowledged before ctx is done are re-delivered after maxConsumeDuration.
+func (q *DelayQueue) Shutdown(ctx context.Context) error {
+	if atomic.LoadInt32(&q.running) > 0 {
+		q.StopConsume()
+	}
+	wait := make(chan struct{})
+	go func() {
+		q.workerWg.Wait()
+		close(wait)
+	}()
+	select {
+	case <-wait:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // GetPendingCount returns the number of pending messages
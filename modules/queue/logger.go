@@ -3,6 +3,7 @@ package queue
 import (
 	"fmt"
 	"log/slog"
+	"strings"
 )
 
 type Logger interface {
@@ -31,7 +32,7 @@ func newDefaultLogger() Logger {
 }
 
 func (d *defaultLogger) Printf(format string, v ...interface{}) {
-	d.defaultSloger.Info(format, v...)
+	d.defaultSloger.Info(strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
 }
 
 func (d *defaultLogger) Debug(args ...any) {
@@ -0,0 +1,386 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MemoryQueue is an in-process message queue supporting delayed/scheduled delivery, retries and concurrent consumers.
+// It has the same consuming semantics as DelayQueue, and can replace it for local development and unit tests.
+// Messages are not shared between processes and are lost when the process exits.
+type MemoryQueue struct {
+	name               string
+	cb                 CallbackFunc
+	logger             Logger
+	maxConsumeDuration time.Duration // default 5 seconds
+	defaultRetryCount  uint          // default 3
+	concurrent         uint          // default 1, executed serially
+
+	mu      sync.Mutex
+	pending memoryMsgHeap // messages waiting for their delivery time, ordered by delivery time
+	ready   int64         // messages dispatched to the workers but not received yet
+	seq     uint64
+
+	processing int64
+	running    int32
+	wake       chan struct{}
+	close      chan struct{}
+	done       chan struct{}
+	deliveries chan *memoryMsg
+	workerWg   sync.WaitGroup
+	workers    workerPool
+
+	eventListener EventListener
+}
+
+type memoryMsg struct {
+	seq       uint64
+	payload   string
+	deliverAt time.Time
+	retries   uint
+}
+
+// NewMemoryQueue creates a new in-process queue, use MemoryQueue.StartConsume to consume or MemoryQueue.SendScheduleMsg to publish message
+func NewMemoryQueue(name string, callback ...CallbackFunc) *MemoryQueue {
+	if name == "" {
+		panic("name is required")
+	}
+	q := &MemoryQueue{
+		name:               name,
+		logger:             newDefaultLogger(),
+		maxConsumeDuration: 5 * time.Second,
+		defaultRetryCount:  3,
+		concurrent:         1,
+		wake:               make(chan struct{}, 1),
+	}
+	if len(callback) > 0 {
+		q.cb = callback[0]
+	}
+	return q
+}
+
+// WithCallback set callback for queue to receives and consumes messages
+// callback returns true to confirm successfully consumed, false to re-deliver this message
+func (q *MemoryQueue) WithCallback(callback CallbackFunc) *MemoryQueue {
+	q.cb = callback
+	return q
+}
+
+// WithLogger customizes logger for queue
+func (q *MemoryQueue) WithLogger(logger Logger) *MemoryQueue {
+	q.logger = logger
+	return q
+}
+
+// WithMaxConsumeDuration customizes max consume duration
+// If the callback does not return within the duration, the message is re-delivered
+func (q *MemoryQueue) WithMaxConsumeDuration(d time.Duration) *MemoryQueue {
+	q.maxConsumeDuration = d
+	return q
+}
+
+// WithConcurrent sets the number of concurrent consumers
+func (q *MemoryQueue) WithConcurrent(c uint) *MemoryQueue {
+	if c == 0 {
+		panic("concurrent cannot be 0")
+	}
+	if atomic.LoadInt32(&q.running) > 0 {
+		panic("operation cannot be performed during running")
+	}
+	q.concurrent = c
+	return q
+}
+
+// SetConcurrent changes the number of concurrent consumers, unlike WithConcurrent it can be called while the queue is consuming.
+// Consumers are added immediately, and removed once they finish their current message.
+func (q *MemoryQueue) SetConcurrent(c uint) {
+	if c == 0 {
+		panic("concurrent cannot be 0")
+	}
+	q.concurrent = c
+	if atomic.LoadInt32(&q.running) == 0 {
+		return
+	}
+	for n := q.workers.resize(int(c)); n > 0; n-- {
+		q.startWorker()
+	}
+}
+
+// WithDefaultRetryCount customizes the max number of retry
+// use WithRetryCount during MemoryQueue.SendScheduleMsg or MemoryQueue.SendDelayMsg to specific retry count of particular message
+func (q *MemoryQueue) WithDefaultRetryCount(count uint) *MemoryQueue {
+	q.defaultRetryCount = count
+	return q
+}
+
+// SendScheduleMsg submits a message delivered at given time, WithRetryCount is supported, WithMsgTTL is ignored
+func (q *MemoryQueue) SendScheduleMsg(payload string, t time.Time, opts ...interface{}) error {
+	retryCount := q.defaultRetryCount
+	for _, opt := range opts {
+		if o, ok := opt.(retryCountOpt); ok {
+			retryCount = uint(o)
+		}
+	}
+	q.mu.Lock()
+	q.seq++
+	heap.Push(&q.pending, &memoryMsg{
+		seq:       q.seq,
+		payload:   payload,
+		deliverAt: t,
+		retries:   retryCount,
+	})
+	q.mu.Unlock()
+	q.reportEvent(NewMessageEvent, 1)
+	q.notify()
+	return nil
+}
+
+// SendDelayMsg submits a message delivered after given duration
+func (q *MemoryQueue) SendDelayMsg(payload string, duration time.Duration, opts ...interface{}) error {
+	return q.SendScheduleMsg(payload, time.Now().Add(duration), opts...)
+}
+
+// StartConsume creates goroutines to consume messages from the queue
+// use `<-done` to wait consumer stopping
+// If there is no callback set, StartConsume will panic
+func (q *MemoryQueue) StartConsume() (done <-chan struct{}) {
+	if q.cb == nil {
+		panic("this instance has no callback")
+	}
+	q.close = make(chan struct{})
+	q.done = make(chan struct{})
+	q.deliveries = make(chan *memoryMsg)
+	atomic.StoreInt32(&q.running, 1)
+	q.workers.reset(int(q.concurrent))
+	for i := 0; i < int(q.concurrent); i++ {
+		q.startWorker()
+	}
+	go q.dispatch()
+	return q.done
+}
+
+// startWorker starts a consumer of the delivered messages.
+func (q *MemoryQueue) startWorker() {
+	deliveries := q.deliveries
+	q.workerWg.Add(1)
+	go func() {
+		defer q.workerWg.Done()
+		for msg := range deliveries {
+			q.consume(msg)
+			if q.workers.stop() {
+				return
+			}
+		}
+	}()
+}
+
+// StopConsume stops consumer goroutines, it does not wait for the in-flight messages, use Shutdown to wait for them
+func (q *MemoryQueue) StopConsume() {
+	atomic.StoreInt32(&q.running, 0)
+	close(q.close)
+}
+
+// Shutdown stops consuming and waits until the in-flight messages are consumed or ctx is done.
+// Messages not delivered yet stay in the queue, and are consumed if the queue starts consuming again.
+func (q *MemoryQueue) Shutdown(ctx context.Context) error {
+	if atomic.LoadInt32(&q.running) > 0 {
+		q.StopConsume()
+	}
+	wait := make(chan struct{})
+	go func() {
+		q.workerWg.Wait()
+		close(wait)
+	}()
+	select {
+	case <-wait:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetPendingCount returns the number of messages waiting for their delivery time
+func (q *MemoryQueue) GetPendingCount() (int64, error) {
+	now := time.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var count int64
+	for _, msg := range q.pending {
+		if msg.deliverAt.After(now) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetReadyCount returns the number of messages which have arrived delivery time but have not been delivered
+func (q *MemoryQueue) GetReadyCount() (int64, error) {
+	now := time.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	count := q.ready
+	for _, msg := range q.pending {
+		if !msg.deliverAt.After(now) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetProcessingCount returns the number of messages which are being processed
+func (q *MemoryQueue) GetProcessingCount() (int64, error) {
+	return atomic.LoadInt64(&q.processing), nil
+}
+
+// ListenEvent register a listener which will be called when events occur, there can be AT MOST ONE EventListener
+func (q *MemoryQueue) ListenEvent(listener EventListener) {
+	q.eventListener = listener
+}
+
+// DisableListener stops reporting events to EventListener
+func (q *MemoryQueue) DisableListener() {
+	q.eventListener = nil
+}
+
+func (q *MemoryQueue) reportEvent(code int, count int) {
+	listener := q.eventListener // eventListener may be changed during running
+	if listener != nil && count > 0 {
+		listener.OnEvent(&Event{
+			Code:      code,
+			Timestamp: time.Now().Unix(),
+			MsgCount:  count,
+		})
+	}
+}
+
+// notify wakes up the dispatcher, so messages sent for an earlier delivery time than the next one are not delayed
+func (q *MemoryQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch sends the messages reaching their delivery time to the workers, until the queue stops consuming
+func (q *MemoryQueue) dispatch() {
+	defer func() {
+		close(q.deliveries)
+		close(q.done)
+	}()
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		q.mu.Lock()
+		var msg *memoryMsg
+		wait := time.Hour
+		if len(q.pending) > 0 {
+			if d := time.Until(q.pending[0].deliverAt); d > 0 {
+				wait = d
+			} else {
+				msg = heap.Pop(&q.pending).(*memoryMsg)
+				q.ready++
+			}
+		}
+		q.mu.Unlock()
+
+		if msg != nil {
+			select {
+			case q.deliveries <- msg:
+				q.mu.Lock()
+				q.ready--
+				q.mu.Unlock()
+				q.reportEvent(DeliveredEvent, 1)
+			case <-q.close:
+				// keep the message for the next consuming
+				q.mu.Lock()
+				q.ready--
+				heap.Push(&q.pending, msg)
+				q.mu.Unlock()
+				return
+			}
+			continue
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-q.wake:
+		case <-q.close:
+			return
+		}
+	}
+}
+
+// consume runs the callback, the message is re-delivered if the callback returns false, panics or exceeds maxConsumeDuration
+func (q *MemoryQueue) consume(msg *memoryMsg) {
+	atomic.AddInt64(&q.processing, 1)
+	defer atomic.AddInt64(&q.processing, -1)
+	result := make(chan bool, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				q.logger.Errorf("panic in consumer of queue %s: %v", q.name, err)
+				result <- false
+			}
+		}()
+		result <- q.cb(msg.payload)
+	}()
+	var ack bool
+	timeout := time.NewTimer(q.maxConsumeDuration)
+	defer timeout.Stop()
+	select {
+	case ack = <-result:
+	case <-timeout.C:
+		q.logger.Warnf("consume message of queue %s timeout after %s", q.name, q.maxConsumeDuration)
+	}
+	if ack {
+		q.reportEvent(AckEvent, 1)
+		return
+	}
+	q.reportEvent(NackEvent, 1)
+	if msg.retries == 0 {
+		q.reportEvent(FinalFailedEvent, 1)
+		return
+	}
+	msg.retries--
+	msg.deliverAt = time.Now()
+	q.mu.Lock()
+	heap.Push(&q.pending, msg)
+	q.mu.Unlock()
+	q.reportEvent(RetryEvent, 1)
+	q.notify()
+}
+
+// memoryMsgHeap orders messages by delivery time, then by send order
+type memoryMsgHeap []*memoryMsg
+
+func (h memoryMsgHeap) Len() int { return len(h) }
+
+func (h memoryMsgHeap) Less(i, j int) bool {
+	if h[i].deliverAt.Equal(h[j].deliverAt) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].deliverAt.Before(h[j].deliverAt)
+}
+
+func (h memoryMsgHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *memoryMsgHeap) Push(x any) { *h = append(*h, x.(*memoryMsg)) }
+
+func (h *memoryMsgHeap) Pop() any {
+	old := *h
+	n := len(old)
+	msg := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return msg
+}
@@ -0,0 +1,205 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryQueue_consume(t *testing.T) {
+	size := 100
+	retryCount := 3
+	deliveryCount := make(map[string]int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(size)
+	cb := func(s string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		deliveryCount[s]++
+		i, _ := strconv.ParseInt(s, 10, 64)
+		// nack the even messages once, they must be re-delivered
+		if i%2 == 0 && deliveryCount[s] == 1 {
+			return false
+		}
+		wg.Done()
+		return true
+	}
+	queue := NewMemoryQueue("test", cb).WithDefaultRetryCount(uint(retryCount)).WithConcurrent(4)
+	for i := 0; i < size; i++ {
+		err := queue.SendDelayMsg(strconv.Itoa(i), 0)
+		assert.NoError(t, err)
+	}
+	queue.StartConsume()
+	wg.Wait()
+	assert.NoError(t, queue.Shutdown(context.Background()))
+
+	for i := 0; i < size; i++ {
+		expected := 1
+		if i%2 == 0 {
+			expected = 2
+		}
+		assert.Equal(t, expected, deliveryCount[strconv.Itoa(i)])
+	}
+}
+
+func TestMemoryQueue_scheduleOrder(t *testing.T) {
+	received := make(chan string, 3)
+	queue := NewMemoryQueue("order", func(s string) bool {
+		received <- s
+		return true
+	})
+	now := time.Now()
+	assert.NoError(t, queue.SendScheduleMsg("third", now.Add(150*time.Millisecond)))
+	assert.NoError(t, queue.SendScheduleMsg("first", now.Add(50*time.Millisecond)))
+	queue.StartConsume()
+	defer queue.Shutdown(context.Background())
+	// a message sent after consuming starts must not wait for the later ones
+	assert.NoError(t, queue.SendScheduleMsg("second", now.Add(100*time.Millisecond)))
+
+	pending, _ := queue.GetPendingCount()
+	assert.Equal(t, int64(3), pending)
+	assert.Equal(t, "first", <-received)
+	assert.Equal(t, "second", <-received)
+	assert.Equal(t, "third", <-received)
+	assert.GreaterOrEqual(t, time.Since(now), 150*time.Millisecond)
+}
+
+func TestMemoryQueue_retryExhausted(t *testing.T) {
+	var deliveries int32
+	var failed int32
+	queue := NewMemoryQueue("retry", func(s string) bool {
+		atomic.AddInt32(&deliveries, 1)
+		return false
+	})
+	queue.ListenEvent(listenerFunc(func(e *Event) {
+		if e.Code == FinalFailedEvent {
+			atomic.AddInt32(&failed, int32(e.MsgCount))
+		}
+	}))
+	assert.NoError(t, queue.SendDelayMsg("msg", 0, WithRetryCount(2)))
+	queue.StartConsume()
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&failed) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.NoError(t, queue.Shutdown(context.Background()))
+	// first delivery and 2 retries
+	assert.Equal(t, int32(3), atomic.LoadInt32(&deliveries))
+}
+
+// warnLogger records the warnings, the other messages go to the default logger.
+type warnLogger struct {
+	Logger
+	mu       sync.Mutex
+	warnings []string
+}
+
+func (l *warnLogger) Warnf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
+func TestMemoryQueue_maxConsumeDuration(t *testing.T) {
+	var deliveries int32
+	logger := &warnLogger{Logger: newDefaultLogger()}
+	done := make(chan struct{})
+	queue := NewMemoryQueue("timeout", func(s string) bool {
+		if atomic.AddInt32(&deliveries, 1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+			return true
+		}
+		close(done)
+		return true
+	}).WithMaxConsumeDuration(50 * time.Millisecond).WithConcurrent(2).WithLogger(logger)
+	assert.NoError(t, queue.SendDelayMsg("slow", 0))
+	queue.StartConsume()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("message exceeding the max consume duration should be re-delivered")
+	}
+	assert.NoError(t, queue.Shutdown(context.Background()))
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	assert.Equal(t, []string{"consume message of queue timeout timeout after 50ms"}, logger.warnings)
+}
+
+func TestMemoryQueue_ShutdownWaitsInFlight(t *testing.T) {
+	started := make(chan struct{})
+	var finished int32
+	queue := NewMemoryQueue("shutdown", func(s string) bool {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+		return true
+	})
+	assert.NoError(t, queue.SendDelayMsg("msg", 0))
+	assert.NoError(t, queue.SendDelayMsg("later", time.Hour))
+	done := queue.StartConsume()
+	<-started
+	assert.NoError(t, queue.Shutdown(context.Background()))
+	<-done
+	assert.Equal(t, int32(1), atomic.LoadInt32(&finished))
+
+	// undelivered messages stay in the queue
+	pending, _ := queue.GetPendingCount()
+	assert.Equal(t, int64(1), pending)
+}
+
+type listenerFunc func(e *Event)
+
+func (f listenerFunc) OnEvent(e *Event) {
+	f(e)
+}
+
+func TestMemoryQueue_SetConcurrent(t *testing.T) {
+	var inFlight, maxInFlight int32
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	queue := NewMemoryQueue("resize", func(s string) bool {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		started <- struct{}{}
+		<-release
+		atomic.AddInt32(&inFlight, -1)
+		return true
+	}).WithMaxConsumeDuration(time.Minute)
+	queue.StartConsume()
+	defer queue.Shutdown(context.Background())
+
+	// grow from 1 to 3 consumers while consuming
+	queue.SetConcurrent(3)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, queue.SendDelayMsg(strconv.Itoa(i), 0))
+	}
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&inFlight))
+
+	// shrink to 1 consumer, the consumers in excess stop after their current message
+	queue.SetConcurrent(1)
+	for i := 0; i < 3; i++ {
+		release <- struct{}{}
+	}
+	atomic.StoreInt32(&maxInFlight, 0)
+	for i := 3; i < 6; i++ {
+		assert.NoError(t, queue.SendDelayMsg(strconv.Itoa(i), 0))
+	}
+	for i := 3; i < 6; i++ {
+		<-started
+		release <- struct{}{}
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&maxInFlight))
+}
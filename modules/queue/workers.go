@@ -0,0 +1,44 @@
+package queue
+
+import "sync"
+
+// workerPool counts the consumers of a running queue, so that their number can change without restarting the queue.
+type workerPool struct {
+	mu sync.Mutex
+	// running is the number of consumers running, including the ones which will stop after their current message
+	running int
+	target  int
+}
+
+// reset is called when the queue starts consuming with n consumers.
+func (p *workerPool) reset(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running = n
+	p.target = n
+}
+
+// resize sets the number of consumers, and returns the number of consumers to start.
+// The consumers in excess stop after their current message, see stop.
+func (p *workerPool) resize(n int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.target = n
+	if n <= p.running {
+		return 0
+	}
+	start := n - p.running
+	p.running = n
+	return start
+}
+
+// stop is called by a consumer after each message, it reports whether the consumer must exit to reduce the number of consumers.
+func (p *workerPool) stop() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running > p.target {
+		p.running--
+		return true
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"net/url"
+	"strings"
+)
+
+// payloadPrefix marks a queue payload carrying a trace context, the context is url encoded and ends at the first line break.
+const payloadPrefix = "goe-trace:"
+
+// WrapPayload prepends the trace context, or other key values such as a request id, to a queue payload, the payload is returned as is if the carrier is empty.
+func WrapPayload(carrier map[string]string, payload string) string {
+	if len(carrier) == 0 {
+		return payload
+	}
+	values := url.Values{}
+	for k, v := range carrier {
+		values.Set(k, v)
+	}
+	return payloadPrefix + values.Encode() + "\n" + payload
+}
+
+// UnwrapPayload splits a payload created by WrapPayload into the trace context and the original payload.
+// A payload without trace context is returned as is with a nil carrier.
+func UnwrapPayload(payload string) (map[string]string, string) {
+	if !strings.HasPrefix(payload, payloadPrefix) {
+		return nil, payload
+	}
+	header, body, found := strings.Cut(payload[len(payloadPrefix):], "\n")
+	if !found {
+		return nil, payload
+	}
+	values, err := url.ParseQuery(header)
+	if err != nil {
+		return nil, payload
+	}
+	carrier := make(map[string]string, len(values))
+	for k := range values {
+		carrier[k] = values.Get(k)
+	}
+	return carrier, body
+}
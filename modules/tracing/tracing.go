@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"io"
+	"os"
+)
+
+// InstrumentationName is the name of the tracer used by the goe modules.
+const InstrumentationName = "go.oease.dev/goe"
+
+// Exporters, selected by TRACING_EXPORTER
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+type Config struct {
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+	// Exporter is otlp or stdout, default is otlp
+	Exporter string
+	// Endpoint is the host:port of the OTLP http receiver, empty means the OTEL_EXPORTER_OTLP_ENDPOINT env or localhost:4318
+	Endpoint string
+	// Insecure sends the spans over http instead of https
+	Insecure bool
+	// SampleRatio is the ratio of the new traces which are sampled, traces started by a caller keep the decision of the caller.
+	// 0 or more than 1 means every trace is sampled.
+	SampleRatio float64
+	// Writer is the output of the stdout exporter, default is os.Stdout
+	Writer io.Writer
+}
+
+// Tracing holds the tracer provider of an app, the spans are exported in batches until Shutdown.
+type Tracing struct {
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// New creates the tracer provider with the exporter selected by the config.
+func New(cfg Config) (*Tracing, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", ExporterOTLP:
+		opts := make([]otlptracehttp.Option, 0, 2)
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		// the exporter connects lazily, an unreachable collector does not prevent the app from starting
+		exporter, err = otlptracehttp.New(context.Background(), opts...)
+	case ExporterStdout:
+		writer := cfg.Writer
+		if writer == nil {
+			writer = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(writer))
+	default:
+		return nil, errors.New("unsupported tracing exporter: " + cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewWithExporter(cfg, exporter), nil
+}
+
+// NewWithExporter creates the tracer provider with a custom exporter, such as an in-memory exporter in tests.
+// The Exporter, Endpoint, Insecure and Writer fields of the config are ignored.
+func NewWithExporter(cfg Config, exporter sdktrace.SpanExporter) *Tracing {
+	attrs := []attribute.KeyValue{
+		attribute.String("service.name", cfg.ServiceName),
+	}
+	if cfg.ServiceVersion != "" {
+		attrs = append(attrs, attribute.String("service.version", cfg.ServiceVersion))
+	}
+	if cfg.Environment != "" {
+		attrs = append(attrs, attribute.String("deployment.environment", cfg.Environment))
+	}
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attrs...)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	)
+	return &Tracing{
+		provider:   provider,
+		tracer:     provider.Tracer(InstrumentationName),
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+}
+
+// Provider returns the tracer provider, it can be set as the global provider for third party instrumentations.
+func (t *Tracing) Provider() *sdktrace.TracerProvider {
+	return t.provider
+}
+
+// Tracer returns the tracer of the goe modules, a nil Tracing returns a tracer which records nothing.
+func (t *Tracing) Tracer() trace.Tracer {
+	if t == nil {
+		return noop.NewTracerProvider().Tracer(InstrumentationName)
+	}
+	return t.tracer
+}
+
+// Propagator returns the propagator of the trace context and baggage, in the W3C formats.
+func (t *Tracing) Propagator() propagation.TextMapPropagator {
+	return t.propagator
+}
+
+// Inject returns the trace context of ctx as key values, such as traceparent, it is empty if ctx has no span.
+func (t *Tracing) Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	t.propagator.Inject(ctx, carrier)
+	return carrier
+}
+
+// Extract returns a context continuing the trace context of the key values, such as those returned by Inject.
+func (t *Tracing) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return t.propagator.Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// Shutdown exports the remaining spans and stops the exporter.
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	return t.provider.Shutdown(ctx)
+}
+
+// End records the error on the span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
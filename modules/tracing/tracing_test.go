@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestPropagateThroughPayload(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tr := NewWithExporter(Config{ServiceName: "test"}, exporter)
+
+	ctx, parent := tr.Tracer().Start(context.Background(), "http")
+	payload := WrapPayload(tr.Inject(ctx), `{"to":"jane@example.com"}`)
+	parent.End()
+
+	carrier, body := UnwrapPayload(payload)
+	assert.Equal(t, `{"to":"jane@example.com"}`, body)
+	assert.NotEmpty(t, carrier["traceparent"])
+
+	_, child := tr.Tracer().Start(tr.Extract(context.Background(), carrier), "consume")
+	End(child, errors.New("smtp is down"))
+
+	assert.NoError(t, tr.Provider().ForceFlush(context.Background()))
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, spans[0].SpanContext.TraceID(), spans[1].SpanContext.TraceID())
+	assert.Equal(t, spans[0].SpanContext.SpanID(), spans[1].Parent.SpanID())
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+}
+
+func TestUnwrapPlainPayload(t *testing.T) {
+	carrier, body := UnwrapPayload(`{"id":1}`)
+	assert.Nil(t, carrier)
+	assert.Equal(t, `{"id":1}`, body)
+
+	// nothing is prepended without a span
+	assert.Equal(t, "raw", WrapPayload(nil, "raw"))
+}
+
+func TestStdoutExporter(t *testing.T) {
+	var out bytes.Buffer
+	tr, err := New(Config{ServiceName: "test", Exporter: ExporterStdout, Writer: &out})
+	assert.NoError(t, err)
+
+	_, span := tr.Tracer().Start(context.Background(), "job")
+	span.End()
+	assert.NoError(t, tr.Shutdown(context.Background()))
+	assert.Contains(t, out.String(), `"Name":"job"`)
+
+	_, err = New(Config{Exporter: "zipkin"})
+	assert.Error(t, err)
+}
+
+func TestNilTracing(t *testing.T) {
+	var tr *Tracing
+	_, span := tr.Tracer().Start(context.Background(), "noop")
+	assert.False(t, span.SpanContext().IsValid())
+}
@@ -0,0 +1,133 @@
+package validation
+
+import (
+	"errors"
+	"github.com/gookit/validate"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldError is a failed rule of a field.
+type FieldError struct {
+	// Field is the json name of the field, nested fields are separated by dots, such as address.city
+	Field string `json:"field"`
+	// Rule is the failed rule, such as required
+	Rule string `json:"rule"`
+	// Message is the message of the rule, see the m tag
+	Message string `json:"message"`
+}
+
+// Error is returned by a failed validation, it holds every failed rule of every field, sorted by field and rule.
+// The error handler of goe renders it as {"message": ..., "code": "validation_failed", "errors": {"field": [...]}}.
+type Error struct {
+	Fields []FieldError
+	// out is the validated struct, it is validated again by Localize
+	out any
+}
+
+// Error returns the message of the first failed rule.
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return "validation failed"
+	}
+	return e.Fields[0].Message
+}
+
+// Messages returns the messages of the failed rules by field.
+func (e *Error) Messages() map[string][]string {
+	messages := make(map[string][]string, len(e.Fields))
+	for _, f := range e.Fields {
+		messages[f.Field] = append(messages[f.Field], f.Message)
+	}
+	return messages
+}
+
+// Localize returns the error with the messages of a locale, the struct is validated again with them.
+// The messages are keyed by the names of the rules, such as required or minLength, the labels replacing the {field} of the messages by the json names of the fields.
+// The error itself is returned if it was not returned by a validation, or if the struct is valid now.
+func (e *Error) Localize(messages, labels map[string]string) *Error {
+	if e.out == nil {
+		return e
+	}
+	var structLabels map[string]string
+	if len(labels) > 0 {
+		names := make(map[string]string)
+		jsonNames(reflect.TypeOf(e.out), "", "", names, make(map[reflect.Type]bool))
+		structLabels = make(map[string]string)
+		for path, name := range names {
+			if label, ok := labels[name]; ok {
+				structLabels[path] = label
+			}
+		}
+	}
+	var localized *Error
+	if errors.As(validateStruct(e.out, messages, structLabels), &localized) {
+		return localized
+	}
+	return e
+}
+
+// newError creates the error of the failed rules of out, the struct field names of gookit/validate are replaced with the json names.
+func newError(out any, errs validate.Errors) *Error {
+	names := make(map[string]string)
+	jsonNames(reflect.TypeOf(out), "", "", names, make(map[reflect.Type]bool))
+	e := &Error{out: out}
+	for field, rules := range errs {
+		name, ok := names[field]
+		if !ok {
+			name = field
+		}
+		for rule, message := range rules {
+			e.Fields = append(e.Fields, FieldError{Field: name, Rule: rule, Message: message})
+		}
+	}
+	sort.Slice(e.Fields, func(i, j int) bool {
+		if e.Fields[i].Field != e.Fields[j].Field {
+			return e.Fields[i].Field < e.Fields[j].Field
+		}
+		return e.Fields[i].Rule < e.Fields[j].Rule
+	})
+	return e
+}
+
+// jsonNames maps the paths of the struct fields, such as Address.City, to the paths of their json names, such as address.city.
+// The types being walked are skipped, so that recursive types end.
+func jsonNames(t reflect.Type, path, jsonPath string, names map[string]string, walking map[reflect.Type]bool) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || walking[t] {
+		return
+	}
+	walking[t] = true
+	defer delete(walking, t)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fieldPath := join(path, sf.Name)
+		if name == "" && sf.Anonymous {
+			// the fields of an embedded struct are inlined by json
+			jsonNames(sf.Type, fieldPath, jsonPath, names, walking)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		names[fieldPath] = join(jsonPath, name)
+		jsonNames(sf.Type, fieldPath, join(jsonPath, name), names, walking)
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -1,20 +1,17 @@
 package validation
 
 import (
-	"github.com/gofiber/fiber/v3"
 	"github.com/gookit/goutil/strutil"
 	"github.com/gookit/validate"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"sync"
 )
 
-type FiberValidator struct {
-	once sync.Once
-}
+var setupOnce sync.Once
 
-func NewFiberValidator() *FiberValidator {
-	fv := &FiberValidator{}
-	fv.once.Do(func() {
+// setup configures the global options of gookit/validate once: the rules are read from the v tag, the messages from the m tag, and the id rule checks ObjectIDs.
+func setup() {
+	setupOnce.Do(func() {
 		validate.Config(func(opt *validate.GlobalOption) {
 			opt.ValidateTag = "v"
 			opt.MessageTag = "m"
@@ -37,13 +34,41 @@ func NewFiberValidator() *FiberValidator {
 			"id": "{field} is not a valid ID",
 		})
 	})
-	return fv
 }
 
-func (f *FiberValidator) Handler(out any) error {
+// FiberValidator validates the structs bound by fiber, it is the StructValidator of the fiber apps of goe.
+type FiberValidator struct {
+}
+
+// NewFiberValidator creates a validator, the global options of gookit/validate are configured on first use.
+func NewFiberValidator() *FiberValidator {
+	setup()
+	return &FiberValidator{}
+}
+
+// Validate validates out with the rules of its v tags, it returns an *Error holding every failed rule.
+func (f *FiberValidator) Validate(out any) error {
+	return validateStruct(out, nil, nil)
+}
+
+// validateStruct validates out, the messages of the rules and the labels of the fields, keyed by the struct paths of the fields, replace the global ones.
+func validateStruct(out any, messages, labels map[string]string) error {
 	v := validate.Struct(out)
+	// every field is checked, so that all the failed rules are reported at once
+	v.StopOnError = false
+	if len(messages) > 0 {
+		v.WithMessages(messages)
+	}
+	if len(labels) > 0 {
+		v.WithTranslates(labels)
+	}
 	if !v.Validate() {
-		return fiber.NewError(fiber.StatusBadRequest, v.Errors.One())
+		return newError(out, v.Errors)
 	}
 	return nil
 }
+
+// Handler validates out, it is the same as Validate.
+func (f *FiberValidator) Handler(out any) error {
+	return f.Validate(out)
+}
@@ -0,0 +1,74 @@
+package validation
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reflect"
+	"testing"
+)
+
+type address struct {
+	City string `json:"city" v:"required"`
+}
+
+type signup struct {
+	Name    string  `json:"name" v:"required"`
+	Email   string  `json:"email" v:"email" m:"email must be a valid address"`
+	OwnerID string  `json:"owner_id" v:"id"`
+	Address address `json:"address"`
+}
+
+func TestValidateReportsEveryField(t *testing.T) {
+	v := NewFiberValidator()
+	err := v.Validate(&signup{Email: "jane", OwnerID: "42", Address: address{}})
+
+	var ve *Error
+	require.True(t, errors.As(err, &ve))
+	fields := make([]string, len(ve.Fields))
+	for i, f := range ve.Fields {
+		fields[i] = f.Field
+	}
+	assert.Equal(t, []string{"address.city", "email", "name", "owner_id"}, fields)
+	assert.Equal(t, "email", ve.Fields[1].Rule)
+	assert.Equal(t, []string{"email must be a valid address"}, ve.Messages()["email"])
+	assert.Equal(t, ve.Fields[0].Message, ve.Error())
+}
+
+func TestValidatePasses(t *testing.T) {
+	v := NewFiberValidator()
+	assert.NoError(t, v.Validate(&signup{Name: "Jane", Address: address{City: "Paris"}}))
+	assert.NoError(t, v.Handler(&signup{Name: "Jane", Address: address{City: "Paris"}}))
+}
+
+func TestJSONNames(t *testing.T) {
+	type Base struct {
+		ID string `json:"id"`
+	}
+	type node struct {
+		Base
+		Title    string `json:"title,omitempty"`
+		Comment  string
+		Hidden   string `json:"-"`
+		Parent   *node  `json:"parent"`
+		internal string
+	}
+	names := make(map[string]string)
+	jsonNames(reflect.TypeOf(&node{}), "", "", names, make(map[reflect.Type]bool))
+	assert.Equal(t, map[string]string{"Base.ID": "id", "Title": "title", "Comment": "Comment", "Parent": "parent"}, names)
+}
+
+func TestLocalize(t *testing.T) {
+	err := NewFiberValidator().Validate(&signup{Name: "Jane", Address: address{}})
+
+	var ve *Error
+	require.True(t, errors.As(err, &ve))
+	localized := ve.Localize(map[string]string{"required": "{field} ist erforderlich"}, map[string]string{"address.city": "Stadt"})
+	require.Len(t, localized.Fields, 1)
+	assert.Equal(t, "address.city", localized.Fields[0].Field)
+	assert.Equal(t, "Stadt ist erforderlich", localized.Error())
+
+	// an error which was not returned by a validation is kept
+	e := &Error{Fields: []FieldError{{Field: "name", Rule: "required", Message: "name is required"}}}
+	assert.Same(t, e, e.Localize(map[string]string{"required": "{field} ist erforderlich"}, nil))
+}
@@ -0,0 +1,147 @@
+package view
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+// ContentTemplate is the name of the rendered view in its layout, the layouts render it with {{ template "content" . }}.
+const ContentTemplate = "content"
+
+// Config configures an Engine.
+type Config struct {
+	// Extension is the extension of the view files, default is .html
+	Extension string
+	// Reload parses the views again before each render, so that the changes of the files are rendered without a restart, such as in development
+	Reload bool
+	// Funcs are added to the functions of the views
+	Funcs template.FuncMap
+}
+
+// Engine renders the html/template views of one or more fs.FS, such as an embed.FS, it is the fiber.Views of the apps of goe.
+// The name of a view is its path without the extension, such as users/show for users/show.html, every view can include the others with the template action.
+// It is safe for concurrent use.
+type Engine struct {
+	cfg   Config
+	fsyss []fs.FS
+	mu    sync.RWMutex
+	// base holds the parsed views, it is never executed so that it can be cloned for each view and layout
+	base *template.Template
+	// sets caches the templates of the rendered views and layouts
+	sets map[string]*template.Template
+}
+
+// New creates an engine rendering the views of fsys, the views of the last fs.FS overwrite the views with the same name.
+// The views are parsed by Load.
+func New(cfg Config, fsys ...fs.FS) *Engine {
+	if cfg.Extension == "" {
+		cfg.Extension = ".html"
+	}
+	if !strings.HasPrefix(cfg.Extension, ".") {
+		cfg.Extension = "." + cfg.Extension
+	}
+	return &Engine{
+		cfg:   cfg,
+		fsyss: fsys,
+		base:  template.New(""),
+		sets:  make(map[string]*template.Template),
+	}
+}
+
+// Load parses the views, it is called by fiber when the app is created.
+func (e *Engine) Load() error {
+	base := template.New("").Funcs(e.cfg.Funcs)
+	for _, fsys := range e.fsyss {
+		err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(path, e.cfg.Extension) {
+				return nil
+			}
+			data, err := fs.ReadFile(fsys, path)
+			if err != nil {
+				return err
+			}
+			if _, err := base.New(strings.TrimSuffix(path, e.cfg.Extension)).Parse(string(data)); err != nil {
+				return fmt.Errorf("invalid view %s: %w", path, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.base = base
+	e.sets = make(map[string]*template.Template)
+	return nil
+}
+
+// Has reports whether the view exists.
+func (e *Engine) Has(name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.base.Lookup(name) != nil
+}
+
+// Render renders the view with the binding, in the first layout if any.
+func (e *Engine) Render(out io.Writer, name string, binding any, layout ...string) error {
+	if e.cfg.Reload {
+		if err := e.Load(); err != nil {
+			return err
+		}
+	}
+	l := ""
+	if len(layout) > 0 {
+		l = layout[0]
+	}
+	set, err := e.set(name, l)
+	if err != nil {
+		return err
+	}
+	if l == "" {
+		return set.ExecuteTemplate(out, name, binding)
+	}
+	return set.ExecuteTemplate(out, l, binding)
+}
+
+// set returns the templates rendering the view in the layout, the view is the content template of the layout.
+func (e *Engine) set(name, layout string) (*template.Template, error) {
+	key := name + "\x00" + layout
+	e.mu.RLock()
+	set, ok := e.sets[key]
+	e.mu.RUnlock()
+	if ok {
+		return set, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if set, ok := e.sets[key]; ok {
+		return set, nil
+	}
+	view := e.base.Lookup(name)
+	if view == nil {
+		return nil, fmt.Errorf("view %s not found", name)
+	}
+	set, err := e.base.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if layout != "" {
+		if set.Lookup(layout) == nil {
+			return nil, fmt.Errorf("layout %s not found", layout)
+		}
+		if _, err := set.AddParseTree(ContentTemplate, view.Tree); err != nil {
+			return nil, err
+		}
+	}
+	e.sets[key] = set
+	return set, nil
+}
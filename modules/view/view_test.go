@@ -0,0 +1,68 @@
+package view
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func render(t *testing.T, e *Engine, name string, binding any, layout ...string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, e.Render(&buf, name, binding, layout...))
+	return buf.String()
+}
+
+func TestRender(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/main.html":  {Data: []byte(`<main>{{ template "partials/nav" . }}{{ template "content" . }}</main>`)},
+		"partials/nav.html":  {Data: []byte(`<nav>{{ upper .Brand }}</nav>`)},
+		"users/show.html":    {Data: []byte(`<h1>{{ .Name }}</h1>`)},
+		"errors/404.html":    {Data: []byte(`not found`)},
+		"users/show.txt":     {Data: []byte(`ignored`)},
+		"assets/style.css":   {Data: []byte(`body {}`)},
+		"layouts/plain.html": {Data: []byte(`<div>{{ template "content" . }}</div>`)},
+	}
+	e := New(Config{Funcs: template.FuncMap{"upper": strings.ToUpper}}, fsys)
+	require.NoError(t, e.Load())
+
+	binding := map[string]any{"Name": "<Jane>", "Brand": "goe"}
+	assert.Equal(t, `<h1>&lt;Jane&gt;</h1>`, render(t, e, "users/show", binding))
+	assert.Equal(t, `<main><nav>GOE</nav><h1>&lt;Jane&gt;</h1></main>`, render(t, e, "users/show", binding, "layouts/main"))
+	// the cached templates of a layout do not mix the views
+	assert.Equal(t, `<main><nav>GOE</nav>not found</main>`, render(t, e, "errors/404", binding, "layouts/main"))
+	assert.Equal(t, `<div><h1>&lt;Jane&gt;</h1></div>`, render(t, e, "users/show", binding, "layouts/plain"))
+
+	assert.True(t, e.Has("errors/404"))
+	assert.False(t, e.Has("errors/500"))
+	assert.ErrorContains(t, e.Render(&bytes.Buffer{}, "errors/500", nil), "view errors/500 not found")
+	assert.ErrorContains(t, e.Render(&bytes.Buffer{}, "users/show", nil, "layouts/none"), "layout layouts/none not found")
+}
+
+func TestOverwrite(t *testing.T) {
+	builtin := fstest.MapFS{
+		"index.tmpl":  {Data: []byte(`builtin`)},
+		"footer.tmpl": {Data: []byte(`footer`)},
+	}
+	app := fstest.MapFS{"index.tmpl": {Data: []byte(`app {{ template "footer" }}`)}}
+	e := New(Config{Extension: "tmpl"}, builtin, app)
+	require.NoError(t, e.Load())
+	assert.Equal(t, "app footer", render(t, e, "index", nil))
+}
+
+func TestReload(t *testing.T) {
+	fsys := fstest.MapFS{"index.html": {Data: []byte(`v1`)}}
+	e := New(Config{Reload: true}, fsys)
+	require.NoError(t, e.Load())
+	assert.Equal(t, "v1", render(t, e, "index", nil))
+
+	fsys["index.html"] = &fstest.MapFile{Data: []byte(`v2`)}
+	assert.Equal(t, "v2", render(t, e, "index", nil))
+
+	fsys["index.html"] = &fstest.MapFile{Data: []byte(`{{ .Broken `)}
+	assert.ErrorContains(t, e.Render(&bytes.Buffer{}, "index", nil), "invalid view index.html")
+}
@@ -0,0 +1,194 @@
+package goe
+
+import (
+	"go.oease.dev/goe/contracts"
+	"go.oease.dev/goe/core"
+	"go.oease.dev/goe/modules/config"
+	"io"
+	"io/fs"
+)
+
+// Option configures the App created by NewApp.
+type Option func(o *appOptions)
+
+type appOptions struct {
+	configDir string
+	config    contracts.Config
+	// configOverrides holds the config values with the highest precedence, such as the command line flags
+	configOverrides map[string]string
+	secretProviders []config.SecretProvider
+	secretFiles     []string
+	logger          contracts.Logger
+	logWriters      []io.Writer
+	// modules holds built-in modules explicitly enabled (true) or disabled (false), others follow the configuration
+	modules map[string]bool
+	// provided holds built-in modules replaced by implementations given by the application
+	provided      map[string]core.Module
+	customModules []core.Module
+	i18nCatalogs  []fs.FS
+	views         []fs.FS
+}
+
+func newAppOptions(opts ...Option) *appOptions {
+	o := &appOptions{
+		configDir:       "./configs",
+		configOverrides: make(map[string]string),
+		modules:         make(map[string]bool),
+		provided:        make(map[string]core.Module),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// isEnabled reports whether the built-in module is enabled, falling back to the default when it is not set explicitly.
+func (o *appOptions) isEnabled(name string, defaultValue bool) bool {
+	if enabled, ok := o.modules[name]; ok {
+		return enabled
+	}
+	return defaultValue
+}
+
+// WithConfigDir sets the directory to read the config files and the .env files from, default is "./configs".
+func WithConfigDir(dir string) Option {
+	return func(o *appOptions) {
+		o.configDir = dir
+	}
+}
+
+// WithConfig uses the given config instead of reading the config directory.
+func WithConfig(cfg contracts.Config) Option {
+	return func(o *appOptions) {
+		o.config = cfg
+	}
+}
+
+// WithConfigOverrides sets config values taking precedence over the config files and the environment variables, such as the command line flags.
+// The keys can be given as environment variable names or as nested keys, so HTTP_PORT and http.port are the same key.
+// They are ignored when the config is given by WithConfig.
+func WithConfigOverrides(values map[string]string) Option {
+	return func(o *appOptions) {
+		for k, v := range values {
+			o.configOverrides[k] = v
+		}
+	}
+}
+
+// WithSecretProvider resolves the config values prefixed by the scheme of the provider, such as vault:secret/db#password.
+// It is ignored when the config is given by WithConfig.
+func WithSecretProvider(p config.SecretProvider) Option {
+	return func(o *appOptions) {
+		o.secretProviders = append(o.secretProviders, p)
+	}
+}
+
+// WithSecretFiles lets the values of the app config keys be read from the file of KEY_FILE, such as PAYMENT_WEBHOOK_FILE.
+// The secret fields of the goe config are read from files already, see config.SecretKeys for the keys of an app config struct.
+// It is ignored when the config is given by WithConfig.
+func WithSecretFiles(keys ...string) Option {
+	return func(o *appOptions) {
+		o.secretFiles = append(o.secretFiles, keys...)
+	}
+}
+
+// WithLogWriter adds an output to the built-in logger, such as a log shipper, besides the outputs of LOG_OUTPUTS.
+// It is ignored when the logger is given by WithLogger.
+func WithLogWriter(w io.Writer) Option {
+	return func(o *appOptions) {
+		o.logWriters = append(o.logWriters, w)
+	}
+}
+
+// WithLogger uses the given logger instead of creating a zap logger from APP_ENV.
+func WithLogger(l contracts.Logger) Option {
+	return func(o *appOptions) {
+		o.logger = l
+	}
+}
+
+// WithModules explicitly enables the given built-in modules, regardless of the feature configuration.
+// An explicitly enabled module that fails to initialize makes NewApp return an error.
+func WithModules(names ...string) Option {
+	return func(o *appOptions) {
+		for _, name := range names {
+			o.modules[name] = true
+		}
+	}
+}
+
+// WithoutModules explicitly disables the given built-in modules, regardless of the feature configuration.
+func WithoutModules(names ...string) Option {
+	return func(o *appOptions) {
+		for _, name := range names {
+			o.modules[name] = false
+		}
+	}
+}
+
+// WithModule registers an application module, it is initialized together with the built-in modules in dependency order.
+func WithModule(m core.Module) Option {
+	return func(o *appOptions) {
+		o.customModules = append(o.customModules, m)
+	}
+}
+
+// WithI18nCatalogs loads the json and yaml message catalogs at the root of fsys, such as an embed.FS of the app, into the i18n module.
+// The catalogs of I18N_DIR are loaded after them, so that they can be overwritten when the app is deployed.
+func WithI18nCatalogs(fsys fs.FS) Option {
+	return func(o *appOptions) {
+		o.i18nCatalogs = append(o.i18nCatalogs, fsys)
+	}
+}
+
+// WithViews renders the html/template views of fsys, such as an embed.FS of the app, with ctx.Render, see view.Engine.
+// The views of VIEW_DIR are loaded after them, the errors/<status> and errors/error views replace the built-in error page.
+func WithViews(fsys fs.FS) Option {
+	return func(o *appOptions) {
+		o.views = append(o.views, fsys)
+	}
+}
+
+// WithDB uses the given MongoDB implementation instead of connecting to MongoDB, e.g. a fake in tests.
+func WithDB(db contracts.MongoDB) Option {
+	return func(o *appOptions) {
+		o.provided[core.ModuleMongoDB] = core.ProvideMongoDB(db)
+	}
+}
+
+// WithSearch uses the given Meilisearch implementation instead of connecting to Meilisearch.
+func WithSearch(search contracts.Meilisearch) Option {
+	return func(o *appOptions) {
+		o.provided[core.ModuleMeilisearch] = core.ProvideMeilisearch(search)
+	}
+}
+
+// WithQueue uses the given queue implementation instead of the built-in queue.
+func WithQueue(queue contracts.Queue) Option {
+	return func(o *appOptions) {
+		o.provided[core.ModuleQueue] = core.ProvideQueue(queue)
+	}
+}
+
+// WithCache uses the given cache implementation instead of the built-in cache.
+func WithCache(cache contracts.Cache) Option {
+	return func(o *appOptions) {
+		o.provided[core.ModuleCache] = core.ProvideCache(cache)
+	}
+}
+
+// WithMailer uses the given mailer implementation instead of the built-in mailer.
+func WithMailer(mailer contracts.Mailer) Option {
+	return func(o *appOptions) {
+		o.provided[core.ModuleMailer] = core.ProvideMailer(mailer)
+	}
+}
+
+// WithEMQX uses the given EMQX implementation instead of connecting to the broker.
+func WithEMQX(emqx contracts.EMQX) Option {
+	return func(o *appOptions) {
+		o.provided[core.ModuleEMQX] = core.ProvideEMQX(emqx)
+	}
+}
@@ -0,0 +1,249 @@
+package webresult
+
+import (
+	"encoding"
+	"fmt"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/binder"
+	"go.oease.dev/goe/core"
+	"go.oease.dev/goe/modules/problem"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HandlerFunc is a typed handler, it receives the bound and validated request and returns the data of the result.
+type HandlerFunc[Req any, Resp any] func(ctx fiber.Ctx, req *Req) (*Resp, error)
+
+// Operation is a route registered by Route, with the types of its request and its response, such as for an OpenAPI generator.
+type Operation struct {
+	Method   string
+	Path     string
+	Request  reflect.Type
+	Response reflect.Type
+}
+
+// operations holds the operations of the routes of an app, it is a resource of the container of the app.
+type operations struct {
+	mu   sync.RWMutex
+	list []Operation
+}
+
+func operationsOf(c *core.Container) *operations {
+	return c.Resource("webresult.operations", func() any {
+		return &operations{}
+	}).(*operations)
+}
+
+// Handle creates a fiber handler of a typed handler, the boilerplate at the top of the handlers is done before calling it:
+// the request is bound from the body, by the Content-Type of the request, from the query string into the fields with a query tag,
+// from the headers into the fields with a header tag and from the route parameters into the fields with an uri tag.
+// The fields without these tags are bound from the body only, so that a query or a header cannot set them, such as a role,
+// and the parameters are bound last so that they cannot be overwritten.
+// The request is then validated with its v tags by the validator of the app.
+// A binding error is the bad_request error, the validation errors and the errors returned by the handler are rendered by the error handler of the app,
+// see webresult.NotFound and problem.Register. The response is sent as the data of a succeeded WebResult.
+func Handle[Req any, Resp any](fn HandlerFunc[Req, Resp]) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		req := new(Req)
+		if err := bind(ctx, req); err != nil {
+			return err
+		}
+		if validator := ctx.App().Config().StructValidator; validator != nil {
+			if err := validator.Validate(req); err != nil {
+				return err
+			}
+		}
+		resp, err := fn(ctx, req)
+		if err != nil {
+			return err
+		}
+		if resp == nil {
+			return SendSucceed(ctx)
+		}
+		return SendSucceed(ctx, resp)
+	}
+}
+
+// Route registers a typed handler on the router, such as an app or a group, after the middlewares, see Handle.
+// The route is recorded with the types of the handler in the operations of the app owning the given container, see Operations.
+func Route[Req any, Resp any](c *core.Container, router fiber.Router, method, path string, fn HandlerFunc[Req, Resp], middleware ...fiber.Handler) fiber.Router {
+	fullPath := path
+	if group, ok := router.(*fiber.Group); ok {
+		fullPath = strings.TrimSuffix(group.Prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+	}
+	ops := operationsOf(c)
+	ops.mu.Lock()
+	ops.list = append(ops.list, Operation{
+		Method:   strings.ToUpper(method),
+		Path:     fullPath,
+		Request:  reflect.TypeOf((*Req)(nil)).Elem(),
+		Response: reflect.TypeOf((*Resp)(nil)).Elem(),
+	})
+	ops.mu.Unlock()
+	return router.Add([]string{strings.ToUpper(method)}, path, Handle(fn), middleware...)
+}
+
+// Operations returns the routes registered by Route in the app owning the given container, sorted by path and method.
+func Operations(c *core.Container) []Operation {
+	ops := operationsOf(c)
+	ops.mu.RLock()
+	defer ops.mu.RUnlock()
+	list := append([]Operation{}, ops.list...)
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Path != list[j].Path {
+			return list[i].Path < list[j].Path
+		}
+		return list[i].Method < list[j].Method
+	})
+	return list
+}
+
+// bind binds the body, the query string, the headers and the route parameters of the request into out, without validating it.
+func bind(ctx fiber.Ctx, out any) error {
+	request := &ctx.RequestCtx().Request
+	if body := ctx.Body(); len(body) > 0 {
+		ctype, _, _ := strings.Cut(strings.ToLower(ctx.Get(fiber.HeaderContentType)), ";")
+		var err error
+		switch strings.TrimSpace(ctype) {
+		case fiber.MIMEApplicationJSON:
+			err = ctx.App().Config().JSONDecoder(body, out)
+		case fiber.MIMEApplicationXML, fiber.MIMETextXML:
+			err = ctx.App().Config().XMLDecoder(body, out)
+		case fiber.MIMEApplicationForm, fiber.MIMEMultipartForm:
+			err = (&binder.FormBinding{}).Bind(request, out)
+		default:
+			return fiber.ErrUnsupportedMediaType
+		}
+		if err != nil {
+			return problem.BadRequest.Wrap(err)
+		}
+	}
+	v := reflect.ValueOf(out).Elem()
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	err := bindTagged(v, "query", func(key string) []string {
+		var values []string
+		for _, value := range request.URI().QueryArgs().PeekMulti(key) {
+			values = append(values, string(value))
+		}
+		return values
+	})
+	if err == nil {
+		err = bindTagged(v, "header", func(key string) []string {
+			var values []string
+			for _, value := range request.Header.PeekAll(key) {
+				values = append(values, string(value))
+			}
+			return values
+		})
+	}
+	if err == nil {
+		err = bindTagged(v, "uri", func(key string) []string {
+			if !slices.Contains(ctx.Route().Params, key) {
+				return nil
+			}
+			return []string{strings.Clone(ctx.Params(key))}
+		})
+	}
+	if err != nil {
+		return problem.BadRequest.Wrap(err)
+	}
+	return nil
+}
+
+// bindTagged sets the fields of the struct v which have the tag to the values returned by get for the name of their tag,
+// the fields of the embedded structs are bound as well. The fields without the tag are not changed.
+func bindTagged(v reflect.Value, tag string, get func(key string) []string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		fv := v.Field(i)
+		if name == "" && field.Anonymous && fv.Kind() == reflect.Struct {
+			if err := bindTagged(fv, tag, get); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		values := get(name)
+		if len(values) == 0 {
+			continue
+		}
+		if err := setValues(fv, values); err != nil {
+			return fmt.Errorf("%s %s: %w", tag, name, err)
+		}
+	}
+	return nil
+}
+
+// setValues sets the field to the values, a slice gets all of them and the other fields the first one.
+func setValues(fv reflect.Value, values []string) error {
+	if fv.Kind() == reflect.Slice && !isTextUnmarshaler(fv) {
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return setValue(fv, values[0])
+}
+
+func setValue(fv reflect.Value, value string) error {
+	if fv.Kind() == reflect.Pointer {
+		elem := reflect.New(fv.Type().Elem())
+		if err := setValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	}
+	if isTextUnmarshaler(fv) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
+
+func isTextUnmarshaler(fv reflect.Value) bool {
+	return fv.CanAddr() && fv.Addr().Type().Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem())
+}
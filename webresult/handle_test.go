@@ -0,0 +1,115 @@
+package webresult_test
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.oease.dev/goe/goetest"
+	"go.oease.dev/goe/webresult"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type paging struct {
+	Page int `query:"page"`
+}
+
+type updateItemRequest struct {
+	paging
+	ID     string   `uri:"id"`
+	Tenant string   `header:"X-Tenant" json:"-"`
+	Tags   []string `query:"tag" json:"-"`
+	Notify *bool    `query:"notify" json:"-"`
+	Name   string   `json:"name" v:"required"`
+	// Role is set by the body only, the query and the headers cannot set the fields without their tag
+	Role string `json:"role"`
+}
+
+type itemResponse struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Role   string   `json:"role"`
+	Tenant string   `json:"tenant"`
+	Tags   []string `json:"tags"`
+	Page   int      `json:"page"`
+	Notify bool     `json:"notify"`
+}
+
+func newItemsApp(t *testing.T) *goetest.Harness {
+	h := goetest.New(t)
+	app := h.App.Fiber().App()
+	webresult.Route(h.App.Container(), app, fiber.MethodPut, "/items/:id", func(ctx fiber.Ctx, req *updateItemRequest) (*itemResponse, error) {
+		if req.ID == "missing" {
+			return nil, webresult.NotFound()
+		}
+		return &itemResponse{
+			ID: req.ID, Name: req.Name, Role: req.Role, Tenant: req.Tenant, Tags: req.Tags, Page: req.Page, Notify: req.Notify != nil && *req.Notify,
+		}, nil
+	})
+	webresult.Route(h.App.Container(), app.Group("/api/"), fiber.MethodDelete, "items/:id", func(ctx fiber.Ctx, req *struct{}) (*struct{}, error) {
+		return nil, nil
+	})
+	return h
+}
+
+func TestHandleBinding(t *testing.T) {
+	h := newItemsApp(t)
+	resp := h.Client().
+		WithHeader("X-Tenant", "acme").
+		WithHeader("Role", "admin").
+		WithHeader("ID", "header-id").
+		Put("/items/42?page=2&tag=a&tag=b&notify=true&role=admin&name=query", map[string]any{"name": "lamp", "id": "body-id"})
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.String())
+	var result struct {
+		Message string       `json:"message"`
+		Data    itemResponse `json:"data"`
+	}
+	require.NoError(t, resp.JSON(&result))
+	assert.Equal(t, "success", result.Message)
+	assert.Equal(t, itemResponse{ID: "42", Name: "lamp", Tenant: "acme", Tags: []string{"a", "b"}, Page: 2, Notify: true}, result.Data)
+
+	// the body sets the fields without a query or header tag
+	resp = h.Client().Put("/items/42?role=admin", map[string]any{"name": "lamp", "role": "member"})
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.String())
+	require.NoError(t, resp.JSON(&result))
+	assert.Equal(t, "member", result.Data.Role)
+}
+
+func TestHandleErrors(t *testing.T) {
+	h := newItemsApp(t)
+	client := h.Client().WithHeader(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+	// the validation error of the required name
+	resp := client.Put("/items/42", map[string]any{"role": "member"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, resp.String())
+	assert.Contains(t, resp.String(), "name is required")
+
+	resp = client.Put("/items/42?page=two", map[string]any{"name": "lamp"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, resp.String())
+	assert.Contains(t, resp.String(), "bad_request")
+
+	resp = client.WithHeader(fiber.HeaderContentType, fiber.MIMETextPlain).Put("/items/42", "lamp")
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode, resp.String())
+
+	// the errors of the handler go to the error handler of the app
+	resp = h.Client().Put("/items/missing", map[string]any{"name": "lamp"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, resp.String())
+
+	// a nil response is a succeeded result without data
+	resp = h.Client().Delete("/api/items/42")
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.String())
+	assert.JSONEq(t, `{"message": "success"}`, resp.String())
+}
+
+func TestOperations(t *testing.T) {
+	h := newItemsApp(t)
+	assert.Equal(t, []webresult.Operation{
+		{Method: fiber.MethodDelete, Path: "/api/items/:id", Request: reflect.TypeOf(struct{}{}), Response: reflect.TypeOf(struct{}{})},
+		{Method: fiber.MethodPut, Path: "/items/:id", Request: reflect.TypeOf(updateItemRequest{}), Response: reflect.TypeOf(itemResponse{})},
+	}, webresult.Operations(h.App.Container()))
+
+	// the operations belong to their app
+	other := goetest.New(t)
+	assert.Empty(t, webresult.Operations(other.App.Container()))
+	assert.Len(t, webresult.Operations(h.App.Container()), 2)
+}
@@ -3,6 +3,7 @@ package webresult
 import (
 	"github.com/gofiber/fiber/v3"
 	"go.oease.dev/goe"
+	"go.oease.dev/goe/core"
 	"go.uber.org/zap"
 )
 
@@ -11,6 +12,9 @@ type WebResult struct {
 	Data    any    `json:"data,omitempty"`
 }
 
+// InvalidParam returns a 400 error, the message is translated to the locale of the request by the error handler of goe when the i18n module is enabled,
+// as are the messages of Unauthorized, Forbidden, NotFound and SystemBusy. The messages are the keys of the catalogs, such as "invalid request data".
+// The json errors have the code of their status, such as bad_request, see problem.ForStatus, problem.Register registers the application errors.
 func InvalidParam(msg ...string) *fiber.Error {
 	if len(msg) > 0 && len(msg[0]) > 0 {
 		return fiber.NewError(fiber.StatusBadRequest, msg[0])
@@ -42,12 +46,13 @@ func SendSucceed(ctx fiber.Ctx, data ...any) error {
 	return ctx.Status(fiber.StatusOK).JSON(result)
 }
 
+// SendFailed sends a 400 result, the message is translated to the locale of the request, see core.T.
 func SendFailed(ctx fiber.Ctx, msg string, data ...any) error {
 	if msg == "" {
 		msg = "operation failed"
 	}
 	result := &WebResult{
-		Message: msg,
+		Message: core.T(ctx, msg),
 	}
 	if len(data) > 0 && data[0] != nil {
 		result.Data = data[0]
@@ -62,9 +67,30 @@ func NotFound(msg ...string) error {
 	return fiber.NewError(fiber.StatusNotFound, "resource not found")
 }
 
+// SystemBusy logs the error with the logger of the default app and returns a 500 error which does not expose it.
+// The log has no request_id and trace_id, and goes to the first app created when several apps run in one process.
+//
+// Deprecated: use SystemBusyContext in the handlers, webresult.SystemBusy(err) becomes webresult.SystemBusyContext(ctx, err).
 func SystemBusy(err ...error) error {
+	return systemBusy(nil, err)
+}
+
+// SystemBusyContext logs the error with the logger of the request, see goe.LogFrom, and returns a 500 error which does not expose it.
+// The log has the request_id, method, path and trace_id of the request, the logger of the default app is used when the request has no logger.
+func SystemBusyContext(ctx fiber.Ctx, err ...error) error {
+	return systemBusy(ctx, err)
+}
+
+func systemBusy(ctx fiber.Ctx, err []error) error {
 	if len(err) > 0 && err[0] != nil {
-		goe.UseLog().GetZapSugarLogger().WithOptions(zap.AddCallerSkip(0)).Error(err)
+		// the logged caller is the caller of SystemBusy
+		if logger := core.LoggerFrom(ctx); logger != nil {
+			logger.GetZapSugarLogger().WithOptions(zap.AddCallerSkip(1)).Error(err[0])
+		} else if app := goe.Default(); app != nil {
+			app.Log().GetZapSugarLogger().WithOptions(zap.AddCallerSkip(1)).Error(err[0])
+		} else {
+			zap.S().Error(err[0])
+		}
 	}
 	return fiber.NewError(fiber.StatusInternalServerError, "system busy")
 }
@@ -0,0 +1,47 @@
+package webresult_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.oease.dev/goe"
+	"go.oease.dev/goe/goetest"
+	"go.oease.dev/goe/modules/log"
+	"go.oease.dev/goe/webresult"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestSystemBusyContext(t *testing.T) {
+	var out bytes.Buffer
+	sampling := false
+	logger, err := log.NewWithConfig(log.Config{Format: log.FormatJSON, Writers: []io.Writer{&out}, Sampling: &sampling})
+	require.NoError(t, err)
+	h := goetest.New(t, goetest.WithAppOptions(goe.WithLogger(logger)))
+	h.App.Fiber().App().Get("/orders", func(ctx fiber.Ctx) error {
+		return webresult.SystemBusyContext(ctx, errors.New("connection refused"))
+	})
+
+	resp := h.Client().WithHeader("X-Request-ID", "req-1").Get("/orders")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.NotContains(t, resp.String(), "connection refused")
+
+	var logged []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(line, &entry), string(line))
+		if entry["msg"] == "connection refused" {
+			logged = append(logged, entry)
+		}
+	}
+	require.Len(t, logged, 1, out.String())
+	assert.Equal(t, "error", logged[0]["level"])
+	assert.Equal(t, "req-1", logged[0]["request_id"])
+	assert.Equal(t, "/orders", logged[0]["path"])
+	// the caller is the handler, not the webresult package
+	assert.Contains(t, logged[0]["caller"], "webresult/sends_test.go")
+}